@@ -3,28 +3,70 @@ package handlers
 import (
 	"chatapp/pkg/accesstoken"
 	"chatapp/pkg/models"
+	"chatapp/pkg/notifier"
+	"chatapp/pkg/sso"
 	"chatapp/pkg/util"
+	"chatapp/services/identity"
+	"chatapp/services/loginattempt"
+	"chatapp/services/passwordreset"
+	"chatapp/services/refreshtoken"
+	"chatapp/services/revocation"
+	"chatapp/services/session"
+	"chatapp/services/twofactor"
 	"chatapp/services/user"
+	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"log"
 	"time"
 )
 
 var (
 	errInvalidCredentials = "Invalid username or password provided."
+	errInvalidRefresh     = "Invalid or expired refresh token provided."
+	errTooManyLogins      = "Too many failed login attempts. Please try again later."
 )
 
 type (
 	// AuthHandlerOptions represents the options required to set up the auth handler
 	AuthHandlerOptions struct {
-		UserService user.Service
-		PasetoKey   string
+		UserService          user.Service
+		RefreshTokenService  refreshtoken.Service
+		RevocationService    revocation.Service
+		SessionService       session.Service
+		PasswordResetService passwordreset.Service
+		Notifier             notifier.Notifier
+		TokenMaker           accesstoken.Maker
+		PasswordHasher       *util.PasswordHasher
+		LoginAttemptService  loginattempt.Service
+		TwoFactorService     twofactor.Service
+		IdentityService      identity.Service
+		SSOProviders         map[string]*sso.Provider
+		Encrypter            *util.Encrypter
+		AccessTokenDuration  time.Duration
+		ChallengeDuration    time.Duration
+		AppURL               string
 	}
 
 	// authHandler handles user auth
 	authHandler struct {
-		userService user.Service
-		pasetoKey   string
+		userService          user.Service
+		refreshTokenService  refreshtoken.Service
+		revocationService    revocation.Service
+		sessionService       session.Service
+		passwordResetService passwordreset.Service
+		notifier             notifier.Notifier
+		tokenMaker           accesstoken.Maker
+		passwordHasher       *util.PasswordHasher
+		loginAttemptService  loginattempt.Service
+		twoFactorService     twofactor.Service
+		identityService      identity.Service
+		ssoProviders         map[string]*sso.Provider
+		encrypter            *util.Encrypter
+		accessTokenDuration  time.Duration
+		challengeDuration    time.Duration
+		appURL               string
 	}
 
 	authUser struct {
@@ -36,19 +78,15 @@ type (
 
 	// authUserResponse has fields returned when a user authenticates successfully
 	authUserResponse struct {
-		User  authUser `json:"user,omitempty"`
-		Token string   `json:"token,omitempty"`
+		User         authUser `json:"user,omitempty"`
+		Token        string   `json:"token,omitempty"`
+		RefreshToken string   `json:"refresh_token,omitempty"`
 	}
 )
 
-// generateAccessToken attempts to create an access token to authenticate the user
-func (h *authHandler) generateAccessToken(user *models.User) (string, error) {
-	maker, err := accesstoken.NewPasetoMaker(h.pasetoKey)
-	if err != nil {
-		return "", err
-	}
-
-	token, err := maker.CreateToken(user, 30*time.Minute)
+// generateAccessToken attempts to create an access token to authenticate the user for the session
+func (h *authHandler) generateAccessToken(user *models.User, sessionID uuid.UUID) (string, error) {
+	token, err := h.tokenMaker.CreateToken(user, h.accessTokenDuration, accesstoken.WithSessionID(sessionID))
 	if err != nil {
 		return "", err
 	}
@@ -57,7 +95,7 @@ func (h *authHandler) generateAccessToken(user *models.User) (string, error) {
 }
 
 // successAuthResponse builds the response data used when a user registers or logs in
-func successAuthResponse(user *models.User, token string) authUserResponse {
+func successAuthResponse(user *models.User, token, refreshToken string) authUserResponse {
 	return authUserResponse{
 		User: authUser{
 			ID:        user.ID,
@@ -65,10 +103,66 @@ func successAuthResponse(user *models.User, token string) authUserResponse {
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
 		},
-		Token: token,
+		Token:        token,
+		RefreshToken: refreshToken,
 	}
 }
 
+// issueTokens starts a new session for the user on the requesting device and creates its access and refresh tokens
+func (h *authHandler) issueTokens(c *fiber.Ctx, user *models.User) (authUserResponse, error) {
+	ctx := c.Context()
+
+	newSession, err := h.sessionService.Start(ctx, user.ID, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return authUserResponse{}, err
+	}
+
+	token, err := h.generateAccessToken(user, newSession.ID)
+	if err != nil {
+		return authUserResponse{}, err
+	}
+
+	refreshToken, _, err := h.refreshTokenService.Issue(ctx, user.ID, newSession.ID)
+	if err != nil {
+		return authUserResponse{}, err
+	}
+
+	return successAuthResponse(user, token, refreshToken), nil
+}
+
+// rehashPassword upgrades the user password hash if it was created with an outdated algorithm or parameters.
+// The plain text password is only available when the user logs in, so failing to upgrade does not fail the login.
+func (h *authHandler) rehashPassword(ctx context.Context, userID uint64, hashedPassword, password string) {
+	if !h.passwordHasher.NeedsRehash(hashedPassword) {
+		return
+	}
+
+	newHashedPassword, err := h.passwordHasher.Hash(password)
+	if err == nil {
+		err = h.userService.UpdatePassword(ctx, userID, newHashedPassword)
+	}
+
+	if err != nil {
+		log.Printf("authHandler.rehashPassword:: error upgrading password hash for user %d - %v", userID, err)
+	}
+}
+
+// revokeSessions ends the sessions along with every refresh token and access token issued for them.
+// The sessions themselves must already have been revoked using the session.Service.
+func (h *authHandler) revokeSessions(ctx context.Context, sessionIDs ...uuid.UUID) error {
+	for _, sessionID := range sessionIDs {
+		if err := h.refreshTokenService.RevokeFamily(ctx, sessionID); err != nil {
+			return err
+		}
+
+		if err := h.revocationService.RevokeSession(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Register adds and returns the new user created
 func (h *authHandler) Register(c *fiber.Ctx) error {
 	var u *models.User
@@ -87,7 +181,7 @@ func (h *authHandler) Register(c *fiber.Ctx) error {
 	u.CreatedAt = now
 	u.UpdatedAt = now
 
-	exists, err := h.userService.CheckIfExists(ctx, "username", u.Username)
+	exists, err := h.userService.Exists(ctx, models.Where(models.UserColumnUsername, u.Username))
 	if err != nil {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
@@ -98,7 +192,7 @@ func (h *authHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	hashedPassword, err := util.HashPassword(u.Password)
+	hashedPassword, err := h.passwordHasher.Hash(u.Password)
 	if err != nil {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
@@ -110,12 +204,15 @@ func (h *authHandler) Register(c *fiber.Ctx) error {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
 
-	token, err := h.generateAccessToken(newUser)
+	// The hash is not needed past this point so it cannot end up in a token or a response
+	newUser.Password = ""
+
+	response, err := h.issueTokens(c, newUser)
 	if err != nil {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
 
-	return successResponse(c, fiber.StatusCreated, successAuthResponse(newUser, token))
+	return successResponse(c, fiber.StatusCreated, response)
 }
 
 // Login attempts to log in a user using the provided credentials
@@ -132,30 +229,225 @@ func (h *authHandler) Login(c *fiber.Ctx) error {
 
 	ctx := c.Context()
 
+	retryAfter, err := h.loginAttemptService.Check(ctx, u.Username, c.IP())
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if retryAfter > 0 {
+		return tooManyRequestsError(c, retryAfter, errTooManyLogins)
+	}
+
 	credentials, err := h.userService.GetIDAndPassword(ctx, u.Username)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			return clientError(c, fiber.StatusUnauthorized, errInvalidCredentials)
+			return h.failedLogin(c, u.Username, errInvalidCredentials)
 		}
 
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
 
-	if err := util.CompareHashAndPassword(credentials.Password, u.Password); err != nil {
-		return clientError(c, fiber.StatusUnauthorized, errInvalidCredentials)
+	if err := h.passwordHasher.Compare(credentials.Password, u.Password); err != nil {
+		return h.failedLogin(c, u.Username, errInvalidCredentials)
 	}
 
+	h.rehashPassword(ctx, credentials.ID, credentials.Password, u.Password)
+
 	authUser, err := h.userService.FindByID(ctx, credentials.ID)
 	if err != nil {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
 
-	token, err := h.generateAccessToken(authUser)
+	return h.completeLogin(c, authUser)
+}
+
+// completeLogin issues the tokens for the user who has proven who they are, or the two-factor challenge if they
+// have two-factor authentication enabled
+func (h *authHandler) completeLogin(c *fiber.Ctx, authUser *models.User) error {
+	ctx := c.Context()
+
+	twoFactorEnabled, err := h.twoFactorService.IsEnabled(ctx, authUser.ID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	// The failed logins are only cleared once the user has fully authenticated, otherwise knowing the password
+	// would be enough to keep guessing two-factor codes
+	if twoFactorEnabled {
+		return h.twoFactorChallenge(c, authUser)
+	}
+
+	if err := h.loginAttemptService.RecordSuccess(ctx, authUser.Username); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	response, err := h.issueTokens(c, authUser)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, response)
+}
+
+// failedLogin records the failed login for the username and the IP address it was made from
+func (h *authHandler) failedLogin(c *fiber.Ctx, username, message string) error {
+	retryAfter, err := h.loginAttemptService.RecordFailure(c.Context(), username, c.IP())
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if retryAfter > 0 {
+		return tooManyRequestsError(c, retryAfter, errTooManyLogins)
+	}
+
+	return clientError(c, fiber.StatusUnauthorized, message)
+}
+
+// Refresh exchanges a refresh token for a new access token and a rotated refresh token
+func (h *authHandler) Refresh(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if req.RefreshToken == "" {
+		return validationDuplicateError(c, fiber.Map{
+			"refresh_token": "cannot be blank",
+		})
+	}
+
+	ctx := c.Context()
+
+	refreshToken, rotated, err := h.refreshTokenService.Rotate(ctx, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, refreshtoken.ErrInvalidRefreshToken) || errors.Is(err, refreshtoken.ErrRefreshTokenReused) {
+			return clientError(c, fiber.StatusUnauthorized, errInvalidRefresh)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	authUser, err := h.userService.FindByID(ctx, rotated.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusUnauthorized, errInvalidRefresh)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.sessionService.Touch(ctx, rotated.FamilyID); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	token, err := h.generateAccessToken(authUser, rotated.FamilyID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, successAuthResponse(authUser, token, refreshToken))
+}
+
+// Logout ends the session the request was made from. The access token is revoked as well as, if provided,
+// the refresh token for tokens issued before sessions were introduced.
+func (h *authHandler) Logout(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return serverError(c, fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	ctx := c.Context()
+	payload := getAuthUserTokenPayload(c)
+
+	if err := h.revocationService.Revoke(ctx, payload); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if payload.SessionID != uuid.Nil {
+		err := h.sessionService.Revoke(ctx, payload.User.ID, payload.SessionID)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			return serverError(c, fiber.StatusInternalServerError, err.Error())
+		}
+
+		if err := h.revokeSessions(ctx, payload.SessionID); err != nil {
+			return serverError(c, fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	if req.RefreshToken != "" {
+		err := h.refreshTokenService.Revoke(ctx, req.RefreshToken, payload.User.ID)
+		if err != nil && !errors.Is(err, refreshtoken.ErrInvalidRefreshToken) {
+			return serverError(c, fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Logged out successfully.",
+	})
+}
+
+// Sessions returns the auth user active sessions
+func (h *authHandler) Sessions(c *fiber.Ctx) error {
+	payload := getAuthUserTokenPayload(c)
+
+	sessions, err := h.sessionService.GetActiveSessions(c.Context(), payload.User.ID, payload.SessionID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"sessions": sessions,
+	})
+}
+
+// DestroySession signs the auth user out of one of their sessions
+func (h *authHandler) DestroySession(c *fiber.Ctx) error {
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, "Invalid session id provided.")
+	}
+
+	ctx := c.Context()
+
+	if err := h.sessionService.Revoke(ctx, getAuthUser(c).ID, sessionID); err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusNotFound, "Session not found.")
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.revokeSessions(ctx, sessionID); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Session signed out successfully.",
+	})
+}
+
+// DestroyOtherSessions signs the auth user out of every session except the one the request was made from
+func (h *authHandler) DestroyOtherSessions(c *fiber.Ctx) error {
+	ctx := c.Context()
+	payload := getAuthUserTokenPayload(c)
+
+	sessionIDs, err := h.sessionService.RevokeOthers(ctx, payload.User.ID, payload.SessionID)
 	if err != nil {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
 
-	return successResponse(c, fiber.StatusOK, successAuthResponse(authUser, token))
+	if err := h.revokeSessions(ctx, sessionIDs...); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message":  "Other sessions signed out successfully.",
+		"sessions": len(sessionIDs),
+	})
 }
 
 // getAuthUserTokenPayload parses the value stored from the auth middleware to token payload
@@ -163,21 +455,55 @@ func getAuthUserTokenPayload(c *fiber.Ctx) *accesstoken.Payload {
 	return c.Locals(accesstoken.AuthUserToken).(*accesstoken.Payload)
 }
 
-// getAuthUser returns the current auth user from the token payload
+// getAuthUser returns the current auth user from the token payload, only the id and username are set
 func getAuthUser(c *fiber.Ctx) *models.User {
-	return getAuthUserTokenPayload(c).User
+	subject := getAuthUserTokenPayload(c).User
+
+	return &models.User{
+		ID:       subject.ID,
+		Username: subject.Username,
+	}
 }
 
 // AuthHandler is an interface for the user authentication
 type AuthHandler interface {
 	Register(c *fiber.Ctx) error
 	Login(c *fiber.Ctx) error
+	Refresh(c *fiber.Ctx) error
+	Logout(c *fiber.Ctx) error
+	Sessions(c *fiber.Ctx) error
+	DestroySession(c *fiber.Ctx) error
+	DestroyOtherSessions(c *fiber.Ctx) error
+	ChangePassword(c *fiber.Ctx) error
+	ForgotPassword(c *fiber.Ctx) error
+	ResetPassword(c *fiber.Ctx) error
+	EnrollTwoFactor(c *fiber.Ctx) error
+	ConfirmTwoFactor(c *fiber.Ctx) error
+	DisableTwoFactor(c *fiber.Ctx) error
+	VerifyTwoFactor(c *fiber.Ctx) error
+	OIDCLogin(c *fiber.Ctx) error
+	OIDCCallback(c *fiber.Ctx) error
+	OIDCLink(c *fiber.Ctx) error
 }
 
 // NewAuthHandler creates a new AuthHandler
 func NewAuthHandler(opts AuthHandlerOptions) AuthHandler {
 	return &authHandler{
-		userService: opts.UserService,
-		pasetoKey:   opts.PasetoKey,
+		userService:          opts.UserService,
+		refreshTokenService:  opts.RefreshTokenService,
+		revocationService:    opts.RevocationService,
+		sessionService:       opts.SessionService,
+		passwordResetService: opts.PasswordResetService,
+		notifier:             opts.Notifier,
+		tokenMaker:           opts.TokenMaker,
+		passwordHasher:       opts.PasswordHasher,
+		loginAttemptService:  opts.LoginAttemptService,
+		twoFactorService:     opts.TwoFactorService,
+		identityService:      opts.IdentityService,
+		ssoProviders:         opts.SSOProviders,
+		encrypter:            opts.Encrypter,
+		accessTokenDuration:  opts.AccessTokenDuration,
+		challengeDuration:    opts.ChallengeDuration,
+		appURL:               opts.AppURL,
 	}
 }
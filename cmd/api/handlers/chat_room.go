@@ -2,27 +2,45 @@ package handlers
 
 import (
 	"chatapp/pkg/models"
+	"chatapp/pkg/realtime"
 	"chatapp/services/chatroom"
+	"chatapp/services/user"
+	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"log"
 	"strconv"
 	"time"
 )
 
 var (
 	errInvalidCharRoomID = "Invalid chatroom id provided."
+	errNotChatRoomMember = "You are not a member of this chat room."
+	errInvalidUserID     = "Invalid user id provided."
+	errForbiddenAction   = "You are not allowed to perform this action."
+
+	errAlreadyChatRoomMember = "You are already a member of this chat room."
 )
 
 type (
 	// ChatRoomHandlerOptions represents the options required to set up the chat room handler
 	ChatRoomHandlerOptions struct {
+		Hub             *realtime.Hub
 		ChatRoomService chatroom.Service
+		UserService     user.Service
 	}
 
 	// chatRoomHandler handles chat room interactions
 	chatRoomHandler struct {
+		hub             *realtime.Hub
 		chatRoomService chatroom.Service
+		userService     user.Service
+	}
+
+	// updateMemberRoleRequest has the fields required to change a member's role
+	updateMemberRoleRequest struct {
+		Role models.ChatRoomRole `json:"role"`
 	}
 )
 
@@ -35,17 +53,73 @@ func findChatRoomError(c *fiber.Ctx, err error) error {
 	return serverError(c, fiber.StatusInternalServerError, err.Error())
 }
 
-// Index returns the auth user chat-rooms
+// ensureCanViewChatRoom reports private chat rooms the auth user is not a member of as not found
+func ensureCanViewChatRoom(c *fiber.Ctx, chatRoomService chatroom.Service, chatRoom *models.ChatRoom) error {
+	canView, err := chatRoomService.CanView(c.Context(), chatRoom, getAuthUser(c).ID)
+	if err != nil {
+		return err
+	}
+
+	if !canView {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// findVisibleChatRoomByUUID fetches a chat room by uuid as long as the auth user can view it
+func findVisibleChatRoomByUUID(c *fiber.Ctx, chatRoomService chatroom.Service, chatRoomUUID string) (
+	*models.ChatRoom, error) {
+	chatRoom, err := chatRoomService.FindByUUID(c.Context(), chatRoomUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureCanViewChatRoom(c, chatRoomService, chatRoom); err != nil {
+		return nil, err
+	}
+
+	return chatRoom, nil
+}
+
+// chatRoomActionError returns the errors that occur performing an action that requires a role in the chat room
+func chatRoomActionError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, models.ErrForbidden) {
+		return clientError(c, fiber.StatusForbidden, errForbiddenAction)
+	}
+
+	if errors.Is(err, models.ErrNoRecord) {
+		return clientError(c, fiber.StatusNotFound, "Chat room member not found.")
+	}
+
+	return serverError(c, fiber.StatusInternalServerError, err.Error())
+}
+
+// Index returns the auth user chat-rooms, the direct conversations are listed separately with the other participant
+// and so are the groups
 func (h *chatRoomHandler) Index(c *fiber.Ctx) error {
-	user := getAuthUser(c)
+	ctx := c.Context()
+	authUser := getAuthUser(c)
 
-	chatRooms, err := h.chatRoomService.GetUserChatRooms(c.Context(), user.ID)
+	chatRooms, err := h.chatRoomService.GetUserChatRooms(ctx, authUser.ID, models.ChatRoomKindRoom)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	groupChatRooms, err := h.chatRoomService.GetUserChatRooms(ctx, authUser.ID, models.ChatRoomKindGroup)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	directChatRooms, err := h.chatRoomService.GetUserDirectChatRooms(ctx, authUser.ID)
 	if err != nil {
 		return serverError(c, fiber.StatusInternalServerError, err.Error())
 	}
 
 	return successResponse(c, fiber.StatusOK, fiber.Map{
-		"chat_rooms": chatRooms,
+		"chat_rooms":        chatRooms,
+		"direct_chat_rooms": directChatRooms,
+		"group_chat_rooms":  groupChatRooms,
 	})
 }
 
@@ -64,6 +138,7 @@ func (h *chatRoomHandler) Store(c *fiber.Ctx) error {
 	user := getAuthUser(c)
 	now := time.Now()
 
+	chatRoom.Kind = models.ChatRoomKindRoom
 	chatRoom.UserID = user.ID
 	chatRoom.CreatedAt = now
 	chatRoom.UpdatedAt = now
@@ -98,6 +173,10 @@ func (h *chatRoomHandler) Show(c *fiber.Ctx) error {
 		return findChatRoomError(c, err)
 	}
 
+	if err := ensureCanViewChatRoom(c, h.chatRoomService, chatRoom); err != nil {
+		return findChatRoomError(c, err)
+	}
+
 	return successResponse(c, fiber.StatusOK, fiber.Map{
 		"chatroom": chatRoom,
 	})
@@ -107,7 +186,7 @@ func (h *chatRoomHandler) Show(c *fiber.Ctx) error {
 func (h *chatRoomHandler) GetByUUID(c *fiber.Ctx) error {
 	chatRoomUUID := c.Params("uuid")
 
-	chatRoom, err := h.chatRoomService.FindByUUID(c.Context(), chatRoomUUID)
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, chatRoomUUID)
 	if err != nil {
 		return findChatRoomError(c, err)
 	}
@@ -125,8 +204,8 @@ func (h *chatRoomHandler) Destroy(c *fiber.Ctx) error {
 		return clientError(c, fiber.StatusBadRequest, errInvalidCharRoomID)
 	}
 
-	if err := h.chatRoomService.SoftDelete(c.Context(), uint64(id)); err != nil {
-		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	if err := h.chatRoomService.SoftDelete(c.Context(), uint64(id), getAuthUser(c).ID); err != nil {
+		return chatRoomActionError(c, err)
 	}
 
 	return successResponse(c, fiber.StatusOK, fiber.Map{
@@ -134,18 +213,327 @@ func (h *chatRoomHandler) Destroy(c *fiber.Ctx) error {
 	})
 }
 
+// Update renames a models.ChatRoom
+func (h *chatRoomHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidCharRoomID)
+	}
+
+	var chatRoom *models.ChatRoom
+
+	if err := c.BodyParser(&chatRoom); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := chatRoom.ValidateStoreRequest(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+
+	if err := h.chatRoomService.Rename(ctx, uint64(id), getAuthUser(c).ID, chatRoom.Name); err != nil {
+		return chatRoomActionError(c, err)
+	}
+
+	updatedChatRoom, err := h.chatRoomService.FindByID(ctx, uint64(id))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"chatroom": updatedChatRoom,
+	})
+}
+
+// Join adds the auth user to the chat room members
+func (h *chatRoomHandler) Join(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	if err := h.chatRoomService.AddMember(ctx, chatRoom, getAuthUser(c).ID); err != nil {
+		if errors.Is(err, models.ErrDuplicateRecord) {
+			return clientError(c, fiber.StatusConflict, errAlreadyChatRoomMember)
+		}
+
+		if errors.Is(err, models.ErrForbidden) {
+			return clientError(c, fiber.StatusForbidden, "This chat room is private, an invite is required to join.")
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "Joined chatroom successfully.",
+	})
+}
+
+// dropSubscriptions stops the user's connections from receiving the chat room's events once they can no longer view
+// it. The subscriptions are dropped if the check fails so a removed member is never left subscribed.
+func (h *chatRoomHandler) dropSubscriptions(ctx context.Context, chatRoom *models.ChatRoom, userID uint64) {
+	canView, err := h.chatRoomService.CanView(ctx, chatRoom, userID)
+	if err == nil && canView {
+		return
+	}
+
+	if err := h.hub.UnsubscribeUser(userID, chatRoom.UUID.String()); err != nil {
+		log.Printf("chatRoomHandler.dropSubscriptions:: %v", err)
+	}
+}
+
+// Leave removes the auth user from the chat room members
+func (h *chatRoomHandler) Leave(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	authUser := getAuthUser(c)
+
+	if err := h.chatRoomService.RemoveMember(ctx, chatRoom, authUser.ID); err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusNotFound, errNotChatRoomMember)
+		}
+
+		if errors.Is(err, models.ErrForbidden) {
+			if chatRoom.Kind == models.ChatRoomKindDirect {
+				return clientError(c, fiber.StatusForbidden, "You cannot leave a direct conversation.")
+			}
+
+			return clientError(c, fiber.StatusForbidden, "The owner cannot leave the chat room.")
+		}
+
+		if errors.Is(err, chatroom.ErrGroupTooSmall) {
+			return clientError(c, fiber.StatusBadRequest,
+				"A group needs at least three members, convert it into a chat room to leave it.")
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	h.dropSubscriptions(ctx, chatRoom, authUser.ID)
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Left chatroom successfully.",
+	})
+}
+
+// Members returns the chat room members
+func (h *chatRoomHandler) Members(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	members, err := h.chatRoomService.GetMembers(ctx, chatRoom.ID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"members": members,
+	})
+}
+
+// Invite adds another user to the chat room members
+func (h *chatRoomHandler) Invite(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userID"))
+
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidUserID)
+	}
+
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	if err := h.chatRoomService.InviteMember(ctx, chatRoom.ID, getAuthUser(c).ID, uint64(userID)); err != nil {
+		if errors.Is(err, models.ErrDuplicateRecord) {
+			return clientError(c, fiber.StatusConflict, "The user is already a member of this chat room.")
+		}
+
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusNotFound, "User not found.")
+		}
+
+		return chatRoomActionError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusCreated, fiber.Map{
+		"message": "User added to chatroom successfully.",
+	})
+}
+
+// Kick removes another user from the chat room members
+func (h *chatRoomHandler) Kick(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userID"))
+
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidUserID)
+	}
+
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	if err := h.chatRoomService.KickMember(ctx, chatRoom.ID, getAuthUser(c).ID, uint64(userID)); err != nil {
+		return chatRoomActionError(c, err)
+	}
+
+	h.dropSubscriptions(ctx, chatRoom, uint64(userID))
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "User removed from chatroom successfully.",
+	})
+}
+
+// UpdateRole changes the role of a chat room member
+func (h *chatRoomHandler) UpdateRole(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userID"))
+
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidUserID)
+	}
+
+	var req updateMemberRoleRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !req.Role.IsValid() {
+		return validationDuplicateError(c, fiber.Map{
+			"role": "must be one of owner, admin or member",
+		})
+	}
+
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	err = h.chatRoomService.UpdateMemberRole(ctx, chatRoom.ID, getAuthUser(c).ID, uint64(userID), req.Role)
+	if err != nil {
+		return chatRoomActionError(c, err)
+	}
+
+	member, err := h.chatRoomService.GetMember(ctx, chatRoom.ID, uint64(userID))
+	if err != nil {
+		return chatRoomActionError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"member": member,
+	})
+}
+
+// StoreInvite creates an invite link for a private chat room
+func (h *chatRoomHandler) StoreInvite(c *fiber.Ctx) error {
+	var invite *models.ChatRoomInvite
+
+	if err := c.BodyParser(&invite); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := invite.ValidateStoreRequest(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	newInvite, err := h.chatRoomService.CreateInvite(ctx, chatRoom, getAuthUser(c).ID, invite)
+	if err != nil {
+		if errors.Is(err, chatroom.ErrChatRoomNotPrivate) {
+			return clientError(c, fiber.StatusBadRequest, "Invites can only be created for private chat rooms.")
+		}
+
+		return chatRoomActionError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusCreated, fiber.Map{
+		"invite": newInvite,
+	})
+}
+
+// RedeemInvite makes the auth user a member of the chat room the invite token was created for
+func (h *chatRoomHandler) RedeemInvite(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	invite, err := h.chatRoomService.RedeemInvite(ctx, c.Params("token"), getAuthUser(c).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			return clientError(c, fiber.StatusNotFound, "Invite not found.")
+		case errors.Is(err, models.ErrInviteExpired):
+			return clientError(c, fiber.StatusGone, "This invite has expired.")
+		case errors.Is(err, models.ErrInviteExhausted):
+			return clientError(c, fiber.StatusGone, "This invite has already been used up.")
+		case errors.Is(err, models.ErrDuplicateRecord):
+			return clientError(c, fiber.StatusConflict, errAlreadyChatRoomMember)
+		default:
+			return serverError(c, fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	chatRoom, err := h.chatRoomService.FindByID(ctx, invite.ChatRoomID)
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"chatroom": chatRoom,
+	})
+}
+
 // ChatRoomHandler is an interface for rooms interactions
 type ChatRoomHandler interface {
 	Index(c *fiber.Ctx) error
 	Store(c *fiber.Ctx) error
 	Show(c *fiber.Ctx) error
 	GetByUUID(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
 	Destroy(c *fiber.Ctx) error
+	Join(c *fiber.Ctx) error
+	Leave(c *fiber.Ctx) error
+	Members(c *fiber.Ctx) error
+	Invite(c *fiber.Ctx) error
+	Kick(c *fiber.Ctx) error
+	UpdateRole(c *fiber.Ctx) error
+	StoreInvite(c *fiber.Ctx) error
+	RedeemInvite(c *fiber.Ctx) error
+	StoreDirect(c *fiber.Ctx) error
+	StoreGroup(c *fiber.Ctx) error
+	AddGroupMembers(c *fiber.Ctx) error
+	ConvertGroup(c *fiber.Ctx) error
 }
 
 // NewChatRoomHandler creates a new ChatRoomHandler
 func NewChatRoomHandler(opts ChatRoomHandlerOptions) ChatRoomHandler {
 	return &chatRoomHandler{
+		hub:             opts.Hub,
 		chatRoomService: opts.ChatRoomService,
+		userService:     opts.UserService,
 	}
 }
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/chatroom"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// StoreDirect returns the direct chat room the auth user shares with the user with the provided id. It is created
+// the first time either of them starts the conversation so calling it again returns the same chat room.
+func (h *chatRoomHandler) StoreDirect(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userID"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidUserID)
+	}
+
+	ctx := c.Context()
+
+	participant, err := h.userService.FindByID(ctx, uint64(userID))
+	if err != nil {
+		return findUserError(c, err)
+	}
+
+	chatRoom, created, err := h.chatRoomService.FindOrCreateDirect(ctx, getAuthUser(c).ID, participant.ID)
+	if err != nil {
+		if errors.Is(err, chatroom.ErrDirectWithSelf) {
+			return clientError(c, fiber.StatusBadRequest, "You cannot start a direct conversation with yourself.")
+		}
+
+		return findUserError(c, err)
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+
+	return successResponse(c, status, fiber.Map{
+		"chatroom": models.DirectChatRoom{
+			ChatRoom:    *chatRoom,
+			Participant: *participant,
+		},
+	})
+}
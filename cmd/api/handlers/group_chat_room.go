@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/chatroom"
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// groupMembersRequest has the users to start a group with or to add to it
+type groupMembersRequest struct {
+	UserIDs []uint64 `json:"user_ids"`
+}
+
+// validate makes sure at least one user is provided
+func (r groupMembersRequest) validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.UserIDs, validation.Required))
+}
+
+// groupActionError returns the errors that occur starting or changing a group
+func groupActionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, chatroom.ErrGroupTooSmall):
+		return clientError(c, fiber.StatusBadRequest, "A group needs at least two other members.")
+	case errors.Is(err, chatroom.ErrGroupTooLarge):
+		return clientError(c, fiber.StatusBadRequest,
+			"The group has too many members, convert it into a chat room to add more.")
+	case errors.Is(err, chatroom.ErrGroupExists):
+		return clientError(c, fiber.StatusConflict, "A group with these members already exists.")
+	case errors.Is(err, chatroom.ErrNotGroup):
+		return clientError(c, fiber.StatusBadRequest, "This chat room is not a group.")
+	case errors.Is(err, models.ErrDuplicateRecord):
+		return clientError(c, fiber.StatusConflict, "The users are already members of this group.")
+	case errors.Is(err, models.ErrNoRecord):
+		return clientError(c, fiber.StatusNotFound, "User not found.")
+	default:
+		return chatRoomActionError(c, err)
+	}
+}
+
+// StoreGroup returns the group the auth user shares with exactly the users provided. It is created the first time
+// any of them starts the conversation so calling it again with the same users returns the same group.
+func (h *chatRoomHandler) StoreGroup(c *fiber.Ctx) error {
+	var req groupMembersRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	chatRoom, created, err := h.chatRoomService.FindOrCreateGroup(c.Context(), getAuthUser(c).ID, req.UserIDs)
+	if err != nil {
+		return groupActionError(c, err)
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+
+	return successResponse(c, status, fiber.Map{
+		"chatroom": chatRoom,
+	})
+}
+
+// AddGroupMembers adds more users to a group the auth user is a member of
+func (h *chatRoomHandler) AddGroupMembers(c *fiber.Ctx) error {
+	var req groupMembersRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	if err := h.chatRoomService.AddGroupMembers(ctx, chatRoom, getAuthUser(c).ID, req.UserIDs); err != nil {
+		return groupActionError(c, err)
+	}
+
+	members, err := h.chatRoomService.GetMembers(ctx, chatRoom.ID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusCreated, fiber.Map{
+		"members": members,
+	})
+}
+
+// ConvertGroup names a group the auth user is a member of and turns it into a private chat room they own
+func (h *chatRoomHandler) ConvertGroup(c *fiber.Ctx) error {
+	var chatRoom *models.ChatRoom
+
+	if err := c.BodyParser(&chatRoom); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := chatRoom.ValidateStoreRequest(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+
+	group, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	if err := h.chatRoomService.ConvertGroup(ctx, group, getAuthUser(c).ID, chatRoom.Name); err != nil {
+		return groupActionError(c, err)
+	}
+
+	convertedChatRoom, err := h.chatRoomService.FindByID(ctx, group.ID)
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"chatroom": convertedChatRoom,
+	})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"chatapp/pkg/accesstoken"
+	"github.com/gofiber/fiber/v2"
+)
+
+type (
+	// KeysHandlerOptions represents the options required to set up the keys handler
+	KeysHandlerOptions struct {
+		TokenMaker accesstoken.Maker
+	}
+
+	// keysHandler publishes the keys access tokens can be verified with
+	keysHandler struct {
+		tokenMaker accesstoken.Maker
+	}
+)
+
+// JWKS returns the public keys access tokens can be verified with as a JSON web key set. The set is not wrapped in
+// the success response since clients expect the standard format.
+func (h *keysHandler) JWKS(c *fiber.Ctx) error {
+	maker, ok := h.tokenMaker.(accesstoken.PublicKeyMaker)
+	if !ok {
+		return clientError(c, fiber.StatusNotFound, "Access tokens are not signed with public keys.")
+	}
+
+	jwks, err := accesstoken.NewJWKS(maker.PublicKeys())
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Status(fiber.StatusOK).JSON(jwks)
+}
+
+// KeysHandler is an interface for publishing the access token verification keys
+type KeysHandler interface {
+	JWKS(c *fiber.Ctx) error
+}
+
+// NewKeysHandler creates a new KeysHandler
+func NewKeysHandler(opts KeysHandlerOptions) KeysHandler {
+	return &keysHandler{
+		tokenMaker: opts.TokenMaker,
+	}
+}
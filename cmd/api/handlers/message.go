@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"chatapp/pkg/realtime"
+	"chatapp/services/chatroom"
+	"chatapp/services/message"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+var (
+	errInvalidMessageID = "Invalid message id provided."
+)
+
+type (
+	// MessageHandlerOptions represents the options required to set up the message handler
+	MessageHandlerOptions struct {
+		Hub             *realtime.Hub
+		ChatRoomService chatroom.Service
+		MessageService  message.Service
+	}
+
+	// messageHandler handles chat room messages interactions
+	messageHandler struct {
+		hub             *realtime.Hub
+		chatRoomService chatroom.Service
+		messageService  message.Service
+	}
+)
+
+// findMessageError returns the errors that occur fetching a message
+func findMessageError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, models.ErrNoRecord) {
+		return clientError(c, fiber.StatusNotFound, "Message not found.")
+	}
+
+	return serverError(c, fiber.StatusInternalServerError, err.Error())
+}
+
+// findVisibleMessage fetches the message and the chat room it was sent to as long as the auth user can view the
+// chat room. Deleted messages are not found.
+func (h *messageHandler) findVisibleMessage(c *fiber.Ctx, id uint64) (*models.Message, *models.ChatRoom, error) {
+	ctx := c.Context()
+
+	msg, err := h.messageService.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if msg.DeletedAt != nil {
+		return nil, nil, models.ErrNoRecord
+	}
+
+	chatRoom, err := h.chatRoomService.FindByID(ctx, msg.ChatRoomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := ensureCanViewChatRoom(c, h.chatRoomService, chatRoom); err != nil {
+		return nil, nil, err
+	}
+
+	return msg, chatRoom, nil
+}
+
+// Index returns a page of the chat room messages from the newest to the oldest
+func (h *messageHandler) Index(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "0"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, pagination.ErrInvalidLimit.Error())
+	}
+
+	params, err := pagination.NewParams(c.Query("before"), c.Query("after"), limit)
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Context()
+
+	chatRoom, err := findVisibleChatRoomByUUID(c, h.chatRoomService, c.Params("uuid"))
+	if err != nil {
+		return findChatRoomError(c, err)
+	}
+
+	page, err := h.messageService.GetChatRoomMessages(ctx, chatRoom.ID, getAuthUser(c).ID, params)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, page)
+}
+
+// Update edits the body of a message sent by the auth user while they are still a member of the chat room and
+// broadcasts the edit to the chat room subscribers
+func (h *messageHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidMessageID)
+	}
+
+	var msg *models.Message
+
+	if err := c.BodyParser(&msg); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := msg.ValidateStoreRequest(); err != nil {
+		return validationError(c, err)
+	}
+
+	foundMessage, chatRoom, err := h.findMemberMessage(c, uint64(id))
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			return clientError(c, fiber.StatusForbidden, errNotChatRoomMember)
+		}
+
+		return findMessageError(c, err)
+	}
+
+	updatedMessage, err := h.messageService.Update(c.Context(), foundMessage.ID, getAuthUser(c).ID, msg.Body)
+	if err != nil {
+		if errors.Is(err, models.ErrForbidden) {
+			return clientError(c, fiber.StatusForbidden, "You can only edit your own messages.")
+		}
+
+		if errors.Is(err, message.ErrEditWindowExpired) {
+			return clientError(c, fiber.StatusForbidden, "This message can no longer be edited.")
+		}
+
+		return findMessageError(c, err)
+	}
+
+	_ = h.hub.Broadcast(chatRoom.UUID.String(), realtime.Event{
+		Type: realtime.EventMessageUpdated,
+		Data: updatedMessage,
+	})
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": updatedMessage,
+	})
+}
+
+// Revisions returns the previous versions of an edited message from the oldest to the newest
+func (h *messageHandler) Revisions(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidMessageID)
+	}
+
+	foundMessage, _, err := h.findVisibleMessage(c, uint64(id))
+	if err != nil {
+		return findMessageError(c, err)
+	}
+
+	revisions, err := h.messageService.GetRevisions(c.Context(), foundMessage.ID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"revisions": revisions,
+	})
+}
+
+// Destroy deletes a message sent by the auth user, or by another member if the auth user is allowed to delete their
+// messages. The tombstone left in the chat room history is broadcast to the chat room subscribers.
+func (h *messageHandler) Destroy(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidMessageID)
+	}
+
+	foundMessage, chatRoom, err := h.findVisibleMessage(c, uint64(id))
+	if err != nil {
+		return findMessageError(c, err)
+	}
+
+	ctx := c.Context()
+	authUser := getAuthUser(c)
+
+	if foundMessage.UserID != authUser.ID {
+		_, err := h.chatRoomService.Authorize(ctx, chatRoom.ID, authUser.ID, chatroom.PermissionDeleteMessages)
+		if err != nil {
+			return chatRoomActionError(c, err)
+		}
+	}
+
+	tombstone, err := h.messageService.SoftDelete(ctx, foundMessage)
+	if err != nil {
+		return findMessageError(c, err)
+	}
+
+	_ = h.hub.Broadcast(chatRoom.UUID.String(), realtime.Event{
+		Type: realtime.EventMessageDeleted,
+		Data: tombstone,
+	})
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": tombstone,
+	})
+}
+
+// MessageHandler is an interface for chat room messages interactions
+type MessageHandler interface {
+	Index(c *fiber.Ctx) error
+	Update(c *fiber.Ctx) error
+	Revisions(c *fiber.Ctx) error
+	Destroy(c *fiber.Ctx) error
+	React(c *fiber.Ctx) error
+	Unreact(c *fiber.Ctx) error
+}
+
+// NewMessageHandler creates a new MessageHandler
+func NewMessageHandler(opts MessageHandlerOptions) MessageHandler {
+	return &messageHandler{
+		hub:             opts.Hub,
+		chatRoomService: opts.ChatRoomService,
+		messageService:  opts.MessageService,
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/realtime"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"strconv"
+)
+
+var (
+	// errMalformedMessageID and errMalformedEmoji are returned by parseReaction when the path cannot be parsed
+	errMalformedMessageID = errors.New("handlers: malformed message id")
+	errMalformedEmoji     = errors.New("handlers: malformed emoji")
+)
+
+// reactionError returns the errors that occur reacting to a message
+func reactionError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errMalformedMessageID):
+		return clientError(c, fiber.StatusBadRequest, errInvalidMessageID)
+	case errors.Is(err, errMalformedEmoji):
+		return clientError(c, fiber.StatusBadRequest, "Invalid emoji provided.")
+	case errors.Is(err, models.ErrForbidden):
+		return clientError(c, fiber.StatusForbidden, errNotChatRoomMember)
+	case errors.Is(err, models.ErrDuplicateRecord):
+		return clientError(c, fiber.StatusConflict, "You have already reacted with this emoji.")
+	default:
+		return findMessageError(c, err)
+	}
+}
+
+// parseReaction returns the reaction described by the message id and the percent encoded emoji in the path
+func parseReaction(c *fiber.Ctx) (*models.MessageReaction, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return nil, errMalformedMessageID
+	}
+
+	emoji, err := url.PathUnescape(c.Params("emoji"))
+	if err != nil {
+		return nil, errMalformedEmoji
+	}
+
+	return &models.MessageReaction{
+		MessageID: uint64(id),
+		Emoji:     emoji,
+	}, nil
+}
+
+// findMemberMessage fetches the message and the chat room it was sent to as long as the auth user is a member of
+// the chat room
+func (h *messageHandler) findMemberMessage(c *fiber.Ctx, id uint64) (*models.Message, *models.ChatRoom, error) {
+	msg, chatRoom, err := h.findVisibleMessage(c, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isMember, err := h.chatRoomService.IsMember(c.Context(), chatRoom.ID, getAuthUser(c).ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isMember {
+		return nil, nil, models.ErrForbidden
+	}
+
+	return msg, chatRoom, nil
+}
+
+// broadcastReaction sends the new count of the emoji to the chat room subscribers
+func (h *messageHandler) broadcastReaction(chatRoom *models.ChatRoom, eventType string, userID uint64,
+	count *models.ReactionCount) {
+	_ = h.hub.Broadcast(chatRoom.UUID.String(), realtime.Event{
+		Type: eventType,
+		Data: fiber.Map{
+			"message_id": count.MessageID,
+			"user_id":    userID,
+			"emoji":      count.Emoji,
+			"count":      count.Count,
+		},
+	})
+}
+
+// React adds the auth user's reaction to a message with the emoji in the path and broadcasts the new count to the
+// chat room subscribers
+func (h *messageHandler) React(c *fiber.Ctx) error {
+	reaction, err := parseReaction(c)
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	if err := reaction.ValidateStoreRequest(); err != nil {
+		return validationError(c, err)
+	}
+
+	foundMessage, chatRoom, err := h.findMemberMessage(c, reaction.MessageID)
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	authUser := getAuthUser(c)
+
+	count, err := h.messageService.React(c.Context(), foundMessage, authUser.ID, reaction.Emoji)
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	h.broadcastReaction(chatRoom, realtime.EventReactionAdded, authUser.ID, count)
+
+	return successResponse(c, fiber.StatusCreated, fiber.Map{
+		"reaction": count,
+	})
+}
+
+// Unreact removes the auth user's reaction to a message with the emoji in the path and broadcasts the new count to
+// the chat room subscribers
+func (h *messageHandler) Unreact(c *fiber.Ctx) error {
+	reaction, err := parseReaction(c)
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	foundMessage, chatRoom, err := h.findMemberMessage(c, reaction.MessageID)
+	if err != nil {
+		return reactionError(c, err)
+	}
+
+	authUser := getAuthUser(c)
+
+	count, err := h.messageService.Unreact(c.Context(), foundMessage, authUser.ID, reaction.Emoji)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusNotFound, "Reaction not found.")
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	h.broadcastReaction(chatRoom, realtime.EventReactionRemoved, authUser.ID, count)
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"reaction": count,
+	})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/sso"
+	"chatapp/services/identity"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"time"
+)
+
+const (
+	// oidcCookieName is the cookie the encrypted auth request is kept in between the redirect to the provider and
+	// the callback
+	oidcCookieName = "oidc_auth_request"
+
+	// oidcCookiePath limits the cookie to the OpenID Connect routes
+	oidcCookiePath = "/api/v1/auth/oidc"
+)
+
+var (
+	errInvalidAuthRequest = "Invalid or expired sign in request, please try again."
+	errProviderNotFound   = "Login provider not found."
+)
+
+// oidcAuthRequest is the auth request kept in the cookie. LinkUserID is set when a signed in user is linking the
+// provider's identity to their account instead of signing in with it.
+type oidcAuthRequest struct {
+	sso.AuthRequest
+	LinkUserID uint64 `json:"link_user_id,omitempty"`
+}
+
+// setAuthRequestCookie stores the value in the auth request cookie which expires at the time provided
+func setAuthRequestCookie(c *fiber.Ctx, value string, expiresAt time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcCookieName,
+		Value:    value,
+		Path:     oidcCookiePath,
+		Expires:  expiresAt,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+}
+
+// readAuthRequest decrypts the auth request stored in the cookie when the sign in was started
+func (h *authHandler) readAuthRequest(c *fiber.Ctx) (*oidcAuthRequest, bool) {
+	value := c.Cookies(oidcCookieName)
+	if value == "" {
+		return nil, false
+	}
+
+	data, err := h.encrypter.Decrypt(value)
+	if err != nil {
+		return nil, false
+	}
+
+	var req oidcAuthRequest
+
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// startAuthRequest creates the auth request for the provider, stores it in the encrypted cookie and returns the URL
+// the user signs in at. The state, nonce and PKCE code verifier are kept in the cookie so the callback can be
+// checked without storing anything on the server.
+func (h *authHandler) startAuthRequest(c *fiber.Ctx, provider *sso.Provider, linkUserID uint64) (string, error) {
+	req, err := provider.NewAuthRequest()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(oidcAuthRequest{
+		AuthRequest: *req,
+		LinkUserID:  linkUserID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	value, err := h.encrypter.Encrypt(data)
+	if err != nil {
+		return "", err
+	}
+
+	setAuthRequestCookie(c, value, req.ExpiresAt)
+
+	return provider.AuthCodeURL(req), nil
+}
+
+// OIDCLogin redirects the user to the OpenID Connect provider to sign in
+func (h *authHandler) OIDCLogin(c *fiber.Ctx) error {
+	provider, ok := h.ssoProviders[c.Params("provider")]
+	if !ok {
+		return clientError(c, fiber.StatusNotFound, errProviderNotFound)
+	}
+
+	authURL, err := h.startAuthRequest(c, provider, 0)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OIDCLink starts linking the auth user's account to their identity at the OpenID Connect provider. It returns the
+// URL the user has to sign in with the provider at, the identity is linked once the provider redirects back to the
+// callback.
+func (h *authHandler) OIDCLink(c *fiber.Ctx) error {
+	provider, ok := h.ssoProviders[c.Params("provider")]
+	if !ok {
+		return clientError(c, fiber.StatusNotFound, errProviderNotFound)
+	}
+
+	authURL, err := h.startAuthRequest(c, provider, getAuthUser(c).ID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"url": authURL,
+	})
+}
+
+// linkIdentity links the identity the user signed in with at the provider to the account that started the link
+func (h *authHandler) linkIdentity(c *fiber.Ctx, provider string, userID uint64, claims *sso.Claims) error {
+	linkedIdentity, err := h.identityService.Link(c.Context(), userID, provider, claims)
+	if err != nil {
+		if errors.Is(err, identity.ErrIdentityLinked) {
+			return clientError(c, fiber.StatusConflict, "This account is already linked to another user.")
+		}
+
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusUnauthorized, errInvalidAuthRequest)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"identity": linkedIdentity,
+	})
+}
+
+// OIDCCallback completes the sign in once the provider redirects back. The user linked to the provider's identity
+// is logged in, and created the first time they sign in. If the sign in was started to link the identity to an
+// account it is linked instead.
+func (h *authHandler) OIDCCallback(c *fiber.Ctx) error {
+	provider, ok := h.ssoProviders[c.Params("provider")]
+	if !ok {
+		return clientError(c, fiber.StatusNotFound, errProviderNotFound)
+	}
+
+	req, ok := h.readAuthRequest(c)
+
+	// The auth request can only be used once
+	setAuthRequestCookie(c, "", time.Unix(0, 0))
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		log.Printf("authHandler.OIDCCallback:: provider %q returned %q - %s", provider.Name(), providerErr,
+			c.Query("error_description"))
+
+		return clientError(c, fiber.StatusUnauthorized, "Sign in with the provider was not completed.")
+	}
+
+	if !ok {
+		return clientError(c, fiber.StatusUnauthorized, errInvalidAuthRequest)
+	}
+
+	ctx := c.Context()
+
+	claims, err := provider.Exchange(ctx, &req.AuthRequest, c.Query("state"), c.Query("code"))
+	if err != nil {
+		if errors.Is(err, sso.ErrInvalidAuthRequest) || errors.Is(err, sso.ErrInvalidIDToken) {
+			return clientError(c, fiber.StatusUnauthorized, errInvalidAuthRequest)
+		}
+
+		return serverError(c, fiber.StatusBadGateway, err.Error())
+	}
+
+	if req.LinkUserID != 0 {
+		return h.linkIdentity(c, provider.Name(), req.LinkUserID, claims)
+	}
+
+	userID, err := h.identityService.SignIn(ctx, provider.Name(), claims)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	authUser, err := h.userService.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusUnauthorized, errInvalidAuthRequest)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return h.completeLogin(c, authUser)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/notifier"
+	"chatapp/services/passwordreset"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"net/url"
+	"time"
+)
+
+var (
+	errInvalidResetToken = "Invalid or expired reset token provided."
+)
+
+// ChangePassword replaces the auth user password after confirming their current password. Every other session
+// is signed out since the password may have been changed because it was compromised.
+func (h *authHandler) ChangePassword(c *fiber.Ctx) error {
+	var req models.ChangePasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+	payload := getAuthUserTokenPayload(c)
+
+	currentPassword, err := h.userService.GetPassword(ctx, payload.User.ID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.passwordHasher.Compare(currentPassword, req.CurrentPassword); err != nil {
+		return validationDuplicateError(c, fiber.Map{
+			"current_password": "is incorrect",
+		})
+	}
+
+	hashedPassword, err := h.passwordHasher.Hash(req.Password)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.userService.UpdatePassword(ctx, payload.User.ID, hashedPassword); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	sessionIDs, err := h.sessionService.RevokeOthers(ctx, payload.User.ID, payload.SessionID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.revokeSessions(ctx, sessionIDs...); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Password changed successfully.",
+	})
+}
+
+// ForgotPassword sends a password reset link to the user through the notifier. The response is the same whether
+// the user exists or not so that it cannot be used to find out which usernames are registered.
+func (h *authHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req models.ForgotPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	response := fiber.Map{
+		"message": "If the account exists, a password reset link has been sent.",
+	}
+
+	ctx := c.Context()
+
+	foundUser, err := h.userService.FindByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return successResponse(c, fiber.StatusAccepted, response)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Failures past this point only happen for users that exist so they are logged and the same response is
+	// returned, otherwise the status would reveal which usernames are registered
+	token, reset, err := h.passwordResetService.Issue(ctx, foundUser.ID)
+	if err != nil {
+		log.Printf("authHandler.ForgotPassword:: error issuing reset token for user %d - %v", foundUser.ID, err)
+		return successResponse(c, fiber.StatusAccepted, response)
+	}
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", h.appURL, url.QueryEscape(token))
+
+	err = h.notifier.Send(ctx, notifier.Message{
+		Recipient: foundUser,
+		Subject:   "Reset your password",
+		Body: fmt.Sprintf("Use the link below to reset your password, it expires at %s.\n\n%s",
+			reset.ExpiresAt.Format(time.RFC1123), resetURL),
+	})
+
+	if err != nil {
+		log.Printf("authHandler.ForgotPassword:: error sending reset link to user %d - %v", foundUser.ID, err)
+	}
+
+	return successResponse(c, fiber.StatusAccepted, response)
+}
+
+// ResetPassword sets a new password using a reset token and signs the user out of every session
+func (h *authHandler) ResetPassword(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+
+	reset, err := h.passwordResetService.Redeem(ctx, req.Token)
+	if err != nil {
+		if errors.Is(err, passwordreset.ErrInvalidResetToken) {
+			return clientError(c, fiber.StatusBadRequest, errInvalidResetToken)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	hashedPassword, err := h.passwordHasher.Hash(req.Password)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.userService.UpdatePassword(ctx, reset.UserID, hashedPassword); err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusBadRequest, errInvalidResetToken)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	sessionIDs, err := h.sessionService.RevokeAll(ctx, reset.UserID)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.revokeSessions(ctx, sessionIDs...); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Password reset successfully.",
+	})
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // serverError returns all 5xx error messages
@@ -19,6 +22,12 @@ func clientError(c *fiber.Ctx, status int, error interface{}) error {
 	})
 }
 
+// tooManyRequestsError returns 429 with the number of seconds the client should wait before retrying
+func tooManyRequestsError(c *fiber.Ctx, retryAfter time.Duration, error interface{}) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return clientError(c, fiber.StatusTooManyRequests, error)
+}
+
 // validationError returns all validation errors as  422
 func validationError(c *fiber.Ctx, err error) error {
 	errorsBody := make(map[string]string)
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"chatapp/pkg/accesstoken"
+	"chatapp/pkg/models"
+	"chatapp/services/twofactor"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errInvalidChallenge    = "Invalid or expired challenge token provided."
+	errInvalidTwoFactor    = "Invalid two-factor code provided."
+	errTwoFactorNotEnabled = "Two-factor authentication is not enabled."
+)
+
+// twoFactorChallengeResponse is returned by the login when the user has to complete the two-factor challenge
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+// twoFactorChallenge responds with a short-lived token the user exchanges for their access and refresh tokens along
+// with a two-factor code
+func (h *authHandler) twoFactorChallenge(c *fiber.Ctx, user *models.User) error {
+	token, err := h.tokenMaker.CreateToken(user, h.challengeDuration,
+		accesstoken.WithScope(accesstoken.ScopeTwoFactorChallenge))
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, twoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+	})
+}
+
+// EnrollTwoFactor starts setting up two-factor authentication for the auth user. The otpauth URI returned is
+// usually shown as a QR code for the authenticator app to scan.
+func (h *authHandler) EnrollTwoFactor(c *fiber.Ctx) error {
+	enrollment, err := h.twoFactorService.Enroll(c.Context(), getAuthUser(c))
+	if err != nil {
+		if errors.Is(err, twofactor.ErrAlreadyEnabled) {
+			return clientError(c, fiber.StatusConflict, "Two-factor authentication is already enabled.")
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusCreated, enrollment)
+}
+
+// ConfirmTwoFactor enables two-factor authentication using a code from the authenticator app and returns the
+// recovery codes. The recovery codes are not stored in plain text so this is the only time they are shown.
+func (h *authHandler) ConfirmTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorCodeRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	recoveryCodes, err := h.twoFactorService.Confirm(c.Context(), getAuthUser(c).ID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, twofactor.ErrInvalidCode):
+			return validationDuplicateError(c, fiber.Map{
+				"code": "is invalid",
+			})
+		case errors.Is(err, twofactor.ErrNotEnrolled):
+			return clientError(c, fiber.StatusBadRequest, "Two-factor authentication has not been set up.")
+		case errors.Is(err, twofactor.ErrAlreadyEnabled):
+			return clientError(c, fiber.StatusConflict, "Two-factor authentication is already enabled.")
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// DisableTwoFactor turns off two-factor authentication for the auth user after verifying a code from the
+// authenticator app or a recovery code. Wrong codes count as failed logins so a stolen access token is not enough to
+// brute-force the codes.
+func (h *authHandler) DisableTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorCodeRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+	authUser := getAuthUser(c)
+
+	retryAfter, err := h.loginAttemptService.Check(ctx, authUser.Username, c.IP())
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if retryAfter > 0 {
+		return tooManyRequestsError(c, retryAfter, errTooManyLogins)
+	}
+
+	if err := h.twoFactorService.Disable(ctx, authUser.ID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, twofactor.ErrInvalidCode):
+			retryAfter, err := h.loginAttemptService.RecordFailure(ctx, authUser.Username, c.IP())
+			if err != nil {
+				return serverError(c, fiber.StatusInternalServerError, err.Error())
+			}
+
+			if retryAfter > 0 {
+				return tooManyRequestsError(c, retryAfter, errTooManyLogins)
+			}
+
+			return validationDuplicateError(c, fiber.Map{
+				"code": "is invalid",
+			})
+		case errors.Is(err, twofactor.ErrNotEnabled):
+			return clientError(c, fiber.StatusBadRequest, errTwoFactorNotEnabled)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"message": "Two-factor authentication disabled successfully.",
+	})
+}
+
+// VerifyTwoFactor completes the login by exchanging the challenge token and a two-factor code for the access and
+// refresh tokens. Wrong codes count as failed logins so the codes cannot be brute-forced.
+func (h *authHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorVerifyRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return validationError(c, err)
+	}
+
+	ctx := c.Context()
+
+	payload, err := h.tokenMaker.VerifyToken(req.ChallengeToken)
+	if err != nil || payload.Scope != accesstoken.ScopeTwoFactorChallenge {
+		return clientError(c, fiber.StatusUnauthorized, errInvalidChallenge)
+	}
+
+	revoked, err := h.revocationService.IsRevoked(ctx, payload)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if revoked {
+		return clientError(c, fiber.StatusUnauthorized, errInvalidChallenge)
+	}
+
+	retryAfter, err := h.loginAttemptService.Check(ctx, payload.User.Username, c.IP())
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if retryAfter > 0 {
+		return tooManyRequestsError(c, retryAfter, errTooManyLogins)
+	}
+
+	if err := h.twoFactorService.Verify(ctx, payload.User.ID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, twofactor.ErrInvalidCode):
+			return h.failedLogin(c, payload.User.Username, errInvalidTwoFactor)
+		case errors.Is(err, twofactor.ErrNotEnabled):
+			return clientError(c, fiber.StatusUnauthorized, errInvalidChallenge)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	// The challenge token can only be exchanged once
+	if err := h.revocationService.Revoke(ctx, payload); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.loginAttemptService.RecordSuccess(ctx, payload.User.Username); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	authUser, err := h.userService.FindByID(ctx, payload.User.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return clientError(c, fiber.StatusUnauthorized, errInvalidChallenge)
+		}
+
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	response, err := h.issueTokens(c, authUser)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, response)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"chatapp/services/user"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+type (
+	// UserHandlerOptions represents the options required to set up the user handler
+	UserHandlerOptions struct {
+		UserService user.Service
+	}
+
+	// userHandler handles user profiles
+	userHandler struct {
+		userService user.Service
+	}
+)
+
+// findUserError returns the errors that occur fetching a user
+func findUserError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, models.ErrNoRecord) {
+		return clientError(c, fiber.StatusNotFound, "User not found.")
+	}
+
+	return serverError(c, fiber.StatusInternalServerError, err.Error())
+}
+
+// Index returns a page of the users whose username or display name starts with the q query param, or the
+// directory of every user if it is empty
+func (h *userHandler) Index(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "0"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, pagination.ErrInvalidLimit.Error())
+	}
+
+	params, err := pagination.NewParams("", c.Query("after"), limit)
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	page, err := h.userService.Search(c.Context(), c.Query("q"), params)
+	if err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return successResponse(c, fiber.StatusOK, page)
+}
+
+// Me returns the auth user profile
+func (h *userHandler) Me(c *fiber.Ctx) error {
+	authUser, err := h.userService.FindByID(c.Context(), getAuthUser(c).ID)
+	if err != nil {
+		return findUserError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"user": authUser,
+	})
+}
+
+// UpdateMe updates the auth user profile. Only the fields provided are changed and sending an empty value clears
+// the field.
+func (h *userHandler) UpdateMe(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	authUser, err := h.userService.FindByID(ctx, getAuthUser(c).ID)
+	if err != nil {
+		return findUserError(c, err)
+	}
+
+	profile := authUser.UserProfile
+
+	if err := c.BodyParser(&profile); err != nil {
+		return serverError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := profile.ValidateUpdateRequest(); err != nil {
+		return validationError(c, err)
+	}
+
+	if err := h.userService.UpdateProfile(ctx, authUser.ID, profile); err != nil {
+		return findUserError(c, err)
+	}
+
+	authUser.UserProfile = profile
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"user": authUser,
+	})
+}
+
+// Show returns the profile of the user with the provided id
+func (h *userHandler) Show(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return clientError(c, fiber.StatusBadRequest, errInvalidUserID)
+	}
+
+	foundUser, err := h.userService.FindByID(c.Context(), uint64(id))
+	if err != nil {
+		return findUserError(c, err)
+	}
+
+	return successResponse(c, fiber.StatusOK, fiber.Map{
+		"user": foundUser,
+	})
+}
+
+// UserHandler is an interface for the user profiles
+type UserHandler interface {
+	Index(c *fiber.Ctx) error
+	Me(c *fiber.Ctx) error
+	UpdateMe(c *fiber.Ctx) error
+	Show(c *fiber.Ctx) error
+}
+
+// NewUserHandler creates a new UserHandler
+func NewUserHandler(opts UserHandlerOptions) UserHandler {
+	return &userHandler{
+		userService: opts.UserService,
+	}
+}
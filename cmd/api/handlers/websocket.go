@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"chatapp/pkg/accesstoken"
+	"chatapp/pkg/models"
+	"chatapp/pkg/ratelimit"
+	"chatapp/pkg/realtime"
+	"chatapp/services/chatroom"
+	"chatapp/services/message"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/websocket/v2"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+const (
+	// wsWriteWait is the time allowed to write a message to the peer
+	wsWriteWait = 10 * time.Second
+
+	// wsPongWait is the time allowed to read the next pong message from the peer
+	wsPongWait = 60 * time.Second
+
+	// wsPingPeriod sends pings to the peer with this period, it must be less than wsPongWait
+	wsPingPeriod = (wsPongWait * 9) / 10
+
+	// wsMaxMessageSize is the maximum message size allowed from the peer
+	wsMaxMessageSize = 8192
+)
+
+var (
+	errSomethingWentWrong = "Something went wrong, please try again."
+)
+
+type (
+	// WebSocketHandlerOptions represents the options required to set up the websocket handler
+	WebSocketHandlerOptions struct {
+		Hub             *realtime.Hub
+		ChatRoomService chatroom.Service
+		MessageService  message.Service
+		MessageLimiter  *ratelimit.Limiter
+	}
+
+	// webSocketHandler handles real-time chat room interactions
+	webSocketHandler struct {
+		hub             *realtime.Hub
+		chatRoomService chatroom.Service
+		messageService  message.Service
+		messageLimiter  *ratelimit.Limiter
+	}
+)
+
+// writePump sends the events queued for the client to the connection until the client is unregistered
+func (h *webSocketHandler) writePump(conn *websocket.Conn, client *realtime.Client, done chan<- struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+
+	defer func() {
+		ticker.Stop()
+		_ = conn.Close()
+		close(done)
+	}()
+
+	for {
+		select {
+		case payload, ok := <-client.Send():
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"))
+				return
+			}
+
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendError notifies the client that the event could not be handled
+func (h *webSocketHandler) sendError(client *realtime.Client, room, message string) {
+	_ = h.hub.SendTo(client, realtime.NewErrorEvent(room, message))
+}
+
+// findChatRoom fetches the chat room the event is meant for
+func (h *webSocketHandler) findChatRoom(ctx context.Context, client *realtime.Client, event realtime.IncomingEvent) (
+	*models.ChatRoom, bool) {
+	if _, err := uuid.Parse(event.Room); err != nil {
+		h.sendError(client, event.Room, errInvalidCharRoomID)
+		return nil, false
+	}
+
+	chatRoom, err := h.chatRoomService.FindByUUID(ctx, event.Room)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			h.sendError(client, event.Room, "Chat room not found.")
+			return nil, false
+		}
+
+		log.Printf("webSocketHandler.findChatRoom:: %v", err)
+		h.sendError(client, event.Room, errSomethingWentWrong)
+		return nil, false
+	}
+
+	canView, err := h.chatRoomService.CanView(ctx, chatRoom, client.UserID)
+	if err != nil {
+		log.Printf("webSocketHandler.findChatRoom:: %v", err)
+		h.sendError(client, event.Room, errSomethingWentWrong)
+		return nil, false
+	}
+
+	if !canView {
+		h.sendError(client, event.Room, "Chat room not found.")
+		return nil, false
+	}
+
+	return chatRoom, true
+}
+
+// subscribe starts sending the chat room's events to the client
+func (h *webSocketHandler) subscribe(ctx context.Context, client *realtime.Client, event realtime.IncomingEvent) {
+	chatRoom, ok := h.findChatRoom(ctx, client, event)
+	if !ok {
+		return
+	}
+
+	room := chatRoom.UUID.String()
+
+	if err := h.hub.Subscribe(client, room); err != nil {
+		return
+	}
+
+	_ = h.hub.SendTo(client, realtime.Event{
+		Type: realtime.EventSubscribed,
+		Room: room,
+	})
+}
+
+// unsubscribe stops sending the chat room's events to the client
+func (h *webSocketHandler) unsubscribe(client *realtime.Client, event realtime.IncomingEvent) {
+	h.hub.Unsubscribe(client, event.Room)
+
+	_ = h.hub.SendTo(client, realtime.Event{
+		Type: realtime.EventUnsubscribed,
+		Room: event.Room,
+	})
+}
+
+// createMessage stores the message and broadcasts it to the chat room subscribers
+func (h *webSocketHandler) createMessage(ctx context.Context, client *realtime.Client, event realtime.IncomingEvent) {
+	chatRoom, ok := h.findChatRoom(ctx, client, event)
+	if !ok {
+		return
+	}
+
+	isMember, err := h.chatRoomService.IsMember(ctx, chatRoom.ID, client.UserID)
+	if err != nil {
+		log.Printf("webSocketHandler.createMessage:: %v", err)
+		h.sendError(client, event.Room, errSomethingWentWrong)
+		return
+	}
+
+	if !isMember {
+		h.sendError(client, event.Room, errNotChatRoomMember)
+		return
+	}
+
+	msg := &models.Message{
+		Body: event.Body,
+	}
+
+	if err := msg.ValidateStoreRequest(); err != nil {
+		h.sendError(client, event.Room, err.Error())
+		return
+	}
+
+	randomUUID, err := uuid.NewRandom()
+	if err != nil {
+		h.sendError(client, event.Room, err.Error())
+		return
+	}
+
+	now := time.Now()
+
+	msg.UUID = randomUUID
+	msg.ChatRoomID = chatRoom.ID
+	msg.UserID = client.UserID
+	msg.CreatedAt = now
+	msg.UpdatedAt = now
+
+	newMessage, err := h.messageService.Create(ctx, msg)
+	if err != nil {
+		log.Printf("webSocketHandler.createMessage:: %v", err)
+		h.sendError(client, event.Room, "Failed to send message, please try again.")
+		return
+	}
+
+	_ = h.hub.Broadcast(chatRoom.UUID.String(), realtime.Event{
+		Type: realtime.EventMessageCreated,
+		Data: newMessage,
+	})
+}
+
+// Serve registers the connection on the hub and handles the events it sends until it disconnects
+func (h *webSocketHandler) Serve(conn *websocket.Conn) {
+	payload, ok := conn.Locals(accesstoken.AuthUserToken).(*accesstoken.Payload)
+	if !ok {
+		_ = conn.Close()
+		return
+	}
+
+	client := realtime.NewClient(payload.User.ID)
+
+	if err := h.hub.Register(client); err != nil {
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"))
+		_ = conn.Close()
+		return
+	}
+
+	done := make(chan struct{})
+	go h.writePump(conn, client, done)
+
+	defer func() {
+		h.hub.Unregister(client)
+		<-done
+	}()
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	ctx := context.Background()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var event realtime.IncomingEvent
+
+		if err := json.Unmarshal(data, &event); err != nil {
+			h.sendError(client, "", "Invalid event provided.")
+			continue
+		}
+
+		switch event.Type {
+		case realtime.EventSubscribe:
+			h.subscribe(ctx, client, event)
+		case realtime.EventUnsubscribe:
+			h.unsubscribe(client, event)
+		case realtime.EventMessageCreated:
+			if !h.messageLimiter.Allow(fmt.Sprintf("user:%d", client.UserID)).Allowed {
+				h.sendError(client, event.Room, "You are sending messages too fast, please slow down.")
+				continue
+			}
+
+			h.createMessage(ctx, client, event)
+		default:
+			h.sendError(client, event.Room, "Unsupported event type provided.")
+		}
+	}
+}
+
+// WebSocketHandler is an interface for real-time chat room interactions
+type WebSocketHandler interface {
+	Serve(conn *websocket.Conn)
+}
+
+// NewWebSocketHandler creates a new WebSocketHandler
+func NewWebSocketHandler(opts WebSocketHandlerOptions) WebSocketHandler {
+	return &webSocketHandler{
+		hub:             opts.Hub,
+		chatRoomService: opts.ChatRoomService,
+		messageService:  opts.MessageService,
+		messageLimiter:  opts.MessageLimiter,
+	}
+}
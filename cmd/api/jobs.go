@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// messagePurgeInterval is how often the deleted messages past their retention period are purged
+const messagePurgeInterval = time.Hour
+
+// purgeDeletedMessages permanently removes the messages deleted more than the configured retention period ago. It
+// runs once when it starts so a restart does not delay the purge by a whole interval, then every interval until the
+// context is cancelled.
+func (app *application) purgeDeletedMessages(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := app.messageService.PurgeDeleted(ctx, app.config.DeletedMessageRetention)
+		if err != nil {
+			log.Printf("purgeDeletedMessages:: %v", err)
+		}
+
+		if purged > 0 {
+			log.Printf("purgeDeletedMessages:: purged %d deleted messages", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,16 +1,33 @@
 package main
 
 import (
+	"chatapp/pkg/accesstoken"
 	"chatapp/pkg/database"
+	"chatapp/pkg/notifier"
+	"chatapp/pkg/ratelimit"
+	"chatapp/pkg/realtime"
+	"chatapp/pkg/sso"
 	"chatapp/pkg/util"
+	"chatapp/repository/memory"
 	"chatapp/repository/mysql"
 	"chatapp/services/chatroom"
+	"chatapp/services/identity"
+	"chatapp/services/loginattempt"
+	"chatapp/services/message"
+	"chatapp/services/passwordreset"
+	"chatapp/services/refreshtoken"
+	"chatapp/services/revocation"
+	"chatapp/services/session"
+	"chatapp/services/twofactor"
 	"chatapp/services/user"
+	"context"
+	"crypto"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 )
 
 var (
@@ -19,10 +36,27 @@ var (
 
 // application provides dependency injection across the system
 type application struct {
-	config          *util.Config
-	db              *sqlx.DB
-	userService     user.Service
-	chatroomService chatroom.Service
+	config               *util.Config
+	db                   *sqlx.DB
+	userService          user.Service
+	chatroomService      chatroom.Service
+	messageService       message.Service
+	refreshTokenService  refreshtoken.Service
+	revocationService    revocation.Service
+	sessionService       session.Service
+	passwordResetService passwordreset.Service
+	notifier             notifier.Notifier
+	tokenMaker           accesstoken.Maker
+	passwordHasher       *util.PasswordHasher
+	loginAttemptService  loginattempt.Service
+	twoFactorService     twofactor.Service
+	identityService      identity.Service
+	ssoProviders         map[string]*sso.Provider
+	encrypter            *util.Encrypter
+	authLimiter          *ratelimit.Limiter
+	restLimiter          *ratelimit.Limiter
+	webSocketLimiter     *ratelimit.Limiter
+	hub                  *realtime.Hub
 }
 
 func init() {
@@ -42,8 +76,186 @@ func (app *application) initServices() {
 	}
 
 	app.db = db
+	app.tokenMaker = app.newTokenMaker()
+	app.passwordHasher = app.newPasswordHasher()
 	app.userService = user.NewService(mysql.NewUserRepository(app.db))
-	app.chatroomService = chatroom.NewService(mysql.NewChatRoomRepository(app.db))
+	app.chatroomService = chatroom.NewService(mysql.NewChatRoomRepository(app.db), app.config.GroupMaxMembers)
+	app.messageService = message.NewService(mysql.NewMessageRepository(app.db), app.config.MessageEditWindow)
+	app.refreshTokenService = refreshtoken.NewService(mysql.NewRefreshTokenRepository(app.db),
+		app.config.RefreshTokenDuration)
+	app.revocationService = revocation.NewService(app.newRevokedTokenRepository(), app.config.AccessTokenDuration)
+	app.sessionService = session.NewService(mysql.NewSessionRepository(app.db), app.config.RefreshTokenDuration)
+	app.passwordResetService = passwordreset.NewService(mysql.NewPasswordResetRepository(app.db),
+		app.config.PasswordResetDuration)
+	app.notifier = app.newNotifier()
+	app.loginAttemptService = loginattempt.NewService(app.newLoginAttemptRepository(), loginattempt.Policy{
+		UsernameMaxFailures: app.config.LoginThrottle.UsernameMaxFailures,
+		IPMaxFailures:       app.config.LoginThrottle.IPMaxFailures,
+		BaseLockout:         app.config.LoginThrottle.BaseLockout,
+		MaxLockout:          app.config.LoginThrottle.MaxLockout,
+		ResetAfter:          app.config.LoginThrottle.ResetAfter,
+	})
+
+	app.encrypter, err = util.NewEncrypter(app.config.EncryptionKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	app.twoFactorService = twofactor.NewService(mysql.NewTwoFactorRepository(app.db), app.encrypter,
+		app.config.TwoFactorIssuer)
+	app.identityService = identity.NewService(mysql.NewUserIdentityRepository(app.db))
+	app.ssoProviders = app.newSSOProviders()
+	app.authLimiter = newLimiter(app.config.RateLimits.Auth)
+	app.restLimiter = newLimiter(app.config.RateLimits.REST)
+	app.webSocketLimiter = newLimiter(app.config.RateLimits.WebSocket)
+	app.hub = realtime.NewHub()
+}
+
+// newLimiter creates a ratelimit.Limiter for the configured limit
+func newLimiter(limit ratelimit.Limit) *ratelimit.Limiter {
+	limiter, err := ratelimit.NewLimiter(limit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return limiter
+}
+
+// newSSOProviders creates the configured OpenID Connect providers, their configuration is discovered from the
+// issuer on start up
+func (app *application) newSSOProviders() map[string]*sso.Provider {
+	providers := make(map[string]*sso.Provider, len(app.config.OIDCProviders))
+
+	for name, providerConfig := range app.config.OIDCProviders {
+		provider, err := sso.NewProvider(context.Background(), name, providerConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		providers[name] = provider
+	}
+
+	return providers
+}
+
+// newPasswordHasher creates the util.PasswordHasher which hashes passwords using the configured algorithm
+func (app *application) newPasswordHasher() *util.PasswordHasher {
+	bcryptHasher := util.NewBcryptHasher(app.config.BcryptCost)
+	argon2idHasher := util.NewArgon2idHasher(app.config.Argon2id)
+
+	switch app.config.PasswordHasher {
+	case "argon2id":
+		return util.NewPasswordHasher(argon2idHasher, bcryptHasher)
+	case "bcrypt":
+		return util.NewPasswordHasher(bcryptHasher, argon2idHasher)
+	default:
+		log.Fatalf("unsupported password hasher %q", app.config.PasswordHasher)
+		return nil
+	}
+}
+
+// newLoginAttemptRepository creates the loginattempt.Repository for the configured login throttle store
+func (app *application) newLoginAttemptRepository() loginattempt.Repository {
+	switch app.config.LoginThrottle.Store {
+	case "memory":
+		return memory.NewLoginAttemptRepository()
+	case "mysql":
+		return mysql.NewLoginAttemptRepository(app.db)
+	default:
+		log.Fatalf("unsupported login throttle store %q", app.config.LoginThrottle.Store)
+		return nil
+	}
+}
+
+// newNotifier creates the notifier.Notifier for the configured notifier
+func (app *application) newNotifier() notifier.Notifier {
+	switch app.config.Notifier {
+	case "log":
+		return notifier.NewLogNotifier(log.New(os.Stdout, "", log.LstdFlags))
+	default:
+		log.Fatalf("unsupported notifier %q", app.config.Notifier)
+		return nil
+	}
+}
+
+// newTokenMaker creates the accesstoken.Maker for the configured token format
+func (app *application) newTokenMaker() accesstoken.Maker {
+	var (
+		maker accesstoken.Maker
+		err   error
+	)
+
+	switch app.config.TokenMaker {
+	case "paseto_local":
+		maker, err = app.newPasetoMaker()
+	case "paseto_public":
+		keyID, signingKey, retiredKeys := app.readTokenSigningKeys()
+		maker, err = accesstoken.NewPasetoPublicMaker(keyID, signingKey, retiredKeys)
+	case "jwt":
+		keyID, signingKey, retiredKeys := app.readTokenSigningKeys()
+		maker, err = accesstoken.NewJWTMaker(keyID, signingKey, retiredKeys)
+	default:
+		log.Fatalf("unsupported token maker %q", app.config.TokenMaker)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return maker
+}
+
+// newPasetoMaker creates the local paseto maker from the configured paseto keyring. The single paseto key is used
+// when no keyring has been configured.
+func (app *application) newPasetoMaker() (accesstoken.Maker, error) {
+	if len(app.config.PasetoKeys) == 0 {
+		return accesstoken.NewPasetoMaker(app.config.PasetoKey)
+	}
+
+	return accesstoken.NewPasetoKeyringMaker(strings.ToLower(app.config.PasetoKeyID), app.config.PasetoKeys)
+}
+
+// readTokenSigningKeys reads the id and private key of the key tokens are signed with and the public keys of the
+// retired signing keys. The key id is lowercased to match the viper map keys.
+func (app *application) readTokenSigningKeys() (string, crypto.Signer, map[string]crypto.PublicKey) {
+	data, err := os.ReadFile(app.config.TokenSigningKeyFile)
+	if err != nil {
+		log.Fatalf("error reading token signing key:: %v", err)
+	}
+
+	signingKey, err := accesstoken.ParsePrivateKeyPEM(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	retiredKeys := make(map[string]crypto.PublicKey, len(app.config.TokenVerificationKeyFiles))
+
+	for id, file := range app.config.TokenVerificationKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatalf("error reading token verification key %q:: %v", id, err)
+		}
+
+		retiredKeys[id], err = accesstoken.ParsePublicKeyPEM(data)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	return strings.ToLower(app.config.TokenSigningKeyID), signingKey, retiredKeys
+}
+
+// newRevokedTokenRepository creates the revocation.Repository for the configured revocation store
+func (app *application) newRevokedTokenRepository() revocation.Repository {
+	switch app.config.RevocationStore {
+	case "memory":
+		return memory.NewRevokedTokenRepository()
+	case "mysql":
+		return mysql.NewRevokedTokenRepository(app.db)
+	default:
+		log.Fatalf("unsupported revocation store %q", app.config.RevocationStore)
+		return nil
+	}
 }
 
 func main() {
@@ -55,6 +267,14 @@ func main() {
 	app.initServices()
 	fiberApp := app.routes()
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Deleted messages are kept as tombstones forever unless a retention period is configured
+	if app.config.DeletedMessageRetention > 0 {
+		go app.purgeDeletedMessages(jobsCtx, messagePurgeInterval)
+	}
+
 	osSigChan := make(chan os.Signal, 1)
 	defer close(osSigChan)
 
@@ -64,6 +284,12 @@ func main() {
 		_ = <-osSigChan
 
 		fmt.Println("Gracefully shutting down the server...")
+
+		stopJobs()
+
+		// Close the websocket connections first since they are hijacked and are not waited on by fiber
+		app.hub.Shutdown()
+
 		if err := fiberApp.Shutdown(); err != nil {
 			log.Fatalf("unexpected error shutting down the server:: %v", err)
 		}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"chatapp/pkg/accesstoken"
+	"chatapp/pkg/ratelimit"
 	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/websocket/v2"
+	"math"
+	"strconv"
 	"strings"
 )
 
@@ -21,38 +26,104 @@ func registerFiberMiddleware(app *fiber.App) {
 	)
 }
 
+// authenticate verifies the access token and stores its payload for the rest of the request
+func (app *application) authenticate(c *fiber.Ctx, accessToken string) error {
+	tokenPayload, err := app.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		if errors.Is(err, accesstoken.ErrInvalidToken) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": accesstoken.ErrInvalidToken.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// Scoped tokens such as the two-factor challenge token are only accepted by the endpoints they were issued for
+	if tokenPayload.Scope != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": accesstoken.ErrInvalidToken.Error(),
+		})
+	}
+
+	revoked, err := app.revocationService.IsRevoked(c.Context(), tokenPayload)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if revoked {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": accesstoken.ErrInvalidToken.Error(),
+		})
+	}
+
+	c.Locals(accesstoken.AuthUserToken, tokenPayload)
+	return c.Next()
+}
+
 // authMiddleware attempts to verify the access token provided before completing the request
 func (app *application) authMiddleware() func(c *fiber.Ctx) error {
 	return func(c *fiber.Ctx) error {
 		requestAccessToken := strings.Split(c.Get("Authorization"), " ")
 
-		if len(requestAccessToken) == 0 || requestAccessToken[0] != "Bearer" {
+		if len(requestAccessToken) != 2 || requestAccessToken[0] != "Bearer" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": "Bearer authorization header is required.",
 			})
 		}
 
-		maker, err := accesstoken.NewPasetoMaker(app.config.PasetoKey)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+		return app.authenticate(c, requestAccessToken[1])
+	}
+}
+
+// webSocketMiddleware ensures the request is a websocket upgrade and verifies the access token provided.
+// Browsers cannot set headers on websocket requests so the token is read from the "token" query param.
+func (app *application) webSocketMiddleware() func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+
+		if accessToken := c.Query("token"); accessToken != "" {
+			return app.authenticate(c, accessToken)
 		}
 
-		tokenPayload, err := maker.VerifyToken(requestAccessToken[1])
-		if err != nil {
-			if errors.Is(err, accesstoken.ErrInvalidToken) {
-				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-					"error": accesstoken.ErrInvalidToken.Error(),
-				})
-			}
+		return app.authMiddleware()(c)
+	}
+}
+
+// rateLimitKey returns the key requests are limited by, the auth user if the request has been authenticated and
+// the IP address otherwise
+func rateLimitKey(c *fiber.Ctx) string {
+	if payload, ok := c.Locals(accesstoken.AuthUserToken).(*accesstoken.Payload); ok {
+		return fmt.Sprintf("user:%d", payload.User.ID)
+	}
+
+	return "ip:" + c.IP()
+}
+
+// rateLimitMiddleware limits the requests made using the limiter and reports the limit using the RateLimit headers.
+// It should come after authMiddleware for the requests to be limited by user.
+func (app *application) rateLimitMiddleware(limiter *ratelimit.Limiter) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		result := limiter.Allow(rateLimitKey(c))
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
 
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many requests, please slow down.",
 			})
 		}
 
-		c.Locals(accesstoken.AuthUserToken, tokenPayload)
 		return c.Next()
 	}
 }
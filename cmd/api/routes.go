@@ -3,6 +3,7 @@ package main
 import (
 	"chatapp/cmd/api/handlers"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
 )
 
 func (app *application) routes() *fiber.App {
@@ -10,27 +11,117 @@ func (app *application) routes() *fiber.App {
 
 	registerFiberMiddleware(fiberApp)
 
+	keysHandler := handlers.NewKeysHandler(handlers.KeysHandlerOptions{
+		TokenMaker: app.tokenMaker,
+	})
+
+	fiberApp.Get("/.well-known/jwks.json", keysHandler.JWKS)
+
 	v1 := fiberApp.Group("api/v1")
 
-	auth := v1.Group("/auth")
+	auth := v1.Group("/auth", app.rateLimitMiddleware(app.authLimiter))
 	authHandler := handlers.NewAuthHandler(handlers.AuthHandlerOptions{
-		UserService: app.userService,
-		PasetoKey:   app.config.PasetoKey,
+		UserService:          app.userService,
+		RefreshTokenService:  app.refreshTokenService,
+		RevocationService:    app.revocationService,
+		SessionService:       app.sessionService,
+		PasswordResetService: app.passwordResetService,
+		Notifier:             app.notifier,
+		TokenMaker:           app.tokenMaker,
+		PasswordHasher:       app.passwordHasher,
+		LoginAttemptService:  app.loginAttemptService,
+		TwoFactorService:     app.twoFactorService,
+		IdentityService:      app.identityService,
+		SSOProviders:         app.ssoProviders,
+		Encrypter:            app.encrypter,
+		AccessTokenDuration:  app.config.AccessTokenDuration,
+		ChallengeDuration:    app.config.TwoFactorChallengeDuration,
+		AppURL:               app.config.AppURL,
 	})
 
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
+	auth.Post("/refresh", authHandler.Refresh)
+	auth.Post("/logout", app.authMiddleware(), authHandler.Logout)
+	auth.Get("/sessions", app.authMiddleware(), authHandler.Sessions)
+	auth.Delete("/sessions", app.authMiddleware(), authHandler.DestroyOtherSessions)
+	auth.Delete("/sessions/:id", app.authMiddleware(), authHandler.DestroySession)
+	auth.Put("/password", app.authMiddleware(), authHandler.ChangePassword)
+	auth.Post("/password/forgot", authHandler.ForgotPassword)
+	auth.Post("/password/reset", authHandler.ResetPassword)
+	auth.Post("/2fa/enroll", app.authMiddleware(), authHandler.EnrollTwoFactor)
+	auth.Post("/2fa/confirm", app.authMiddleware(), authHandler.ConfirmTwoFactor)
+	auth.Delete("/2fa", app.authMiddleware(), authHandler.DisableTwoFactor)
+	auth.Post("/2fa/verify", authHandler.VerifyTwoFactor)
+	auth.Get("/oidc/:provider", authHandler.OIDCLogin)
+	auth.Get("/oidc/:provider/callback", authHandler.OIDCCallback)
+	auth.Post("/oidc/:provider/link", app.authMiddleware(), authHandler.OIDCLink)
+
+	users := v1.Group("/users").Use(app.authMiddleware(), app.rateLimitMiddleware(app.restLimiter))
+	usersHandler := handlers.NewUserHandler(handlers.UserHandlerOptions{
+		UserService: app.userService,
+	})
+
+	users.Get("/", usersHandler.Index)
+	users.Get("/me", usersHandler.Me)
+	users.Patch("/me", usersHandler.UpdateMe)
+	users.Get("/:id", usersHandler.Show)
 
-	chatRooms := v1.Group("/chat-rooms").Use(app.authMiddleware())
+	chatRooms := v1.Group("/chat-rooms").Use(app.authMiddleware(), app.rateLimitMiddleware(app.restLimiter))
 	chatRoomsHandler := handlers.NewChatRoomHandler(handlers.ChatRoomHandlerOptions{
+		Hub:             app.hub,
 		ChatRoomService: app.chatroomService,
+		UserService:     app.userService,
 	})
 
 	chatRooms.Get("/", chatRoomsHandler.Index)
 	chatRooms.Post("/", chatRoomsHandler.Store)
 	chatRooms.Get("/:id", chatRoomsHandler.Show)
 	chatRooms.Get("/:uuid/uuid", chatRoomsHandler.GetByUUID)
+	chatRooms.Patch("/:id", chatRoomsHandler.Update)
 	chatRooms.Delete("/:id", chatRoomsHandler.Destroy)
+	chatRooms.Get("/:uuid/members", chatRoomsHandler.Members)
+	chatRooms.Post("/:uuid/members", chatRoomsHandler.Join)
+	chatRooms.Delete("/:uuid/members", chatRoomsHandler.Leave)
+	chatRooms.Post("/:uuid/members/:userID", chatRoomsHandler.Invite)
+	chatRooms.Delete("/:uuid/members/:userID", chatRoomsHandler.Kick)
+	chatRooms.Put("/:uuid/members/:userID/role", chatRoomsHandler.UpdateRole)
+	chatRooms.Post("/:uuid/invites", chatRoomsHandler.StoreInvite)
+
+	invites := v1.Group("/invites").Use(app.authMiddleware(), app.rateLimitMiddleware(app.restLimiter))
+	invites.Post("/:token", chatRoomsHandler.RedeemInvite)
+
+	dms := v1.Group("/dms").Use(app.authMiddleware(), app.rateLimitMiddleware(app.restLimiter))
+	dms.Post("/:userID", chatRoomsHandler.StoreDirect)
+
+	groups := v1.Group("/groups").Use(app.authMiddleware(), app.rateLimitMiddleware(app.restLimiter))
+	groups.Post("/", chatRoomsHandler.StoreGroup)
+	groups.Post("/:uuid/members", chatRoomsHandler.AddGroupMembers)
+	groups.Put("/:uuid/convert", chatRoomsHandler.ConvertGroup)
+
+	messagesHandler := handlers.NewMessageHandler(handlers.MessageHandlerOptions{
+		Hub:             app.hub,
+		ChatRoomService: app.chatroomService,
+		MessageService:  app.messageService,
+	})
+
+	chatRooms.Get("/:uuid/messages", messagesHandler.Index)
+
+	messages := v1.Group("/messages").Use(app.authMiddleware(), app.rateLimitMiddleware(app.restLimiter))
+	messages.Patch("/:id", messagesHandler.Update)
+	messages.Delete("/:id", messagesHandler.Destroy)
+	messages.Get("/:id/revisions", messagesHandler.Revisions)
+	messages.Post("/:id/reactions/:emoji", messagesHandler.React)
+	messages.Delete("/:id/reactions/:emoji", messagesHandler.Unreact)
+
+	webSocketHandler := handlers.NewWebSocketHandler(handlers.WebSocketHandlerOptions{
+		Hub:             app.hub,
+		ChatRoomService: app.chatroomService,
+		MessageService:  app.messageService,
+		MessageLimiter:  app.webSocketLimiter,
+	})
+
+	v1.Get("/ws", app.webSocketMiddleware(), websocket.New(webSocketHandler.Serve))
 
 	return fiberApp
 }
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS messages
+(
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    uuid         CHAR(36)        NOT NULL,
+    chat_room_id BIGINT UNSIGNED NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    body         TEXT            NOT NULL,
+    created_at   TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT messages_uuid_unique UNIQUE (uuid),
+    CONSTRAINT messages_chat_room_id_foreign FOREIGN KEY (chat_room_id) REFERENCES chat_rooms (id),
+    CONSTRAINT messages_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id),
+    INDEX messages_chat_room_id_id_index (chat_room_id, id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
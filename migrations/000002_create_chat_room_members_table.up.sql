@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS chat_room_members
+(
+    chat_room_id BIGINT UNSIGNED NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    created_at   TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_room_id, user_id),
+    CONSTRAINT chat_room_members_chat_room_id_foreign FOREIGN KEY (chat_room_id) REFERENCES chat_rooms (id),
+    CONSTRAINT chat_room_members_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id),
+    INDEX chat_room_members_user_id_index (user_id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
+
+-- Every room creator is a member of their room
+INSERT IGNORE INTO chat_room_members (chat_room_id, user_id, created_at)
+SELECT id, user_id, created_at
+FROM chat_rooms;
+
+UPDATE chat_rooms
+SET users_count = (SELECT COUNT(*) FROM chat_room_members WHERE chat_room_members.chat_room_id = chat_rooms.id);
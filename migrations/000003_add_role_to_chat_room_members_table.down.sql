@@ -0,0 +1,2 @@
+ALTER TABLE chat_room_members
+    DROP COLUMN role;
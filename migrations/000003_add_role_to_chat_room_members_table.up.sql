@@ -0,0 +1,7 @@
+ALTER TABLE chat_room_members
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'member' AFTER user_id;
+
+UPDATE chat_room_members
+    INNER JOIN chat_rooms ON chat_rooms.id = chat_room_members.chat_room_id
+SET chat_room_members.role = 'owner'
+WHERE chat_room_members.user_id = chat_rooms.user_id;
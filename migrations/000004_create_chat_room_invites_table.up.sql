@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS chat_room_invites
+(
+    id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    chat_room_id BIGINT UNSIGNED NOT NULL,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    token_hash   CHAR(64)        NOT NULL,
+    max_uses     INT UNSIGNED    NOT NULL DEFAULT 0,
+    uses         INT UNSIGNED    NOT NULL DEFAULT 0,
+    expires_at   TIMESTAMP       NOT NULL,
+    created_at   TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chat_room_invites_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT chat_room_invites_chat_room_id_foreign FOREIGN KEY (chat_room_id) REFERENCES chat_rooms (id),
+    CONSTRAINT chat_room_invites_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    family_id  CHAR(36)        NOT NULL,
+    token_hash CHAR(64)        NOT NULL,
+    expires_at TIMESTAMP       NOT NULL,
+    rotated_at TIMESTAMP       NULL,
+    revoked_at TIMESTAMP       NULL,
+    created_at TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT refresh_tokens_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT refresh_tokens_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id),
+    INDEX refresh_tokens_family_id_index (family_id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
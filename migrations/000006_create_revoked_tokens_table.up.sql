@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens
+(
+    uuid       CHAR(36)  NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    INDEX revoked_tokens_expires_at_index (expires_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
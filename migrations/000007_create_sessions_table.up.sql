@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS sessions
+(
+    id           CHAR(36)        NOT NULL PRIMARY KEY,
+    user_id      BIGINT UNSIGNED NOT NULL,
+    user_agent   VARCHAR(512)    NOT NULL DEFAULT '',
+    ip_address   VARCHAR(45)     NOT NULL DEFAULT '',
+    created_at   TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at   TIMESTAMP       NULL,
+    CONSTRAINT sessions_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id),
+    INDEX sessions_user_id_last_seen_at_index (user_id, last_seen_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS password_resets
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    token_hash CHAR(64)        NOT NULL,
+    expires_at TIMESTAMP       NOT NULL,
+    used_at    TIMESTAMP       NULL,
+    created_at TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT password_resets_token_hash_unique UNIQUE (token_hash),
+    CONSTRAINT password_resets_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS login_attempts
+(
+    attempt_key    VARCHAR(191) NOT NULL PRIMARY KEY,
+    failures       INT UNSIGNED NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP    NOT NULL,
+    locked_until   TIMESTAMP    NULL,
+    INDEX login_attempts_last_failed_at_index (last_failed_at)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS two_factors
+(
+    user_id        BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+    secret         VARCHAR(255)    NOT NULL,
+    confirmed_at   TIMESTAMP       NULL,
+    last_used_step BIGINT          NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT two_factors_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    code_hash  CHAR(64)        NOT NULL,
+    used_at    TIMESTAMP       NULL,
+    created_at TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT two_factor_recovery_codes_user_id_code_hash_unique UNIQUE (user_id, code_hash),
+    CONSTRAINT two_factor_recovery_codes_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS user_identities
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    provider   VARCHAR(50)     NOT NULL,
+    subject    VARCHAR(255)    NOT NULL,
+    email      VARCHAR(255)    NOT NULL DEFAULT '',
+    created_at TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT user_identities_provider_subject_unique UNIQUE (provider, subject),
+    CONSTRAINT user_identities_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP COLUMN status_emoji,
+    DROP COLUMN status_text,
+    DROP COLUMN bio,
+    DROP COLUMN avatar_url,
+    DROP COLUMN display_name;
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN display_name VARCHAR(50)   NOT NULL DEFAULT '' AFTER password,
+    ADD COLUMN avatar_url   VARCHAR(2048) NOT NULL DEFAULT '' AFTER display_name,
+    ADD COLUMN bio          VARCHAR(500)  NOT NULL DEFAULT '' AFTER avatar_url,
+    ADD COLUMN status_text  VARCHAR(100)  NOT NULL DEFAULT '' AFTER bio,
+    ADD COLUMN status_emoji VARCHAR(32)   NOT NULL DEFAULT '' AFTER status_text;
@@ -0,0 +1,2 @@
+DROP INDEX users_display_name_deleted_at_index ON users;
+DROP INDEX users_username_deleted_at_index ON users;
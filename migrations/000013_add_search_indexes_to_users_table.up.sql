@@ -0,0 +1,2 @@
+CREATE INDEX users_username_deleted_at_index ON users (username, deleted_at);
+CREATE INDEX users_display_name_deleted_at_index ON users (display_name, deleted_at);
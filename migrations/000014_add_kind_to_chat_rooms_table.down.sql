@@ -0,0 +1,4 @@
+ALTER TABLE chat_rooms
+    DROP INDEX chat_rooms_member_key_unique,
+    DROP COLUMN member_key,
+    DROP COLUMN kind;
@@ -0,0 +1,4 @@
+ALTER TABLE chat_rooms
+    ADD COLUMN kind       VARCHAR(16) NOT NULL DEFAULT 'room' AFTER name,
+    ADD COLUMN member_key VARCHAR(64) NULL AFTER kind,
+    ADD CONSTRAINT chat_rooms_member_key_unique UNIQUE (member_key);
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    DROP COLUMN edited_at;
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN edited_at TIMESTAMP NULL AFTER body;
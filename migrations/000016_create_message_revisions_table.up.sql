@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS message_revisions
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    message_id BIGINT UNSIGNED NOT NULL,
+    body       TEXT            NOT NULL,
+    created_at TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT message_revisions_message_id_foreign FOREIGN KEY (message_id) REFERENCES messages (id),
+    INDEX message_revisions_message_id_id_index (message_id, id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    DROP INDEX messages_deleted_at_index,
+    DROP COLUMN deleted_at;
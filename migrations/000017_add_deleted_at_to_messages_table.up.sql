@@ -0,0 +1,3 @@
+ALTER TABLE messages
+    ADD COLUMN deleted_at TIMESTAMP NULL AFTER updated_at,
+    ADD INDEX messages_deleted_at_index (deleted_at);
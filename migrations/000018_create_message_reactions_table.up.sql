@@ -0,0 +1,14 @@
+-- The emoji are compared by their bytes since the unicode collation treats many different emoji as equal
+CREATE TABLE IF NOT EXISTS message_reactions
+(
+    id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    message_id BIGINT UNSIGNED                 NOT NULL,
+    user_id    BIGINT UNSIGNED                 NOT NULL,
+    emoji      VARCHAR(32) COLLATE utf8mb4_bin NOT NULL,
+    created_at TIMESTAMP                       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT message_reactions_message_id_user_id_emoji_unique UNIQUE (message_id, user_id, emoji),
+    CONSTRAINT message_reactions_message_id_foreign FOREIGN KEY (message_id) REFERENCES messages (id),
+    CONSTRAINT message_reactions_user_id_foreign FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8mb4
+  COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,148 @@
+package accesstoken
+
+import (
+	"chatapp/pkg/models"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"strconv"
+	"time"
+)
+
+// jwtClaims are the claims of the JWTs created by the JWTMaker
+type jwtClaims struct {
+	User      *Subject  `json:"user"`
+	SessionID uuid.UUID `json:"session_id,omitempty"`
+	Scope     string    `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTMaker is a JWT token maker. Tokens are signed using an Ed25519 (EdDSA) or RSA (RS256) private key and can be
+// verified by anyone holding the public key.
+type JWTMaker struct {
+	privateKey    crypto.Signer
+	signingMethod jwt.SigningMethod
+	keyID         string
+	publicKeys    publicKeyring
+}
+
+// jwtSigningMethod returns the signing method used for the key
+func jwtSigningMethod(key crypto.PublicKey) jwt.SigningMethod {
+	switch key.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256
+	default:
+		return nil
+	}
+}
+
+// CreateToken creates a new JWT signed with the private key
+func (m *JWTMaker) CreateToken(user *models.User, duration time.Duration, opts ...PayloadOption) (string, error) {
+	payload, err := NewPayload(user, duration, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwtClaims{
+		User:      payload.User,
+		SessionID: payload.SessionID,
+		Scope:     payload.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.UUID.String(),
+			Issuer:    "jwambugu",
+			Subject:   strconv.FormatUint(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiresAt),
+		},
+	}
+
+	jwtToken := jwt.NewWithClaims(m.signingMethod, claims)
+	jwtToken.Header["kid"] = m.keyID
+
+	token, err := jwtToken.SignedString(m.privateKey)
+	if err != nil {
+		return "", fmt.Errorf("accesstoken.CreateToken:: error signing token - %v", err)
+	}
+
+	return token, nil
+}
+
+// VerifyToken checks if the token is valid or not using the public key it was signed with
+func (m *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	claims := &jwtClaims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		keyID, _ := t.Header["kid"].(string)
+
+		key, ok := m.publicKeys[keyID]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		if t.Method != jwtSigningMethod(key) {
+			return nil, ErrInvalidToken
+		}
+
+		return key, nil
+	})
+
+	if err != nil || claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	tokenUUID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{
+		UUID:      tokenUUID,
+		SessionID: claims.SessionID,
+		Scope:     claims.Scope,
+		User:      claims.User,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+
+	if err := payload.IsValid(); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+// PublicKeys returns the keys the tokens can be verified with
+func (m *JWTMaker) PublicKeys() []PublicKey {
+	return m.publicKeys.publicKeys(func(key crypto.PublicKey) string {
+		return jwtSigningMethod(key).Alg()
+	})
+}
+
+// NewJWTMaker creates a new JWTMaker which signs tokens using the Ed25519 or RSA private key. Tokens signed by
+// retired keys can still be verified as long as their public keys are provided.
+func NewJWTMaker(keyID string, privateKey crypto.Signer, retiredKeys map[string]crypto.PublicKey) (Maker, error) {
+	signingMethod := jwtSigningMethod(privateKey.Public())
+	if signingMethod == nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	for _, retiredKey := range retiredKeys {
+		if jwtSigningMethod(retiredKey) == nil {
+			return nil, ErrUnsupportedKey
+		}
+	}
+
+	maker := &JWTMaker{
+		privateKey:    privateKey,
+		signingMethod: signingMethod,
+		keyID:         keyID,
+		publicKeys:    newPublicKeyring(keyID, privateKey, retiredKeys),
+	}
+
+	return maker, nil
+}
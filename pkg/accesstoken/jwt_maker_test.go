@@ -0,0 +1,167 @@
+package accesstoken
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/repository/factory"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJWTMaker_VerifyToken(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		key  crypto.Signer
+		alg  string
+	}{
+		{
+			name: "signs and verifies tokens using an ed25519 key",
+			key:  edKey,
+			alg:  "EdDSA",
+		},
+		{
+			name: "signs and verifies tokens using an rsa key",
+			key:  rsaKey,
+			alg:  "RS256",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewJWTMaker("2021-12", tc.key, nil)
+			assert.NoError(t, err)
+
+			user := factory.NewUser()
+			user.ID = 1
+			sessionID := uuid.New()
+
+			duration := time.Minute
+			issuedAt := time.Now()
+
+			token, err := maker.CreateToken(user, duration, WithSessionID(sessionID))
+			assert.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, user.ID, payload.User.ID)
+			assert.Equal(t, sessionID, payload.SessionID)
+			assert.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+			assert.WithinDuration(t, issuedAt.Add(duration), payload.ExpiresAt, time.Second)
+
+			expiredToken, err := maker.CreateToken(user, -time.Minute)
+			assert.NoError(t, err)
+
+			_, err = maker.VerifyToken(expiredToken)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+
+			keys := maker.(PublicKeyMaker).PublicKeys()
+			assert.Len(t, keys, 1)
+			assert.Equal(t, tc.alg, keys[0].Algorithm)
+		})
+	}
+}
+
+func TestJWTMaker_VerifyToken_Scope(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	maker, err := NewJWTMaker("2021-12", key, nil)
+	assert.NoError(t, err)
+
+	token, err := maker.CreateToken(factory.NewUser(), time.Minute)
+	assert.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	assert.NoError(t, err)
+	assert.Empty(t, payload.Scope, "tokens are not scoped by default")
+
+	scopedToken, err := maker.CreateToken(factory.NewUser(), time.Minute, WithScope(ScopeTwoFactorChallenge))
+	assert.NoError(t, err)
+
+	payload, err = maker.VerifyToken(scopedToken)
+	assert.NoError(t, err)
+	assert.Equal(t, ScopeTwoFactorChallenge, payload.Scope)
+}
+
+func TestJWTMaker_VerifyToken_RotatedKeys(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	oldMaker, err := NewJWTMaker("2021-11", oldKey, nil)
+	assert.NoError(t, err)
+
+	maker, err := NewJWTMaker("2021-12", newKey, map[string]crypto.PublicKey{
+		"2021-11": oldKey.Public(),
+	})
+	assert.NoError(t, err)
+
+	oldToken, err := oldMaker.CreateToken(factory.NewUser(), time.Minute)
+	assert.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	assert.NoError(t, err)
+
+	token, err := maker.CreateToken(factory.NewUser(), time.Minute)
+	assert.NoError(t, err)
+
+	_, err = oldMaker.VerifyToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestJWTMaker_CreateToken_Claims(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	maker, err := NewJWTMaker("2021-12", key, nil)
+	assert.NoError(t, err)
+
+	user := factory.NewUser()
+	user.ID = 1
+	user.UserProfile = models.UserProfile{
+		DisplayName: "Jane",
+		Bio:         "Writes the release notes",
+		StatusText:  "On holiday",
+	}
+
+	token, err := maker.CreateToken(user, time.Minute)
+	assert.NoError(t, err)
+
+	// The claims are only signed so anyone holding the token can decode them
+	parts := strings.Split(token, ".")
+	assert.Len(t, parts, 3)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[1])
+	assert.NoError(t, err)
+
+	var claims map[string]interface{}
+	assert.NoError(t, json.Unmarshal(decoded, &claims))
+
+	assert.Equal(t, map[string]interface{}{
+		"id":       float64(1),
+		"username": user.Username,
+	}, claims["user"])
+	assert.NotContains(t, string(decoded), "password")
+	assert.NotContains(t, string(decoded), user.Password)
+
+	// The profile changes without the token being reissued so it is read from the database instead
+	for _, field := range []string{"display_name", "avatar_url", "bio", "status_text", "status_emoji"} {
+		assert.NotContains(t, string(decoded), field)
+	}
+}
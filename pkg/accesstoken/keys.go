@@ -0,0 +1,169 @@
+package accesstoken
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	// ErrInvalidPEM is returned if the key provided is not PEM encoded
+	ErrInvalidPEM = errors.New("accesstoken: key must be PEM encoded")
+
+	// ErrUnsupportedKey is returned if the key type cannot be used by the maker
+	ErrUnsupportedKey = errors.New("accesstoken: unsupported key type")
+)
+
+type (
+	// PublicKey is a key that can be used to verify the tokens created by a PublicKeyMaker. The algorithm is only
+	// set for JWTs since paseto tokens have no JOSE algorithm.
+	PublicKey struct {
+		ID        string
+		Algorithm string
+		Key       crypto.PublicKey
+	}
+
+	// publicKeyring maps the id of every key tokens can be verified with to the key
+	publicKeyring map[string]crypto.PublicKey
+
+	// PublicKeyMaker is a Maker whose tokens can be verified using its public keys, which allows other services
+	// to verify the tokens without being able to create them
+	PublicKeyMaker interface {
+		Maker
+		PublicKeys() []PublicKey
+	}
+
+	// JWK is the JSON web key representation of a PublicKey
+	JWK struct {
+		KeyType   string `json:"kty"`
+		KeyID     string `json:"kid"`
+		Use       string `json:"use"`
+		Algorithm string `json:"alg,omitempty"`
+		Curve     string `json:"crv,omitempty"`
+		X         string `json:"x,omitempty"`
+		N         string `json:"n,omitempty"`
+		E         string `json:"e,omitempty"`
+	}
+
+	// JWKS is a set of JSON web keys
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+)
+
+// ParsePrivateKeyPEM parses a PKCS #8 PEM encoded Ed25519 or RSA private key
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("accesstoken.ParsePrivateKeyPEM:: error parsing key - %v", err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// ParsePublicKeyPEM parses a PKIX PEM encoded Ed25519 or RSA public key
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidPEM
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("accesstoken.ParsePublicKeyPEM:: error parsing key - %v", err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return k, nil
+	case *rsa.PublicKey:
+		return k, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// newPublicKeyring creates a publicKeyring with the public key of the active signing key and the retired keys
+func newPublicKeyring(keyID string, signer crypto.Signer, retiredKeys map[string]crypto.PublicKey) publicKeyring {
+	keyring := publicKeyring{
+		keyID: signer.Public(),
+	}
+
+	for id, key := range retiredKeys {
+		if id != keyID {
+			keyring[id] = key
+		}
+	}
+
+	return keyring
+}
+
+// publicKeys returns the keys in the keyring ordered by their id
+func (k publicKeyring) publicKeys(algorithm func(key crypto.PublicKey) string) []PublicKey {
+	keys := make([]PublicKey, 0, len(k))
+
+	for id, key := range k {
+		keys = append(keys, PublicKey{
+			ID:        id,
+			Algorithm: algorithm(key),
+			Key:       key,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys
+}
+
+// NewJWKS creates the JSON web key set for the public keys provided
+func NewJWKS(keys []PublicKey) (JWKS, error) {
+	jwks := JWKS{
+		Keys: make([]JWK, 0, len(keys)),
+	}
+
+	for _, key := range keys {
+		jwk := JWK{
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Algorithm,
+		}
+
+		switch k := key.Key.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(k)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		default:
+			return JWKS{}, ErrUnsupportedKey
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
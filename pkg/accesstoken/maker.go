@@ -7,6 +7,6 @@ import (
 
 // Maker manages access tokens
 type Maker interface {
-	CreateToken(user *models.User, duration time.Duration) (string, error)
+	CreateToken(user *models.User, duration time.Duration, opts ...PayloadOption) (string, error)
 	VerifyToken(token string) (*Payload, error)
 }
@@ -2,37 +2,51 @@ package accesstoken
 
 import (
 	"chatapp/pkg/models"
+	"errors"
 	"fmt"
 	"github.com/o1egl/paseto"
 	"golang.org/x/crypto/chacha20poly1305"
 	"time"
 )
 
+// DefaultKeyID is the id of the key used by NewPasetoMaker. Tokens without a key id in the footer were created
+// before key rotation was supported and are verified using this key.
+const DefaultKeyID = "default"
+
 var (
 	// ErrInvalidPasetoKeySize ia returned if the paseto token is not of expected size
 	ErrInvalidPasetoKeySize = fmt.Errorf("accesstoken: key must be %d characters", chacha20poly1305.KeySize)
+
+	// ErrUnknownActiveKey is returned if the key used to create tokens is not part of the keyring
+	ErrUnknownActiveKey = errors.New("accesstoken: active key id is not in the keyring")
 )
 
-type footer map[string]interface{}
+// footer is the unencrypted part of the token, the key id tells which key in the keyring the token was created with
+type footer struct {
+	Creator string `json:"creator"`
+	KeyID   string `json:"kid,omitempty"`
+}
 
 // PasetoMaker is a new paseto token maker
 type PasetoMaker struct {
-	paseto *paseto.V2
-	key    []byte
+	paseto      *paseto.V2
+	keys        map[string][]byte
+	activeKeyID string
 }
 
-// CreateToken creates a new paseto token
-func (m *PasetoMaker) CreateToken(user *models.User, duration time.Duration) (string, error) {
-	payload, err := NewPayload(user, duration)
+// CreateToken creates a new paseto token using the active key
+func (m *PasetoMaker) CreateToken(user *models.User, duration time.Duration, opts ...PayloadOption) (string, error) {
+	payload, err := NewPayload(user, duration, opts...)
 	if err != nil {
 		return "", err
 	}
 
 	tokenFooter := footer{
-		"creator": "jwambugu",
+		Creator: "jwambugu",
+		KeyID:   m.activeKeyID,
 	}
 
-	token, err := m.paseto.Encrypt(m.key, payload, tokenFooter)
+	token, err := m.paseto.Encrypt(m.keys[m.activeKeyID], payload, tokenFooter)
 	if err != nil {
 		return "", fmt.Errorf("accesstoken.CreateToken:: error encrypting token - %v", err)
 	}
@@ -40,13 +54,27 @@ func (m *PasetoMaker) CreateToken(user *models.User, duration time.Duration) (st
 	return token, nil
 }
 
-// VerifyToken checks if the token is valid or not
+// VerifyToken checks if the token is valid or not using the key it was created with
 func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
-	payload := &Payload{}
-
 	var tokenFooter footer
 
-	if err := m.paseto.Decrypt(token, m.key, payload, &tokenFooter); err != nil {
+	if err := paseto.ParseFooter(token, &tokenFooter); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	keyID := tokenFooter.KeyID
+	if keyID == "" {
+		keyID = DefaultKeyID
+	}
+
+	key, ok := m.keys[keyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+
+	if err := m.paseto.Decrypt(token, key, payload, &tokenFooter); err != nil {
 		return nil, ErrInvalidToken
 	}
 
@@ -57,15 +85,35 @@ func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	return payload, nil
 }
 
-// NewPasetoMaker creates a new PasetoMaker
+// NewPasetoMaker creates a new PasetoMaker with a single key
 func NewPasetoMaker(key string) (Maker, error) {
-	if len(key) != chacha20poly1305.KeySize {
-		return nil, ErrInvalidPasetoKeySize
+	return NewPasetoKeyringMaker(DefaultKeyID, map[string]string{
+		DefaultKeyID: key,
+	})
+}
+
+// NewPasetoKeyringMaker creates a new PasetoMaker from a keyring of keys mapped by their id. Tokens are created
+// using the active key and can be verified using any key in the keyring, which allows keys to be rotated by adding
+// a new active key and keeping the retired ones until the tokens created with them expire.
+func NewPasetoKeyringMaker(activeKeyID string, keys map[string]string) (Maker, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, ErrUnknownActiveKey
+	}
+
+	keyring := make(map[string][]byte, len(keys))
+
+	for id, key := range keys {
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("%w - key id %q", ErrInvalidPasetoKeySize, id)
+		}
+
+		keyring[id] = []byte(key)
 	}
 
 	maker := &PasetoMaker{
-		paseto: paseto.NewV2(),
-		key:    []byte(key),
+		paseto:      paseto.NewV2(),
+		keys:        keyring,
+		activeKeyID: activeKeyID,
 	}
 
 	return maker, nil
@@ -2,6 +2,7 @@ package accesstoken
 
 import (
 	"chatapp/repository/factory"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -89,3 +90,102 @@ func TestPasetoMaker_VerifyToken_Expired(t *testing.T) {
 	assert.ErrorIs(t, err, ErrInvalidToken)
 	assert.Nil(t, payload)
 }
+
+func TestPasetoMaker_VerifyToken_SessionID(t *testing.T) {
+	maker, err := NewPasetoMaker(factory.RandomString(32))
+	assert.NoError(t, err)
+
+	sessionID := uuid.New()
+
+	token, err := maker.CreateToken(factory.NewUser(), time.Minute, WithSessionID(sessionID))
+	assert.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, sessionID, payload.SessionID)
+}
+
+func TestNewPasetoKeyringMaker(t *testing.T) {
+	testCases := []struct {
+		name        string
+		activeKeyID string
+		keys        map[string]string
+		wantsErr    error
+	}{
+		{
+			name:        "creates a maker from the keyring",
+			activeKeyID: "2021-12",
+			keys: map[string]string{
+				"2021-11": factory.RandomString(32),
+				"2021-12": factory.RandomString(32),
+			},
+		},
+		{
+			name:        "ensures the active key is in the keyring",
+			activeKeyID: "2022-01",
+			keys: map[string]string{
+				"2021-12": factory.RandomString(32),
+			},
+			wantsErr: ErrUnknownActiveKey,
+		},
+		{
+			name:        "ensures every key is of 32 characters",
+			activeKeyID: "2021-12",
+			keys: map[string]string{
+				"2021-11": factory.RandomString(31),
+				"2021-12": factory.RandomString(32),
+			},
+			wantsErr: ErrInvalidPasetoKeySize,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPasetoKeyringMaker(tc.activeKeyID, tc.keys)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPasetoMaker_VerifyToken_RotatedKeys(t *testing.T) {
+	oldKey := factory.RandomString(32)
+	newKey := factory.RandomString(32)
+
+	oldMaker, err := NewPasetoMaker(oldKey)
+	assert.NoError(t, err)
+
+	oldToken, err := oldMaker.CreateToken(factory.NewUser(), time.Minute)
+	assert.NoError(t, err)
+
+	rotatedMaker, err := NewPasetoKeyringMaker("2021-12", map[string]string{
+		DefaultKeyID: oldKey,
+		"2021-12":    newKey,
+	})
+	assert.NoError(t, err)
+
+	newToken, err := rotatedMaker.CreateToken(factory.NewUser(), time.Minute)
+	assert.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(oldToken)
+	assert.NoError(t, err)
+
+	_, err = rotatedMaker.VerifyToken(newToken)
+	assert.NoError(t, err)
+
+	_, err = oldMaker.VerifyToken(newToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	retiredMaker, err := NewPasetoKeyringMaker("2021-12", map[string]string{
+		"2021-12": newKey,
+	})
+	assert.NoError(t, err)
+
+	_, err = retiredMaker.VerifyToken(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
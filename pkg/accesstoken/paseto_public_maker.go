@@ -0,0 +1,98 @@
+package accesstoken
+
+import (
+	"chatapp/pkg/models"
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"github.com/o1egl/paseto"
+	"time"
+)
+
+// PasetoPublicMaker is a v2.public paseto token maker. Tokens are signed using an Ed25519 private key and can be
+// verified by anyone holding the public key.
+type PasetoPublicMaker struct {
+	paseto     *paseto.V2
+	privateKey ed25519.PrivateKey
+	keyID      string
+	publicKeys publicKeyring
+}
+
+// CreateToken creates a new paseto token signed with the private key
+func (m *PasetoPublicMaker) CreateToken(user *models.User, duration time.Duration, opts ...PayloadOption) (
+	string, error) {
+	payload, err := NewPayload(user, duration, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	tokenFooter := footer{
+		Creator: "jwambugu",
+		KeyID:   m.keyID,
+	}
+
+	token, err := m.paseto.Sign(m.privateKey, payload, tokenFooter)
+	if err != nil {
+		return "", fmt.Errorf("accesstoken.CreateToken:: error signing token - %v", err)
+	}
+
+	return token, nil
+}
+
+// VerifyToken checks if the token is valid or not using the public key it was signed with
+func (m *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	var tokenFooter footer
+
+	if err := paseto.ParseFooter(token, &tokenFooter); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, ok := m.publicKeys[tokenFooter.KeyID].(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+
+	if err := m.paseto.Verify(token, key, payload, &tokenFooter); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := payload.IsValid(); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+// PublicKeys returns the keys the tokens can be verified with
+func (m *PasetoPublicMaker) PublicKeys() []PublicKey {
+	return m.publicKeys.publicKeys(func(crypto.PublicKey) string {
+		return ""
+	})
+}
+
+// NewPasetoPublicMaker creates a new PasetoPublicMaker which signs tokens using the Ed25519 private key. Tokens signed
+// by retired keys can still be verified as long as their public keys are provided.
+func NewPasetoPublicMaker(keyID string, privateKey crypto.Signer, retiredKeys map[string]crypto.PublicKey) (
+	Maker, error) {
+	key, ok := privateKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	for _, retiredKey := range retiredKeys {
+		if _, ok := retiredKey.(ed25519.PublicKey); !ok {
+			return nil, ErrUnsupportedKey
+		}
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto:     paseto.NewV2(),
+		privateKey: key,
+		keyID:      keyID,
+		publicKeys: newPublicKeyring(keyID, key, retiredKeys),
+	}
+
+	return maker, nil
+}
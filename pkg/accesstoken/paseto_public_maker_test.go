@@ -0,0 +1,183 @@
+package accesstoken
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/repository/factory"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPasetoPublicMaker(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		key         crypto.Signer
+		retiredKeys map[string]crypto.PublicKey
+		wantsErr    error
+	}{
+		{
+			name: "creates a maker with an ed25519 key",
+			key:  edKey,
+		},
+		{
+			name:     "ensures the signing key is an ed25519 key",
+			key:      rsaKey,
+			wantsErr: ErrUnsupportedKey,
+		},
+		{
+			name: "ensures the retired keys are ed25519 keys",
+			key:  edKey,
+			retiredKeys: map[string]crypto.PublicKey{
+				"2021-11": rsaKey.Public(),
+			},
+			wantsErr: ErrUnsupportedKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPasetoPublicMaker("2021-12", tc.key, tc.retiredKeys)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPasetoPublicMaker_VerifyToken(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	oldMaker, err := NewPasetoPublicMaker("2021-11", oldKey, nil)
+	assert.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker("2021-12", newKey, map[string]crypto.PublicKey{
+		"2021-11": oldKey.Public(),
+	})
+	assert.NoError(t, err)
+
+	user := factory.NewUser()
+	user.ID = 1
+	sessionID := uuid.New()
+
+	token, err := maker.CreateToken(user, time.Minute, WithSessionID(sessionID))
+	assert.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, payload.User.ID)
+	assert.Equal(t, sessionID, payload.SessionID)
+
+	oldToken, err := oldMaker.CreateToken(user, time.Minute)
+	assert.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	assert.NoError(t, err)
+
+	_, err = oldMaker.VerifyToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expiredToken, err := maker.CreateToken(user, -time.Minute)
+	assert.NoError(t, err)
+
+	_, err = maker.VerifyToken(expiredToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	localMaker, err := NewPasetoMaker(factory.RandomString(32))
+	assert.NoError(t, err)
+
+	localToken, err := localMaker.CreateToken(user, time.Minute)
+	assert.NoError(t, err)
+
+	_, err = maker.VerifyToken(localToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestPasetoPublicMaker_PublicKeys(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker("2021-12", newKey, map[string]crypto.PublicKey{
+		"2021-11": oldKey.Public(),
+	})
+	assert.NoError(t, err)
+
+	keys := maker.(PublicKeyMaker).PublicKeys()
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "2021-11", keys[0].ID)
+	assert.Equal(t, oldKey.Public(), keys[0].Key)
+	assert.Equal(t, "2021-12", keys[1].ID)
+	assert.Equal(t, newKey.Public(), keys[1].Key)
+
+	jwks, err := NewJWKS(keys)
+	assert.NoError(t, err)
+	assert.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Curve)
+	assert.Empty(t, jwks.Keys[1].Algorithm)
+}
+
+func TestPasetoPublicMaker_CreateToken_Payload(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker("2021-12", key, nil)
+	assert.NoError(t, err)
+
+	user := factory.NewUser()
+	user.ID = 1
+	user.UserProfile = models.UserProfile{
+		DisplayName: "Jane",
+		Bio:         "Writes the release notes",
+		StatusText:  "On holiday",
+	}
+
+	token, err := maker.CreateToken(user, time.Minute)
+	assert.NoError(t, err)
+
+	// v2.public tokens are the payload followed by the signature, only signed so anyone can decode them
+	parts := strings.Split(token, ".")
+	assert.Len(t, parts, 4)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.NoError(t, err)
+
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(decoded[:len(decoded)-ed25519.SignatureSize], &payload))
+
+	assert.Equal(t, map[string]interface{}{
+		"id":       float64(1),
+		"username": user.Username,
+	}, payload["user"])
+	assert.NotContains(t, string(decoded), "password")
+	assert.NotContains(t, string(decoded), user.Password)
+
+	// The profile changes without the token being reissued so it is read from the database instead
+	for _, field := range []string{"display_name", "avatar_url", "bio", "status_text", "status_emoji"} {
+		assert.NotContains(t, string(decoded), field)
+	}
+}
@@ -16,12 +16,43 @@ var (
 // AuthUserToken is a  key to be used to pass auth user data between requests
 const AuthUserToken = "auth_user_token"
 
+// ScopeTwoFactorChallenge is the scope of the token returned by the login when the user has to complete the
+// two-factor authentication challenge
+const ScopeTwoFactorChallenge = "2fa_challenge"
+
+// Subject identifies the user an access token was issued to. Signed tokens can be read by anyone holding them so
+// nothing else about the user is kept in the token.
+type Subject struct {
+	ID       uint64 `json:"id"`
+	Username string `json:"username"`
+}
+
 // Payload contains the payload for the access token
 type Payload struct {
-	UUID      uuid.UUID    `json:"uuid"`
-	User      *models.User `json:"user"`
-	IssuedAt  time.Time    `json:"issued_at"`
-	ExpiresAt time.Time    `json:"expires_at"`
+	UUID      uuid.UUID `json:"uuid"`
+	SessionID uuid.UUID `json:"session_id,omitempty"`
+	// Scope limits what the token can be used for, tokens with a scope cannot be used as access tokens
+	Scope     string    `json:"scope,omitempty"`
+	User      *Subject  `json:"user"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PayloadOption sets the optional fields of a Payload
+type PayloadOption func(p *Payload)
+
+// WithSessionID ties the token to the login session it was issued for
+func WithSessionID(sessionID uuid.UUID) PayloadOption {
+	return func(p *Payload) {
+		p.SessionID = sessionID
+	}
+}
+
+// WithScope limits the token to the scope provided
+func WithScope(scope string) PayloadOption {
+	return func(p *Payload) {
+		p.Scope = scope
+	}
 }
 
 // IsValid checks if the token payload is valid or not
@@ -34,7 +65,7 @@ func (p *Payload) IsValid() error {
 }
 
 // NewPayload creates a new Payload
-func NewPayload(user *models.User, duration time.Duration) (*Payload, error) {
+func NewPayload(user *models.User, duration time.Duration, opts ...PayloadOption) (*Payload, error) {
 	randomUUID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, fmt.Errorf("accesstoken.NewRandom:: error generating uuid - %v", err)
@@ -44,14 +75,17 @@ func NewPayload(user *models.User, duration time.Duration) (*Payload, error) {
 
 	payload := &Payload{
 		UUID: randomUUID,
-		User: &models.User{
-			ID:        user.ID,
-			Username:  user.Username,
-			CreatedAt: user.CreatedAt,
+		User: &Subject{
+			ID:       user.ID,
+			Username: user.Username,
 		},
 		IssuedAt:  now,
 		ExpiresAt: now.Add(duration),
 	}
 
+	for _, opt := range opts {
+		opt(payload)
+	}
+
 	return payload, nil
 }
@@ -1,21 +1,87 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/google/uuid"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// The columns chat rooms can be filtered by
+const (
+	ChatRoomColumnID     = "id"
+	ChatRoomColumnUUID   = "uuid"
+	ChatRoomColumnName   = "name"
+	ChatRoomColumnUserID = "user_id"
+)
+
+// ChatRoomKind tells the chat rooms users create and join apart from the conversations between users
+type ChatRoomKind string
+
+const (
+	// ChatRoomKindRoom is a named ChatRoom users create and join
+	ChatRoomKindRoom ChatRoomKind = "room"
+
+	// ChatRoomKindDirect is the private ChatRoom two users share to message each other
+	ChatRoomKindDirect ChatRoomKind = "direct"
+
+	// ChatRoomKindGroup is a private ChatRoom without a name a few users start to message each other. It can be
+	// converted into a named ChatRoomKindRoom once it outgrows being a conversation.
+	ChatRoomKindGroup ChatRoomKind = "group"
+)
+
 // ChatRoom represents an identifier for a Chat between users
 type ChatRoom struct {
-	ID         uint64    `json:"id,omitempty" db:"id"`
-	UUID       uuid.UUID `json:"uuid,omitempty" db:"uuid"`
-	Name       string    `json:"name,omitempty" db:"name"`
-	UsersCount uint      `json:"users_count,omitempty" db:"users_count"`
-	IsPrivate  bool      `json:"is_private,omitempty" db:"is_private"`
-	UserID     uint64    `json:"user_id,omitempty" db:"user_id"`
-	CreatedAt  time.Time `json:"created_at,omitempty" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	ID         uint64       `json:"id,omitempty" db:"id"`
+	UUID       uuid.UUID    `json:"uuid,omitempty" db:"uuid"`
+	Name       string       `json:"name,omitempty" db:"name"`
+	Kind       ChatRoomKind `json:"kind,omitempty" db:"kind"`
+	MemberKey  string       `json:"-" db:"member_key"`
+	UsersCount uint         `json:"users_count,omitempty" db:"users_count"`
+	IsPrivate  bool         `json:"is_private,omitempty" db:"is_private"`
+	UserID     uint64       `json:"user_id,omitempty" db:"user_id"`
+	CreatedAt  time.Time    `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// DirectChatRoom is a direct ChatRoom shown using the profile of the other participant instead of a name
+type DirectChatRoom struct {
+	ChatRoom
+	Participant User `json:"participant" db:"participant"`
+}
+
+// DirectMemberKey returns the unique member key of the direct ChatRoom between the two users. It is the same
+// whichever of them starts the conversation.
+func DirectMemberKey(userID, otherUserID uint64) string {
+	if userID > otherUserID {
+		userID, otherUserID = otherUserID, userID
+	}
+
+	return fmt.Sprintf("dm:%d:%d", userID, otherUserID)
+}
+
+// GroupMemberKey returns the unique member key of the group ChatRoom with the users provided as its members. It is
+// a hash of the sorted ids so that it is the same whatever order the users are listed in.
+func GroupMemberKey(userIDs []uint64) string {
+	sorted := make([]uint64, len(userIDs))
+	copy(sorted, userIDs)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	ids := make([]string, len(sorted))
+	for i, id := range sorted {
+		ids[i] = strconv.FormatUint(id, 10)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, ",")))
+	return "group:" + base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
 // ValidateStoreRequest validates incoming store request
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+const (
+	// DefaultChatRoomInviteExpiry is used when an invite is created without an expiry
+	DefaultChatRoomInviteExpiry = 7 * 24 * time.Hour
+
+	// MaxChatRoomInviteExpiry is the longest an invite can be valid for
+	MaxChatRoomInviteExpiry = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInviteExpired is used when an invite is redeemed after it has expired
+	ErrInviteExpired = errors.New("model: invite has expired")
+
+	// ErrInviteExhausted is used when an invite is redeemed after reaching its max uses
+	ErrInviteExhausted = errors.New("model: invite has no uses left")
+)
+
+// ChatRoomInvite allows users to join a private ChatRoom by redeeming its token
+type ChatRoomInvite struct {
+	ID         uint64    `json:"id,omitempty" db:"id"`
+	ChatRoomID uint64    `json:"chat_room_id,omitempty" db:"chat_room_id"`
+	UserID     uint64    `json:"user_id,omitempty" db:"user_id"`
+	Token      string    `json:"token,omitempty" db:"-"`
+	TokenHash  string    `json:"-" db:"token_hash"`
+	MaxUses    uint      `json:"max_uses" db:"max_uses"`
+	Uses       uint      `json:"uses" db:"uses"`
+	ExpiresIn  uint      `json:"expires_in,omitempty" db:"-"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt  time.Time `json:"created_at,omitempty" db:"created_at"`
+}
+
+// ValidateStoreRequest validates incoming store request. MaxUses of 0 allows unlimited uses and ExpiresIn is
+// the number of seconds the invite is valid for.
+func (i ChatRoomInvite) ValidateStoreRequest() error {
+	return validation.ValidateStruct(&i,
+		validation.Field(&i.MaxUses, validation.Max(uint(1000))),
+		validation.Field(&i.ExpiresIn, validation.Min(uint(60)), validation.Max(uint(MaxChatRoomInviteExpiry.Seconds()))),
+	)
+}
+
+// CanBeRedeemed checks if the invite has not expired and has uses left at the time provided
+func (i ChatRoomInvite) CanBeRedeemed(now time.Time) error {
+	if !now.Before(i.ExpiresAt) {
+		return ErrInviteExpired
+	}
+
+	if i.MaxUses > 0 && i.Uses >= i.MaxUses {
+		return ErrInviteExhausted
+	}
+
+	return nil
+}
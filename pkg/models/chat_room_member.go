@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// ChatRoomRole is the role a ChatRoomMember has within a ChatRoom
+type ChatRoomRole string
+
+const (
+	// ChatRoomRoleOwner is the role of the User that created the ChatRoom
+	ChatRoomRoleOwner ChatRoomRole = "owner"
+
+	// ChatRoomRoleAdmin is the role of a User that helps the owner moderate the ChatRoom
+	ChatRoomRoleAdmin ChatRoomRole = "admin"
+
+	// ChatRoomRoleMember is the role every other User that joins the ChatRoom has
+	ChatRoomRoleMember ChatRoomRole = "member"
+)
+
+// rank orders the roles from the least to the most privileged
+func (r ChatRoomRole) rank() int {
+	switch r {
+	case ChatRoomRoleOwner:
+		return 3
+	case ChatRoomRoleAdmin:
+		return 2
+	case ChatRoomRoleMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValid checks if the role is one of the known roles
+func (r ChatRoomRole) IsValid() bool {
+	return r.rank() > 0
+}
+
+// Outranks checks if the role is more privileged than the other role
+func (r ChatRoomRole) Outranks(other ChatRoomRole) bool {
+	return r.rank() > other.rank()
+}
+
+// ChatRoomMember represents a User that has joined a ChatRoom
+type ChatRoomMember struct {
+	ChatRoomID uint64       `json:"chat_room_id,omitempty" db:"chat_room_id"`
+	UserID     uint64       `json:"user_id,omitempty" db:"user_id"`
+	Username   string       `json:"username,omitempty" db:"username"`
+	Role       ChatRoomRole `json:"role,omitempty" db:"role"`
+	CreatedAt  time.Time    `json:"created_at,omitempty" db:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "errors"
+
+var (
+	// ErrInvalidFilter is used when a repository cannot filter by the column provided
+	ErrInvalidFilter = errors.New("model: invalid filter")
+)
+
+// Filter matches the records whose Column is equal to Value. Repositories only filter by the columns they allow,
+// such as the UserColumn and ChatRoomColumn constants.
+type Filter struct {
+	Column string
+	Value  interface{}
+}
+
+// Where creates a Filter matching the records whose column is equal to the value
+func Where(column string, value interface{}) Filter {
+	return Filter{
+		Column: column,
+		Value:  value,
+	}
+}
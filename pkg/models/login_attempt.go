@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks the failed logins for a username or an IP address
+type LoginAttempt struct {
+	Key          string     `json:"key" db:"attempt_key"`
+	Failures     int        `json:"failures" db:"failures"`
+	LastFailedAt time.Time  `json:"last_failed_at" db:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+}
+
+// RetryAfter returns how long until logins are allowed again at the time provided, zero if they are allowed
+func (a LoginAttempt) RetryAfter(now time.Time) time.Duration {
+	if a.LockedUntil == nil || !now.Before(*a.LockedUntil) {
+		return 0
+	}
+
+	return a.LockedUntil.Sub(now)
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Message represents a single message sent by a User to a ChatRoom. A deleted message is kept in the history as a
+// tombstone: Deleted is true, DeletedAt is set and the body and reactions are empty. Clients check Deleted rather than
+// an empty body.
+type Message struct {
+	ID         uint64     `json:"id,omitempty" db:"id"`
+	UUID       uuid.UUID  `json:"uuid,omitempty" db:"uuid"`
+	ChatRoomID uint64     `json:"chat_room_id,omitempty" db:"chat_room_id"`
+	UserID     uint64     `json:"user_id,omitempty" db:"user_id"`
+	Body       string     `json:"body,omitempty" db:"body"`
+	EditedAt   *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	CreatedAt  time.Time  `json:"created_at,omitempty" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty" db:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	Deleted    bool       `json:"deleted" db:"deleted"`
+
+	Reactions []ReactionCount `json:"reactions,omitempty" db:"-"`
+}
+
+// ValidateStoreRequest validates incoming store request
+func (m Message) ValidateStoreRequest() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Body, validation.Required, validation.Length(1, 4000)))
+}
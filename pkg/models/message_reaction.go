@@ -0,0 +1,112 @@
+package models
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+	"unicode"
+)
+
+var (
+	// emojiBases are the code points an emoji starts with: the pictographic blocks, the symbols and dingbats with an
+	// emoji presentation and the regional indicators flags are made of
+	emojiBases = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x00a9, Hi: 0x00ae, Stride: 5},
+			{Lo: 0x203c, Hi: 0x2049, Stride: 13},
+			{Lo: 0x2122, Hi: 0x2139, Stride: 23},
+			{Lo: 0x2194, Hi: 0x21aa, Stride: 1},
+			{Lo: 0x231a, Hi: 0x23ff, Stride: 1},
+			{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+			{Lo: 0x25aa, Hi: 0x25fe, Stride: 1},
+			{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+			{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+			{Lo: 0x2b05, Hi: 0x2b55, Stride: 1},
+			{Lo: 0x3030, Hi: 0x303d, Stride: 13},
+			{Lo: 0x3297, Hi: 0x3299, Stride: 2},
+		},
+		R32: []unicode.Range32{
+			{Lo: 0x1f000, Hi: 0x1faff, Stride: 1},
+		},
+	}
+
+	// emojiModifiers are the code points that only change the emoji before them: the zero width joiner, the emoji
+	// variation selector, the keycap and the tags of subdivision flags. Skin tones are in emojiBases.
+	emojiModifiers = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x200d, Hi: 0x200d, Stride: 1},
+			{Lo: 0x20e3, Hi: 0x20e3, Stride: 1},
+			{Lo: 0xfe0f, Hi: 0xfe0f, Stride: 1},
+		},
+		R32: []unicode.Range32{
+			{Lo: 0xe0020, Hi: 0xe007f, Stride: 1},
+		},
+	}
+
+	errNotEmoji = errors.New("must be an emoji")
+)
+
+// MessageReaction is an emoji a User reacted to a Message with, a User can react with each emoji once
+type MessageReaction struct {
+	ID        uint64    `json:"id,omitempty" db:"id"`
+	MessageID uint64    `json:"message_id,omitempty" db:"message_id"`
+	UserID    uint64    `json:"user_id,omitempty" db:"user_id"`
+	Emoji     string    `json:"emoji,omitempty" db:"emoji"`
+	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at"`
+}
+
+// ReactionCount is the number of users who reacted to a Message with the emoji and whether the User viewing the
+// Message is one of them
+type ReactionCount struct {
+	MessageID   uint64 `json:"-" db:"message_id"`
+	Emoji       string `json:"emoji" db:"emoji"`
+	Count       uint   `json:"count" db:"count"`
+	ReactedByMe bool   `json:"reacted_by_me" db:"reacted_by_me"`
+}
+
+// ValidateStoreRequest validates incoming store request
+func (r MessageReaction) ValidateStoreRequest() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Emoji, validation.Required, validation.RuneLength(1, 16), validation.Length(1, 32),
+			validation.By(isEmoji)))
+}
+
+// isEmoji checks the value is a single emoji, an emoji base optionally followed by modifiers and joined emoji, or a
+// keycap such as 1️⃣
+func isEmoji(value interface{}) error {
+	emoji, _ := value.(string)
+	runes := []rune(emoji)
+
+	if len(runes) == 0 {
+		return nil
+	}
+
+	if isKeycap(runes) {
+		return nil
+	}
+
+	if !unicode.Is(emojiBases, runes[0]) {
+		return errNotEmoji
+	}
+
+	for _, r := range runes[1:] {
+		if !unicode.Is(emojiBases, r) && !unicode.Is(emojiModifiers, r) {
+			return errNotEmoji
+		}
+	}
+
+	return nil
+}
+
+// isKeycap checks the runes are a digit, # or * followed by the keycap, with or without the variation selector
+func isKeycap(runes []rune) bool {
+	if len(runes) < 2 || len(runes) > 3 || runes[len(runes)-1] != 0x20e3 {
+		return false
+	}
+
+	if len(runes) == 3 && runes[1] != 0xfe0f {
+		return false
+	}
+
+	return (runes[0] >= '0' && runes[0] <= '9') || runes[0] == '#' || runes[0] == '*'
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestMessageReaction_ValidateStoreRequest(t *testing.T) {
+	testCases := []struct {
+		name      string
+		emoji     string
+		wantsErrs bool
+	}{
+		{name: "accepts an emoji", emoji: "👍"},
+		{name: "accepts an emoji with a skin tone", emoji: "👍🏽"},
+		{name: "accepts a symbol with the variation selector", emoji: "❤️"},
+		{name: "accepts an emoji joined with others", emoji: "👩‍💻"},
+		{name: "accepts a flag", emoji: "🇳🇱"},
+		{name: "accepts a keycap", emoji: "1️⃣"},
+		{name: "rejects an empty emoji", emoji: "", wantsErrs: true},
+		{name: "rejects text", emoji: "lol", wantsErrs: true},
+		{name: "rejects an emoji followed by text", emoji: "👍 nice", wantsErrs: true},
+		{name: "rejects a lone variation selector", emoji: "\ufe0f", wantsErrs: true},
+		{name: "rejects a digit without the keycap", emoji: "1", wantsErrs: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := MessageReaction{Emoji: tc.emoji}.ValidateStoreRequest()
+
+			if (err != nil) != tc.wantsErrs {
+				t.Errorf("ValidateStoreRequest() error = %v, wantsErrs = %v", err, tc.wantsErrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// MessageRevision is a previous version of an edited Message, CreatedAt is when it was replaced
+type MessageRevision struct {
+	ID        uint64    `json:"id,omitempty" db:"id"`
+	MessageID uint64    `json:"message_id,omitempty" db:"message_id"`
+	Body      string    `json:"body,omitempty" db:"body"`
+	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at"`
+}
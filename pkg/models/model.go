@@ -4,6 +4,8 @@ import "errors"
 
 const (
 	MySQLDuplicateEntryNumber = 1062
+
+	MySQLForeignKeyViolationNumber = 1452
 )
 
 var (
@@ -12,4 +14,7 @@ var (
 
 	// ErrDuplicateRecord us used when a unique record already exists
 	ErrDuplicateRecord = errors.New("model: duplicate record was found")
+
+	// ErrForbidden is used when a user is not allowed to perform an action on a resource
+	ErrForbidden = errors.New("model: action is forbidden")
 )
@@ -0,0 +1,62 @@
+package models
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+// PasswordReset allows a User who has forgotten their password to set a new one. The token is single use and is
+// only valid until it expires.
+type PasswordReset struct {
+	ID        uint64     `json:"id,omitempty" db:"id"`
+	UserID    uint64     `json:"user_id,omitempty" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at,omitempty" db:"created_at"`
+}
+
+// CanBeRedeemed checks if the reset token has not been used or expired at the time provided
+func (r PasswordReset) CanBeRedeemed(now time.Time) bool {
+	return r.UsedAt == nil && now.Before(r.ExpiresAt)
+}
+
+// ChangePasswordRequest is the body sent by an authenticated User to change their password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+}
+
+// Validate validates incoming change password request
+func (r ChangePasswordRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.CurrentPassword, validation.Required),
+		validation.Field(&r.Password, validation.Required, validation.Length(8, 0)),
+	)
+}
+
+// ForgotPasswordRequest is the body sent to request a password reset token
+type ForgotPasswordRequest struct {
+	Username string `json:"username"`
+}
+
+// Validate validates incoming forgot password request
+func (r ForgotPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Username, validation.Required),
+	)
+}
+
+// ResetPasswordRequest is the body sent to set a new password using a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// Validate validates incoming reset password request
+func (r ResetPasswordRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Token, validation.Required),
+		validation.Field(&r.Password, validation.Required, validation.Length(8, 0)),
+	)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RefreshToken allows a User to get a new access token without logging in again. Every rotation creates a new
+// RefreshToken in the same family, the family is what ties the tokens of a single login together and its id is
+// the id of the Session for the login.
+type RefreshToken struct {
+	ID        uint64     `json:"id,omitempty" db:"id"`
+	UserID    uint64     `json:"user_id,omitempty" db:"user_id"`
+	FamilyID  uuid.UUID  `json:"family_id,omitempty" db:"family_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at,omitempty" db:"created_at"`
+}
+
+// IsActive checks if the token has not been rotated, revoked or expired at the time provided
+func (t RefreshToken) IsActive(now time.Time) bool {
+	return t.RotatedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// RefreshTokenRequest is the body sent to exchange a refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Session represents a single login of a User on a device. Its ID is shared with the family of refresh tokens
+// issued for the login and is embedded in every access token issued for it.
+type Session struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uint64     `json:"user_id,omitempty" db:"user_id"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IPAddress  string     `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at,omitempty" db:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at,omitempty" db:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	Current    bool       `json:"current" db:"-"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+// TwoFactor is the TOTP two-factor authentication set up for a User. It is only enabled once the user confirms
+// it with a code from their authenticator app.
+type TwoFactor struct {
+	UserID uint64 `json:"user_id" db:"user_id"`
+	// Secret is the encrypted TOTP secret
+	Secret      string     `json:"-" db:"secret"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
+	// LastUsedStep is the TOTP step of the last code used, codes for it or earlier steps cannot be used again
+	LastUsedStep int64     `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsEnabled checks if the two-factor authentication has been confirmed
+func (t TwoFactor) IsEnabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// TwoFactorEnrollment is returned when a User starts setting up two-factor authentication
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorCodeRequest is the body sent with a code from the authenticator app or a recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// Validate validates incoming two-factor code request
+func (r TwoFactorCodeRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.Code, validation.Required),
+	)
+}
+
+// TwoFactorVerifyRequest is the body sent to complete a login using the challenge token returned by the login
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+// Validate validates incoming two-factor verify request
+func (r TwoFactorVerifyRequest) Validate() error {
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.ChallengeToken, validation.Required),
+		validation.Field(&r.Code, validation.Required),
+	)
+}
@@ -2,23 +2,52 @@ package models
 
 import (
 	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/go-ozzo/ozzo-validation/is"
+	"regexp"
 	"time"
 )
 
+// The columns users can be filtered by
+const (
+	UserColumnID       = "id"
+	UserColumnUsername = "username"
+)
+
+// usernameRules are the rules every username is checked against, whether it is picked by the user or derived
+var usernameRules = []validation.Rule{validation.Required}
+
+// avatarURLRegex makes sure avatars are only loaded from http or https URLs
+var avatarURLRegex = regexp.MustCompile(`^https?://`)
+
+// UserProfile has the details a User shows to other users and can edit
+type UserProfile struct {
+	DisplayName string `json:"display_name,omitempty" db:"display_name"`
+	AvatarURL   string `json:"avatar_url,omitempty" db:"avatar_url"`
+	Bio         string `json:"bio,omitempty" db:"bio"`
+	StatusText  string `json:"status_text,omitempty" db:"status_text"`
+	StatusEmoji string `json:"status_emoji,omitempty" db:"status_emoji"`
+}
+
 // User represents a person using the system
 type User struct {
-	ID        uint64    `json:"id,omitempty" db:"id"`
-	Username  string    `json:"username,omitempty" db:"username"`
-	Password  string    `json:"password,omitempty" db:"password"`
+	ID       uint64 `json:"id,omitempty" db:"id"`
+	Username string `json:"username,omitempty" db:"username"`
+	Password string `json:"password,omitempty" db:"password"`
+	UserProfile
 	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"`
 	DeletedAt time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// ValidateUsername checks the username against the rules usernames are registered with
+func ValidateUsername(username string) error {
+	return validation.Validate(username, usernameRules...)
+}
+
 // ValidateRegisterRequest validates incoming registration request
 func (u User) ValidateRegisterRequest() error {
 	return validation.ValidateStruct(&u,
-		validation.Field(&u.Username, validation.Required),
+		validation.Field(&u.Username, usernameRules...),
 		validation.Field(&u.Password, validation.Required, validation.Length(8, 0)),
 	)
 }
@@ -30,3 +59,15 @@ func (u User) ValidateLoginRequest() error {
 		validation.Field(&u.Password, validation.Required, validation.Length(8, 0)),
 	)
 }
+
+// ValidateUpdateRequest validates incoming profile update request
+func (p UserProfile) ValidateUpdateRequest() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.DisplayName, validation.RuneLength(0, 50)),
+		validation.Field(&p.AvatarURL, validation.Length(0, 2048), is.URL,
+			validation.Match(avatarURLRegex).Error("must be an http or https URL")),
+		validation.Field(&p.Bio, validation.RuneLength(0, 500)),
+		validation.Field(&p.StatusText, validation.RuneLength(0, 100)),
+		validation.Field(&p.StatusEmoji, validation.RuneLength(0, 16)),
+	)
+}
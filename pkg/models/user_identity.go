@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// UserIdentity links a User to the account they sign in with at an external identity provider
+type UserIdentity struct {
+	ID       uint64 `json:"id" db:"id"`
+	UserID   uint64 `json:"user_id" db:"user_id"`
+	Provider string `json:"provider" db:"provider"`
+	// Subject is the id of the user at the provider, it never changes unlike the email
+	Subject   string    `json:"subject" db:"subject"`
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,24 @@
+package notifier
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes the messages to the logger instead of delivering them. It is meant for local development.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// Send writes the message to the logger
+func (n *LogNotifier) Send(_ context.Context, msg Message) error {
+	n.logger.Printf("notifier: to=%q subject=%q body=%q", msg.Recipient.Username, msg.Subject, msg.Body)
+	return nil
+}
+
+// NewLogNotifier creates a new LogNotifier
+func NewLogNotifier(logger *log.Logger) Notifier {
+	return &LogNotifier{
+		logger: logger,
+	}
+}
@@ -0,0 +1,26 @@
+package notifier
+
+import (
+	"bytes"
+	"chatapp/pkg/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+)
+
+func TestLogNotifier_Send(t *testing.T) {
+	var buf bytes.Buffer
+
+	n := NewLogNotifier(log.New(&buf, "", 0))
+
+	err := n.Send(context.Background(), Message{
+		Recipient: &models.User{Username: "jwambugu"},
+		Subject:   "Reset your password",
+		Body:      "https://localhost/reset-password?token=abc",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `to="jwambugu"`)
+	assert.Contains(t, buf.String(), "token=abc")
+}
@@ -0,0 +1,18 @@
+package notifier
+
+import (
+	"chatapp/pkg/models"
+	"context"
+)
+
+// Message is a notification sent to a models.User
+type Message struct {
+	Recipient *models.User
+	Subject   string
+	Body      string
+}
+
+// Notifier delivers messages to users. Implementations decide how the message reaches the user, e.g. by email.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
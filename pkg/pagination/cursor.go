@@ -0,0 +1,105 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLimit is the number of records returned when no limit is requested
+	DefaultLimit = 50
+
+	// MaxLimit is the maximum number of records that can be requested at once
+	MaxLimit = 100
+
+	cursorPrefix = "id:"
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor cannot be decoded
+	ErrInvalidCursor = errors.New("pagination: invalid cursor")
+
+	// ErrConflictingCursors is returned when both the before and after cursors are provided
+	ErrConflictingCursors = errors.New("pagination: only one of before or after can be provided")
+
+	// ErrInvalidLimit is returned when the limit is out of range
+	ErrInvalidLimit = errors.New("pagination: limit must be between 1 and 100")
+)
+
+type (
+	// Params describes the page being requested. Only one of Before or After is set.
+	Params struct {
+		Before uint64
+		After  uint64
+		Limit  int
+	}
+
+	// Cursors points to the pages around the current page. Next points to older records and Prev to newer
+	// records, either is empty when there is nothing more to fetch in that direction.
+	Cursors struct {
+		Next string `json:"next"`
+		Prev string `json:"prev"`
+	}
+)
+
+// EncodeCursor returns an opaque cursor for the record id
+func EncodeCursor(id uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatUint(id, 10)))
+}
+
+// DecodeCursor returns the record id the cursor points to
+func DecodeCursor(cursor string) (uint64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	value := string(decoded)
+	if !strings.HasPrefix(value, cursorPrefix) {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(value, cursorPrefix), 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
+
+// NewParams creates Params from the raw cursors and limit provided. A zero limit uses DefaultLimit.
+func NewParams(before, after string, limit int) (Params, error) {
+	params := Params{
+		Limit: limit,
+	}
+
+	if params.Limit == 0 {
+		params.Limit = DefaultLimit
+	}
+
+	if params.Limit < 0 || params.Limit > MaxLimit {
+		return Params{}, ErrInvalidLimit
+	}
+
+	if before != "" && after != "" {
+		return Params{}, ErrConflictingCursors
+	}
+
+	var err error
+
+	if before != "" {
+		if params.Before, err = DecodeCursor(before); err != nil {
+			return Params{}, err
+		}
+	}
+
+	if after != "" {
+		if params.After, err = DecodeCursor(after); err != nil {
+			return Params{}, err
+		}
+	}
+
+	return params, nil
+}
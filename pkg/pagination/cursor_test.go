@@ -0,0 +1,97 @@
+package pagination
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cursor   string
+		wants    uint64
+		wantsErr bool
+	}{
+		{
+			name:   "decodes an encoded cursor",
+			cursor: EncodeCursor(42),
+			wants:  42,
+		},
+		{
+			name:     "fails on a cursor that is not base64",
+			cursor:   "not a cursor!",
+			wantsErr: true,
+		},
+		{
+			name:     "fails on a cursor without the id prefix",
+			cursor:   "NDI",
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeCursor(tc.cursor)
+
+			if tc.wantsErr {
+				assert.ErrorIs(t, err, ErrInvalidCursor)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wants, got)
+		})
+	}
+}
+
+func TestNewParams(t *testing.T) {
+	testCases := []struct {
+		name     string
+		before   string
+		after    string
+		limit    int
+		wants    Params
+		wantsErr error
+	}{
+		{
+			name:  "uses the default limit",
+			wants: Params{Limit: DefaultLimit},
+		},
+		{
+			name:   "decodes the before cursor",
+			before: EncodeCursor(10),
+			limit:  20,
+			wants:  Params{Before: 10, Limit: 20},
+		},
+		{
+			name:  "decodes the after cursor",
+			after: EncodeCursor(10),
+			wants: Params{After: 10, Limit: DefaultLimit},
+		},
+		{
+			name:     "fails when both cursors are provided",
+			before:   EncodeCursor(10),
+			after:    EncodeCursor(5),
+			wantsErr: ErrConflictingCursors,
+		},
+		{
+			name:     "fails when the limit is too large",
+			limit:    MaxLimit + 1,
+			wantsErr: ErrInvalidLimit,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewParams(tc.before, tc.after, tc.limit)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wants, got)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often buckets that have refilled are removed
+const pruneInterval = time.Minute
+
+var (
+	// ErrInvalidLimit is returned if the limit does not allow any requests
+	ErrInvalidLimit = errors.New("ratelimit: requests, per and burst must be greater than zero")
+)
+
+// Limit is the number of requests allowed per period. Burst is the number of requests that can be made at once,
+// the bucket refills at the rate of Requests per Per.
+type Limit struct {
+	Requests int           `yaml:"requests" mapstructure:"requests"`
+	Per      time.Duration `yaml:"per" mapstructure:"per"`
+	Burst    int           `yaml:"burst" mapstructure:"burst"`
+}
+
+// rate returns the number of tokens added to the bucket every second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of requests that can be made right now
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, zero if it was allowed
+	RetryAfter time.Duration
+}
+
+// bucket holds the tokens for a single key
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is a token bucket rate limiter with a bucket per key. Buckets are kept in memory so the limits are per
+// instance.
+type Limiter struct {
+	mu         sync.Mutex
+	limit      Limit
+	buckets    map[string]*bucket
+	lastPruned time.Time
+	now        func() time.Time
+}
+
+// refill adds the tokens earned since the bucket was last updated
+func (l *Limiter) refill(b *bucket, now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+elapsed*l.limit.rate())
+	b.updated = now
+}
+
+// prune removes the buckets that are full since they are the same as a new bucket
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPruned) < pruneInterval {
+		return
+	}
+
+	for key, b := range l.buckets {
+		l.refill(b, now)
+
+		if b.tokens >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastPruned = now
+}
+
+// Allow takes a token from the key's bucket if there is one
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens:  float64(l.limit.Burst),
+			updated: now,
+		}
+
+		l.buckets[key] = b
+	}
+
+	l.refill(b, now)
+
+	result := Result{
+		Limit: l.limit.Burst,
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.secondsToDuration((1 - b.tokens) / l.limit.rate())
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = l.secondsToDuration((float64(l.limit.Burst) - b.tokens) / l.limit.rate())
+
+	return result
+}
+
+// secondsToDuration converts the fractional seconds to a time.Duration
+func (l *Limiter) secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// NewLimiter creates a new Limiter enforcing the limit for every key
+func NewLimiter(limit Limit) (*Limiter, error) {
+	if limit.Requests <= 0 || limit.Per <= 0 || limit.Burst <= 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	limiter := &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+
+	return limiter, nil
+}
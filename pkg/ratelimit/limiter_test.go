@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewLimiter(t *testing.T) {
+	_, err := NewLimiter(Limit{Requests: 1, Per: time.Second, Burst: 0})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	_, err = NewLimiter(Limit{Requests: 1, Per: time.Second, Burst: 1})
+	assert.NoError(t, err)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter, err := NewLimiter(Limit{Requests: 1, Per: time.Second, Burst: 3})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	limiter.now = func() time.Time {
+		return now
+	}
+
+	for i := 2; i >= 0; i-- {
+		result := limiter.Allow("user:1")
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result := limiter.Allow("user:1")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	assert.True(t, limiter.Allow("user:2").Allowed, "every key has its own bucket")
+
+	now = now.Add(1500 * time.Millisecond)
+
+	result = limiter.Allow("user:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	now = now.Add(time.Hour)
+
+	result = limiter.Allow("user:1")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining, "the bucket never holds more than the burst")
+	assert.Len(t, limiter.buckets, 1, "full buckets are pruned")
+}
@@ -0,0 +1,58 @@
+package realtime
+
+const (
+	// EventSubscribe is sent by a client to start receiving a chat room's events
+	EventSubscribe = "subscribe"
+
+	// EventUnsubscribe is sent by a client to stop receiving a chat room's events
+	EventUnsubscribe = "unsubscribe"
+
+	// EventSubscribed acknowledges a successful EventSubscribe
+	EventSubscribed = "subscribed"
+
+	// EventUnsubscribed acknowledges a successful EventUnsubscribe
+	EventUnsubscribed = "unsubscribed"
+
+	// EventMessageCreated is sent by a client to post a message and broadcast once it has been stored
+	EventMessageCreated = "message.created"
+
+	// EventMessageUpdated is broadcast once the author has edited a message
+	EventMessageUpdated = "message.updated"
+
+	// EventMessageDeleted is broadcast with the tombstone of a deleted message
+	EventMessageDeleted = "message.deleted"
+
+	// EventReactionAdded is broadcast with the new count once a member has reacted to a message
+	EventReactionAdded = "reaction.added"
+
+	// EventReactionRemoved is broadcast with the new count once a member has removed their reaction to a message
+	EventReactionRemoved = "reaction.removed"
+
+	// EventError is sent to a client when one of its events could not be handled
+	EventError = "error"
+)
+
+// Event is the envelope for everything sent over a websocket connection
+type Event struct {
+	Type string      `json:"type"`
+	Room string      `json:"room,omitempty"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// IncomingEvent is an Event received from a client
+type IncomingEvent struct {
+	Type string `json:"type"`
+	Room string `json:"room"`
+	Body string `json:"body,omitempty"`
+}
+
+// NewErrorEvent creates an EventError for the room with the message provided
+func NewErrorEvent(room, message string) Event {
+	return Event{
+		Type: EventError,
+		Room: room,
+		Data: map[string]string{
+			"error": message,
+		},
+	}
+}
@@ -0,0 +1,236 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// clientSendBufferSize is the number of events queued for a client before it is considered too slow
+const clientSendBufferSize = 256
+
+var (
+	// ErrHubClosed is returned when interacting with a Hub that has been shut down
+	ErrHubClosed = errors.New("realtime: hub is closed")
+
+	// ErrClientNotRegistered is returned when the client is not connected to the Hub
+	ErrClientNotRegistered = errors.New("realtime: client is not registered")
+)
+
+// Client represents a single connection that can subscribe to chat rooms
+type Client struct {
+	UserID uint64
+	send   chan []byte
+	rooms  map[string]struct{}
+}
+
+// Send returns the channel the client's encoded events are queued on. It is closed once the client
+// has been unregistered from the Hub.
+func (c *Client) Send() <-chan []byte {
+	return c.send
+}
+
+// NewClient creates a new Client for the user
+func NewClient(userID uint64) *Client {
+	return &Client{
+		UserID: userID,
+		send:   make(chan []byte, clientSendBufferSize),
+		rooms:  make(map[string]struct{}),
+	}
+}
+
+// Hub keeps track of the connected clients per chat room and fans out the events broadcast to a room
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	rooms   map[string]map[*Client]struct{}
+	closed  bool
+}
+
+// Register adds the client to the hub
+func (h *Hub) Register(c *Client) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrHubClosed
+	}
+
+	h.clients[c] = struct{}{}
+	return nil
+}
+
+// Unregister removes the client from all the rooms it is subscribed to and closes its send channel.
+// It is safe to call more than once.
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeClient(c)
+}
+
+// removeClient must be called with the lock held
+func (h *Hub) removeClient(c *Client) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+
+	for room := range c.rooms {
+		h.removeFromRoom(c, room)
+	}
+
+	delete(h.clients, c)
+	close(c.send)
+}
+
+// removeFromRoom must be called with the lock held
+func (h *Hub) removeFromRoom(c *Client, room string) {
+	delete(c.rooms, room)
+
+	subscribers, ok := h.rooms[room]
+	if !ok {
+		return
+	}
+
+	delete(subscribers, c)
+
+	if len(subscribers) == 0 {
+		delete(h.rooms, room)
+	}
+}
+
+// Subscribe starts sending the room's events to the client
+func (h *Hub) Subscribe(c *Client, room string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return ErrClientNotRegistered
+	}
+
+	subscribers, ok := h.rooms[room]
+	if !ok {
+		subscribers = make(map[*Client]struct{})
+		h.rooms[room] = subscribers
+	}
+
+	subscribers[c] = struct{}{}
+	c.rooms[room] = struct{}{}
+
+	return nil
+}
+
+// Unsubscribe stops sending the room's events to the client
+func (h *Hub) Unsubscribe(c *Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeFromRoom(c, room)
+}
+
+// UnsubscribeUser stops sending the room's events to every client of the user, e.g. once they have left the room.
+// The clients are told they were unsubscribed.
+func (h *Hub) UnsubscribeUser(userID uint64, room string) error {
+	payload, err := json.Marshal(Event{
+		Type: EventUnsubscribed,
+		Room: room,
+	})
+	if err != nil {
+		return fmt.Errorf("realtime.UnsubscribeUser:: error encoding event - %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for c := range h.rooms[room] {
+		if c.UserID != userID {
+			continue
+		}
+
+		h.removeFromRoom(c, room)
+		h.enqueue(c, payload)
+	}
+
+	return nil
+}
+
+// IsSubscribed checks if the client is receiving the room's events
+func (h *Hub) IsSubscribed(c *Client, room string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := c.rooms[room]
+	return ok
+}
+
+// enqueue queues the payload for the client, dropping the client if its buffer is full.
+// It must be called with the lock held.
+func (h *Hub) enqueue(c *Client, payload []byte) {
+	select {
+	case c.send <- payload:
+	default:
+		h.removeClient(c)
+	}
+}
+
+// Broadcast sends the event to every client subscribed to the room
+func (h *Hub) Broadcast(room string, event Event) error {
+	event.Room = room
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("realtime.Broadcast:: error encoding event - %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return ErrHubClosed
+	}
+
+	for c := range h.rooms[room] {
+		h.enqueue(c, payload)
+	}
+
+	return nil
+}
+
+// SendTo sends the event to a single client
+func (h *Hub) SendTo(c *Client, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("realtime.SendTo:: error encoding event - %v", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return ErrClientNotRegistered
+	}
+
+	h.enqueue(c, payload)
+	return nil
+}
+
+// Shutdown unregisters every client and stops the hub from accepting new ones
+func (h *Hub) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+
+	for c := range h.clients {
+		h.removeClient(c)
+	}
+}
+
+// NewHub creates a new Hub
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*Client]struct{}),
+		rooms:   make(map[string]map[*Client]struct{}),
+	}
+}
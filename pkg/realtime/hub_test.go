@@ -0,0 +1,142 @@
+package realtime
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func receiveEvent(t *testing.T, c *Client) Event {
+	select {
+	case payload, ok := <-c.Send():
+		assert.True(t, ok)
+
+		var event Event
+		assert.NoError(t, json.Unmarshal(payload, &event))
+
+		return event
+	default:
+		t.Fatal("expected an event to be queued for the client")
+	}
+
+	return Event{}
+}
+
+func TestHub_Broadcast(t *testing.T) {
+	hub := NewHub()
+
+	subscriber := NewClient(1)
+	other := NewClient(2)
+
+	assert.NoError(t, hub.Register(subscriber))
+	assert.NoError(t, hub.Register(other))
+	assert.NoError(t, hub.Subscribe(subscriber, "room-1"))
+	assert.NoError(t, hub.Subscribe(other, "room-2"))
+
+	err := hub.Broadcast("room-1", Event{
+		Type: EventMessageCreated,
+		Data: "hello",
+	})
+	assert.NoError(t, err)
+
+	event := receiveEvent(t, subscriber)
+	assert.Equal(t, EventMessageCreated, event.Type)
+	assert.Equal(t, "room-1", event.Room)
+	assert.Equal(t, "hello", event.Data)
+
+	assert.Len(t, other.Send(), 0)
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	hub := NewHub()
+	client := NewClient(1)
+
+	assert.NoError(t, hub.Register(client))
+	assert.NoError(t, hub.Subscribe(client, "room-1"))
+	assert.True(t, hub.IsSubscribed(client, "room-1"))
+
+	hub.Unsubscribe(client, "room-1")
+	assert.False(t, hub.IsSubscribed(client, "room-1"))
+
+	assert.NoError(t, hub.Broadcast("room-1", Event{Type: EventMessageCreated}))
+	assert.Len(t, client.Send(), 0)
+}
+
+func TestHub_UnsubscribeUser(t *testing.T) {
+	hub := NewHub()
+
+	phone, laptop, other := NewClient(1), NewClient(1), NewClient(2)
+
+	for _, c := range []*Client{phone, laptop, other} {
+		assert.NoError(t, hub.Register(c))
+		assert.NoError(t, hub.Subscribe(c, "room-1"))
+	}
+
+	assert.NoError(t, hub.Subscribe(phone, "room-2"))
+	assert.NoError(t, hub.UnsubscribeUser(1, "room-1"))
+
+	for _, c := range []*Client{phone, laptop} {
+		assert.False(t, hub.IsSubscribed(c, "room-1"))
+
+		event := receiveEvent(t, c)
+		assert.Equal(t, EventUnsubscribed, event.Type)
+		assert.Equal(t, "room-1", event.Room)
+	}
+
+	assert.True(t, hub.IsSubscribed(phone, "room-2"))
+	assert.True(t, hub.IsSubscribed(other, "room-1"))
+	assert.Len(t, other.Send(), 0)
+}
+
+func TestHub_Subscribe_RequiresRegistration(t *testing.T) {
+	hub := NewHub()
+
+	err := hub.Subscribe(NewClient(1), "room-1")
+	assert.ErrorIs(t, err, ErrClientNotRegistered)
+}
+
+func TestHub_Unregister(t *testing.T) {
+	hub := NewHub()
+	client := NewClient(1)
+
+	assert.NoError(t, hub.Register(client))
+	assert.NoError(t, hub.Subscribe(client, "room-1"))
+
+	hub.Unregister(client)
+	hub.Unregister(client)
+
+	_, ok := <-client.Send()
+	assert.False(t, ok)
+	assert.Empty(t, hub.rooms)
+}
+
+func TestHub_Broadcast_DropsSlowClients(t *testing.T) {
+	hub := NewHub()
+	client := NewClient(1)
+
+	assert.NoError(t, hub.Register(client))
+	assert.NoError(t, hub.Subscribe(client, "room-1"))
+
+	for i := 0; i <= clientSendBufferSize; i++ {
+		assert.NoError(t, hub.Broadcast("room-1", Event{Type: EventMessageCreated}))
+	}
+
+	assert.NotContains(t, hub.clients, client)
+	assert.ErrorIs(t, hub.SendTo(client, Event{Type: EventError}), ErrClientNotRegistered)
+}
+
+func TestHub_Shutdown(t *testing.T) {
+	hub := NewHub()
+	client := NewClient(1)
+
+	assert.NoError(t, hub.Register(client))
+	assert.NoError(t, hub.Subscribe(client, "room-1"))
+
+	hub.Shutdown()
+
+	_, ok := <-client.Send()
+	assert.False(t, ok)
+
+	assert.ErrorIs(t, hub.Register(NewClient(2)), ErrHubClosed)
+	assert.ErrorIs(t, hub.Broadcast("room-1", Event{Type: EventMessageCreated}), ErrHubClosed)
+}
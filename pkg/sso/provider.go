@@ -0,0 +1,188 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"time"
+)
+
+// authRequestDuration is how long the user has to sign in with the provider
+const authRequestDuration = 10 * time.Minute
+
+var (
+	// ErrInvalidAuthRequest is returned when the callback does not match the auth request it was started with
+	ErrInvalidAuthRequest = errors.New("sso: invalid or expired auth request")
+
+	// ErrInvalidIDToken is returned when the provider does not return a valid ID token
+	ErrInvalidIDToken = errors.New("sso: invalid id token")
+)
+
+type (
+	// ProviderConfig is the configuration of an OpenID Connect provider
+	ProviderConfig struct {
+		IssuerURL    string   `yaml:"issuer_url" mapstructure:"issuer_url"`
+		ClientID     string   `yaml:"client_id" mapstructure:"client_id"`
+		ClientSecret string   `yaml:"client_secret" mapstructure:"client_secret"`
+		RedirectURL  string   `yaml:"redirect_url" mapstructure:"redirect_url"`
+		Scopes       []string `yaml:"scopes" mapstructure:"scopes"`
+	}
+
+	// AuthRequest holds the values the callback is checked against. It has to be kept by the client between the
+	// redirect to the provider and the callback.
+	AuthRequest struct {
+		Provider     string    `json:"provider"`
+		State        string    `json:"state"`
+		Nonce        string    `json:"nonce"`
+		CodeVerifier string    `json:"code_verifier"`
+		ExpiresAt    time.Time `json:"expires_at"`
+	}
+
+	// Claims are the claims read from the ID token of the user who signed in
+	Claims struct {
+		Subject           string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		PreferredUsername string `json:"preferred_username"`
+		Name              string `json:"name"`
+	}
+
+	// Provider signs users in with an OpenID Connect provider using the authorization code flow with PKCE
+	Provider struct {
+		name         string
+		oauth2Config oauth2.Config
+		verifier     *oidc.IDTokenVerifier
+	}
+)
+
+// randomString returns a random URL safe string of n bytes
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("sso.randomString:: error generating random bytes - %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE code challenge of the code verifier
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Name returns the name the provider was configured with
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// NewAuthRequest starts a new sign in with the provider
+func (p *Provider) NewAuthRequest() (*AuthRequest, error) {
+	state, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	codeVerifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthRequest{
+		Provider:     p.name,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(authRequestDuration),
+	}, nil
+}
+
+// AuthCodeURL returns the URL of the provider's consent page the user is redirected to
+func (p *Provider) AuthCodeURL(req *AuthRequest) string {
+	return p.oauth2Config.AuthCodeURL(req.State,
+		oidc.Nonce(req.Nonce),
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge(req.CodeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+}
+
+// Exchange checks the state returned to the callback, exchanges the authorization code for the ID token and returns
+// its claims
+func (p *Provider) Exchange(ctx context.Context, req *AuthRequest, state, code string) (*Claims, error) {
+	if req.Provider != p.name || time.Now().After(req.ExpiresAt) ||
+		subtle.ConstantTimeCompare([]byte(req.State), []byte(state)) != 1 {
+		return nil, ErrInvalidAuthRequest
+	}
+
+	token, err := p.oauth2Config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", req.CodeVerifier))
+	if err != nil {
+		// The provider rejected the code, it may have expired, been used or issued for another code challenge
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			return nil, ErrInvalidAuthRequest
+		}
+
+		return nil, fmt.Errorf("sso.Exchange:: error exchanging code - %v", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(req.Nonce)) != 1 {
+		return nil, ErrInvalidIDToken
+	}
+
+	var claims Claims
+
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("sso.Exchange:: error reading claims - %v", err)
+	}
+
+	return &claims, nil
+}
+
+// NewProvider creates a new Provider using the configuration discovered from the issuer
+func NewProvider(ctx context.Context, name string, config ProviderConfig) (*Provider, error) {
+	provider, err := oidc.NewProvider(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("sso.NewProvider:: error discovering provider %q - %v", name, err)
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"profile", "email"}
+	}
+
+	return &Provider{
+		name: name,
+		oauth2Config: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, scopes...),
+		},
+		verifier: provider.Verifier(&oidc.Config{
+			ClientID: config.ClientID,
+		}),
+	}, nil
+}
@@ -0,0 +1,232 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// mockIssuer is a local OpenID Connect issuer which signs users in without asking for consent
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// codes maps the authorization codes issued to the code challenge and the nonce of the auth request
+	codes  map[string][2]string
+	claims jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	issuer := &mockIssuer{
+		key:   key,
+		codes: make(map[string][2]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/keys", issuer.keys)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *mockIssuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                i.server.URL,
+		"authorization_endpoint":                i.server.URL + "/authorize",
+		"token_endpoint":                        i.server.URL + "/token",
+		"jwks_uri":                              i.server.URL + "/keys",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (i *mockIssuer) keys(w http.ResponseWriter, _ *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+// authorize redirects back with a code straight away as if the user had signed in
+func (i *mockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "pkce is required", http.StatusBadRequest)
+		return
+	}
+
+	i.codes["code"] = [2]string{query.Get("code_challenge"), query.Get("nonce")}
+
+	redirectURL, _ := url.Parse(query.Get("redirect_uri"))
+	redirectURL.RawQuery = url.Values{"code": {"code"}, "state": {query.Get("state")}}.Encode()
+
+	http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+}
+
+func (i *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+
+	code, ok := i.codes[r.PostForm.Get("code")]
+	if !ok || codeChallenge(r.PostForm.Get("code_verifier")) != code[0] {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	delete(i.codes, r.PostForm.Get("code"))
+
+	claims := jwt.MapClaims{
+		"iss":   i.server.URL,
+		"aud":   "chat-app",
+		"sub":   "248289761001",
+		"email": "jay@example.com",
+		"nonce": code[1],
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+
+	for k, v := range i.claims {
+		claims[k] = v
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test"
+
+	signed, _ := idToken.SignedString(i.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+// signIn follows the redirect to the mock issuer and returns the state and code sent to the callback
+func signIn(t *testing.T, authCodeURL string) (string, string) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(authCodeURL)
+	assert.NoError(t, err)
+	_ = res.Body.Close()
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+
+	return location.Query().Get("state"), location.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		claims   jwt.MapClaims
+		tamper   func(req *AuthRequest, state, code string) (string, string)
+		wantsErr error
+	}{
+		{
+			name: "signs in the user",
+		},
+		{
+			name: "fails if the state does not match",
+			tamper: func(_ *AuthRequest, _, code string) (string, string) {
+				return "forged", code
+			},
+			wantsErr: ErrInvalidAuthRequest,
+		},
+		{
+			name: "fails if the auth request has expired",
+			tamper: func(req *AuthRequest, state, code string) (string, string) {
+				req.ExpiresAt = time.Now().Add(-time.Minute)
+				return state, code
+			},
+			wantsErr: ErrInvalidAuthRequest,
+		},
+		{
+			name: "fails if the code verifier does not match",
+			tamper: func(req *AuthRequest, state, code string) (string, string) {
+				req.CodeVerifier = "forged"
+				return state, code
+			},
+			wantsErr: ErrInvalidAuthRequest,
+		},
+		{
+			name:     "fails if the nonce does not match",
+			claims:   jwt.MapClaims{"nonce": "forged"},
+			wantsErr: ErrInvalidIDToken,
+		},
+		{
+			name:     "fails if the id token was issued for another client",
+			claims:   jwt.MapClaims{"aud": "another-app"},
+			wantsErr: ErrInvalidIDToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			issuer.claims = tc.claims
+
+			provider, err := NewProvider(ctx, "company", ProviderConfig{
+				IssuerURL:    issuer.server.URL,
+				ClientID:     "chat-app",
+				ClientSecret: "secret",
+				RedirectURL:  "https://localhost/api/v1/auth/oidc/company/callback",
+			})
+			assert.NoError(t, err)
+
+			req, err := provider.NewAuthRequest()
+			assert.NoError(t, err)
+
+			state, code := signIn(t, provider.AuthCodeURL(req))
+			assert.Equal(t, req.State, state)
+
+			if tc.tamper != nil {
+				state, code = tc.tamper(req, state, code)
+			}
+
+			claims, err := provider.Exchange(ctx, req, state, code)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "248289761001", claims.Subject)
+			assert.Equal(t, "jay@example.com", claims.Email)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is how long each code is valid for
+	Period = 30 * time.Second
+
+	// Digits is the number of digits in a code
+	Digits = 6
+
+	// secretSize is the number of random bytes in a secret, as recommended by RFC 4226
+	secretSize = 20
+
+	// skew is the number of periods before and after the current one a code is accepted for to allow for clock drift
+	skew = 1
+)
+
+// encoding is the base32 encoding authenticator apps expect secrets in
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32 encoded secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("totp.GenerateSecret:: error generating secret - %v", err)
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth URI used to add the secret to an authenticator app, usually shown as a QR code
+func URI(issuer, accountName, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(Digits))
+	params.Set("period", fmt.Sprint(int(Period.Seconds())))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// step returns the number of periods since the unix epoch
+func step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// code returns the code for the secret at the step
+func code(key []byte, step int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
+
+// decodeSecret decodes the base32 secret, ignoring case and spaces
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("totp.decodeSecret:: error decoding secret - %v", err)
+	}
+
+	return key, nil
+}
+
+// Code returns the code for the secret at the time provided
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return code(key, step(t)), nil
+}
+
+// Validate checks if the code is valid for the secret at the time provided. It returns the step the code was
+// generated for so that callers can reject codes that have already been used.
+func Validate(secret, passcode string, t time.Time) (int64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil || len(passcode) != Digits {
+		return 0, false
+	}
+
+	current := step(t)
+
+	for s := current - skew; s <= current+skew; s++ {
+		if hmac.Equal([]byte(code(key, s)), []byte(passcode)) {
+			return s, true
+		}
+	}
+
+	return 0, false
+}
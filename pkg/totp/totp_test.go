@@ -0,0 +1,64 @@
+package totp
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 secret used by the RFC 6238 test vectors
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	testCases := []struct {
+		name  string
+		time  int64
+		wants string
+	}{
+		{name: "matches the rfc test vector at 59", time: 59, wants: "287082"},
+		{name: "matches the rfc test vector at 1111111109", time: 1111111109, wants: "081804"},
+		{name: "matches the rfc test vector at 1234567890", time: 1234567890, wants: "005924"},
+		{name: "matches the rfc test vector at 2000000000", time: 2000000000, wants: "279037"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Code(rfcSecret, time.Unix(tc.time, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wants, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+
+	passcode, err := Code(secret, now)
+	assert.NoError(t, err)
+
+	step, ok := Validate(secret, passcode, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30, step)
+
+	_, ok = Validate(secret, passcode, now.Add(Period))
+	assert.True(t, ok, "codes from the previous period are accepted")
+
+	_, ok = Validate(secret, passcode, now.Add(3*Period))
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Chat App", "jay", "SECRET")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Chat%20App:jay?"))
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=Chat+App")
+}
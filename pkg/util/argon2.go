@@ -0,0 +1,128 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Argon2idParams are the parameters used to create argon2id hashes
+type Argon2idParams struct {
+	// Memory is the amount of memory used in KiB
+	Memory uint32 `yaml:"memory" mapstructure:"memory"`
+	// Iterations is the number of passes over the memory
+	Iterations uint32 `yaml:"iterations" mapstructure:"iterations"`
+	// Parallelism is the number of threads used
+	Parallelism uint8 `yaml:"parallelism" mapstructure:"parallelism"`
+	// SaltLength is the length of the random salt in bytes
+	SaltLength uint32 `yaml:"salt_length" mapstructure:"salt_length"`
+	// KeyLength is the length of the hash in bytes
+	KeyLength uint32 `yaml:"key_length" mapstructure:"key_length"`
+}
+
+// DefaultArgon2idParams follows the OWASP recommendation for argon2id
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// argon2idPrefix is the start of the PHC string format of argon2id hashes
+const argon2idPrefix = "$argon2id$"
+
+// Argon2idHasher hashes passwords using argon2id. Hashes are encoded in the PHC string format
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash> so the parameters used are stored alongside the hash.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// decodeArgon2idHash returns the parameters, salt and key encoded in the hash
+func decodeArgon2idHash(hashedPassword string) (*Argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrUnsupportedHash
+	}
+
+	params := &Argon2idParams{}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return nil, nil, nil, ErrUnsupportedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrUnsupportedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrUnsupportedHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// Hash returns the argon2id hash of the password
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("util.password:: error generating salt - %v", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism,
+		h.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.params.Memory,
+		h.params.Iterations, h.params.Parallelism, base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Compare compares the argon2id hash with the plain text password using the parameters encoded in the hash
+func (h *Argon2idHasher) Compare(hashedPassword, password string) error {
+	params, salt, key, err := decodeArgon2idHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		params.KeyLength)
+
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+// Recognizes checks if the hash is an argon2id hash
+func (h *Argon2idHasher) Recognizes(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, argon2idPrefix)
+}
+
+// NeedsRehash checks if the hash was created with different parameters
+func (h *Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2idHash(hashedPassword)
+	return err != nil || *params != h.params
+}
+
+// NewArgon2idHasher creates a new Argon2idHasher using the parameters provided
+func NewArgon2idHasher(params Argon2idParams) Hasher {
+	return &Argon2idHasher{
+		params: params,
+	}
+}
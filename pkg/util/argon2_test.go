@@ -0,0 +1,79 @@
+package util
+
+import (
+	"chatapp/repository/factory"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// testArgon2idParams keep the tests fast
+var testArgon2idParams = Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasher_Compare(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	password := factory.RandomString(8)
+
+	hashedPassword, err := hasher.Hash(password)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, hasher.Recognizes(hashedPassword))
+	assert.False(t, hasher.NeedsRehash(hashedPassword))
+
+	assert.NoError(t, hasher.Compare(hashedPassword, password))
+	assert.ErrorIs(t, hasher.Compare(hashedPassword, "password"), ErrMismatchedHashAndPassword)
+	assert.ErrorIs(t, hasher.Compare("$argon2id$v=19$invalid", password), ErrUnsupportedHash)
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+
+	assert.True(t, NewArgon2idHasher(stronger).NeedsRehash(hashedPassword))
+	assert.NoError(t, NewArgon2idHasher(stronger).Compare(hashedPassword, password))
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	bcryptHasher := NewBcryptHasher(4)
+	argon2idHasher := NewArgon2idHasher(testArgon2idParams)
+	hasher := NewPasswordHasher(argon2idHasher, bcryptHasher)
+
+	password := factory.RandomString(8)
+
+	bcryptHash, err := bcryptHasher.Hash(password)
+	assert.NoError(t, err)
+
+	argon2idHash, err := hasher.Hash(password)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		hash        string
+		needsRehash bool
+	}{
+		{
+			name:        "verifies and upgrades hashes created by other hashers",
+			hash:        bcryptHash,
+			needsRehash: true,
+		},
+		{
+			name:        "verifies hashes created by the preferred hasher",
+			hash:        argon2idHash,
+			needsRehash: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.NoError(t, hasher.Compare(tc.hash, password))
+			assert.ErrorIs(t, hasher.Compare(tc.hash, "password"), ErrMismatchedHashAndPassword)
+			assert.Equal(t, tc.needsRehash, hasher.NeedsRehash(tc.hash))
+		})
+	}
+
+	assert.ErrorIs(t, hasher.Compare("plain-text", password), ErrUnsupportedHash)
+}
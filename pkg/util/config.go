@@ -1,10 +1,14 @@
 package util
 
 import (
+	"chatapp/pkg/ratelimit"
+	"chatapp/pkg/sso"
 	"fmt"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 type (
@@ -18,6 +22,25 @@ type (
 		MySQL MySQL `yaml:"mysql" mapstructure:"mysql"`
 	}
 
+	// LoginThrottleConfig decides when failed logins are locked out and for how long
+	LoginThrottleConfig struct {
+		// Store is where failed logins are kept, either "mysql" or "memory"
+		Store               string        `yaml:"store" mapstructure:"store"`
+		UsernameMaxFailures int           `yaml:"username_max_failures" mapstructure:"username_max_failures"`
+		IPMaxFailures       int           `yaml:"ip_max_failures" mapstructure:"ip_max_failures"`
+		BaseLockout         time.Duration `yaml:"base_lockout" mapstructure:"base_lockout"`
+		MaxLockout          time.Duration `yaml:"max_lockout" mapstructure:"max_lockout"`
+		ResetAfter          time.Duration `yaml:"reset_after" mapstructure:"reset_after"`
+	}
+
+	// RateLimitsConfig stores the separate rate limits for the auth routes, the rest of the API and the messages sent
+	// over websocket connections
+	RateLimitsConfig struct {
+		Auth      ratelimit.Limit `yaml:"auth" mapstructure:"auth"`
+		REST      ratelimit.Limit `yaml:"rest" mapstructure:"rest"`
+		WebSocket ratelimit.Limit `yaml:"websocket" mapstructure:"websocket"`
+	}
+
 	// Config stores all configuration of the application.
 	Config struct {
 		AppURL        string   `yaml:"app_url" mapstructure:"app_url"`
@@ -26,9 +49,116 @@ type (
 		DBConfig      DBConfig `yaml:"db_config" mapstructure:"db_config"`
 		EncryptionKey string   `yaml:"encryption_key" mapstructure:"encryption_key"`
 		PasetoKey     string   `yaml:"paseto_key" mapstructure:"paseto_key"`
+
+		// PasetoKeys is the keyring used to verify access tokens mapped by the key id and PasetoKeyID is the id of
+		// the key new tokens are created with. Key ids are case-insensitive since viper lowercases map keys.
+		PasetoKeys  map[string]string `yaml:"paseto_keys" mapstructure:"paseto_keys"`
+		PasetoKeyID string            `yaml:"paseto_key_id" mapstructure:"paseto_key_id"`
+
+		// TokenMaker is the access token format, either "paseto_local", "paseto_public" or "jwt". Public paseto
+		// tokens and JWTs are signed using the PKCS #8 PEM private key in TokenSigningKeyFile and the tokens signed by
+		// retired keys are verified using the PEM public keys in TokenVerificationKeyFiles mapped by the key id.
+		TokenMaker                string            `yaml:"token_maker" mapstructure:"token_maker"`
+		TokenSigningKeyID         string            `yaml:"token_signing_key_id" mapstructure:"token_signing_key_id"`
+		TokenSigningKeyFile       string            `yaml:"token_signing_key_file" mapstructure:"token_signing_key_file"`
+		TokenVerificationKeyFiles map[string]string `yaml:"token_verification_key_files" mapstructure:"token_verification_key_files"`
+
+		AccessTokenDuration  time.Duration `yaml:"access_token_duration" mapstructure:"access_token_duration"`
+		RefreshTokenDuration time.Duration `yaml:"refresh_token_duration" mapstructure:"refresh_token_duration"`
+
+		// RevocationStore is where revoked access tokens are kept, either "mysql" or "memory"
+		RevocationStore string `yaml:"revocation_store" mapstructure:"revocation_store"`
+
+		// PasswordResetDuration is how long a password reset token is valid for
+		PasswordResetDuration time.Duration `yaml:"password_reset_duration" mapstructure:"password_reset_duration"`
+
+		// Notifier is how notifications such as password reset links are delivered, only "log" is supported
+		Notifier string `yaml:"notifier" mapstructure:"notifier"`
+
+		// PasswordHasher is the algorithm new passwords are hashed with, either "argon2id" or "bcrypt". Hashes
+		// created by the other algorithm are still verified and are upgraded when the user logs in.
+		PasswordHasher string         `yaml:"password_hasher" mapstructure:"password_hasher"`
+		BcryptCost     int            `yaml:"bcrypt_cost" mapstructure:"bcrypt_cost"`
+		Argon2id       Argon2idParams `yaml:"argon2id" mapstructure:"argon2id"`
+
+		// TwoFactorIssuer is the name shown in authenticator apps and TwoFactorChallengeDuration is how long the user
+		// has to enter their two-factor code after logging in with their password
+		TwoFactorIssuer            string        `yaml:"two_factor_issuer" mapstructure:"two_factor_issuer"`
+		TwoFactorChallengeDuration time.Duration `yaml:"two_factor_challenge_duration" mapstructure:"two_factor_challenge_duration"`
+
+		// MessageEditWindow is how long the author of a message has to edit it after sending it
+		MessageEditWindow time.Duration `yaml:"message_edit_window" mapstructure:"message_edit_window"`
+
+		// DeletedMessageRetention is how long deleted messages are kept as tombstones before they are purged, they
+		// are kept forever if it is zero
+		DeletedMessageRetention time.Duration `yaml:"deleted_message_retention" mapstructure:"deleted_message_retention"`
+
+		// GroupMaxMembers is the most members a group conversation can have before it has to be converted into a
+		// chat room
+		GroupMaxMembers int `yaml:"group_max_members" mapstructure:"group_max_members"`
+
+		// OIDCProviders are the OpenID Connect providers users can sign in with mapped by the name used in the login
+		// routes. Provider names are case-insensitive since viper lowercases map keys.
+		OIDCProviders map[string]sso.ProviderConfig `yaml:"oidc_providers" mapstructure:"oidc_providers"`
+
+		LoginThrottle LoginThrottleConfig `yaml:"login_throttle" mapstructure:"login_throttle"`
+		RateLimits    RateLimitsConfig    `yaml:"rate_limits" mapstructure:"rate_limits"`
 	}
 )
 
+const (
+	// minGroupMaxMembers is the size of the smallest group, a lower limit would not allow any groups
+	minGroupMaxMembers = 3
+	// minArgon2idSaltLength and minArgon2idKeyLength are the smallest salt and hash argon2id is considered safe with
+	minArgon2idSaltLength = 8
+	minArgon2idKeyLength  = 16
+)
+
+// Validate checks the values that would leave a feature unusable or misbehaving rather than failing outright
+func (c *Config) Validate() error {
+	if c.GroupMaxMembers < minGroupMaxMembers {
+		return fmt.Errorf("config.Validate:: group_max_members must be at least %d", minGroupMaxMembers)
+	}
+
+	if c.PasswordHasher != "argon2id" && c.PasswordHasher != "bcrypt" {
+		return fmt.Errorf("config.Validate:: password_hasher must be argon2id or bcrypt")
+	}
+
+	// bcrypt hashes with the default cost below its minimum so every hash would need rehashing, and fails above its
+	// maximum
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		return fmt.Errorf("config.Validate:: bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return c.Argon2id.validate()
+}
+
+// validate checks the params can be used by argon2id, which panics without an iteration or a thread and raises
+// the memory to 8 KiB per thread
+func (p Argon2idParams) validate() error {
+	if p.Iterations < 1 {
+		return fmt.Errorf("config.Validate:: argon2id.iterations must be at least 1")
+	}
+
+	if p.Parallelism < 1 {
+		return fmt.Errorf("config.Validate:: argon2id.parallelism must be at least 1")
+	}
+
+	if p.Memory < 8*uint32(p.Parallelism) {
+		return fmt.Errorf("config.Validate:: argon2id.memory must be at least 8 KiB per thread")
+	}
+
+	if p.SaltLength < minArgon2idSaltLength {
+		return fmt.Errorf("config.Validate:: argon2id.salt_length must be at least %d", minArgon2idSaltLength)
+	}
+
+	if p.KeyLength < minArgon2idKeyLength {
+		return fmt.Errorf("config.Validate:: argon2id.key_length must be at least %d", minArgon2idKeyLength)
+	}
+
+	return nil
+}
+
 // GetAbsolutePath returns the project absolute path from the entry point
 func GetAbsolutePath() string {
 	_, b, _, _ := runtime.Caller(0)
@@ -45,6 +175,33 @@ func ReadConfig(path string) (*Config, error) {
 	viper.AutomaticEnv()
 	viper.AddConfigPath(path)
 
+	viper.SetDefault("access_token_duration", 30*time.Minute)
+	viper.SetDefault("refresh_token_duration", 30*24*time.Hour)
+	viper.SetDefault("revocation_store", "mysql")
+	viper.SetDefault("token_maker", "paseto_local")
+	viper.SetDefault("password_reset_duration", time.Hour)
+	viper.SetDefault("notifier", "log")
+	viper.SetDefault("password_hasher", "argon2id")
+	viper.SetDefault("bcrypt_cost", bcrypt.DefaultCost)
+	viper.SetDefault("argon2id.memory", DefaultArgon2idParams.Memory)
+	viper.SetDefault("argon2id.iterations", DefaultArgon2idParams.Iterations)
+	viper.SetDefault("argon2id.parallelism", DefaultArgon2idParams.Parallelism)
+	viper.SetDefault("argon2id.salt_length", DefaultArgon2idParams.SaltLength)
+	viper.SetDefault("argon2id.key_length", DefaultArgon2idParams.KeyLength)
+	viper.SetDefault("two_factor_issuer", "Chat App")
+	viper.SetDefault("two_factor_challenge_duration", 5*time.Minute)
+	viper.SetDefault("message_edit_window", 15*time.Minute)
+	viper.SetDefault("group_max_members", 10)
+	viper.SetDefault("login_throttle.store", "mysql")
+	viper.SetDefault("login_throttle.username_max_failures", 5)
+	viper.SetDefault("login_throttle.ip_max_failures", 20)
+	viper.SetDefault("login_throttle.base_lockout", 30*time.Second)
+	viper.SetDefault("login_throttle.max_lockout", time.Hour)
+	viper.SetDefault("login_throttle.reset_after", 24*time.Hour)
+	viper.SetDefault("rate_limits.auth", map[string]interface{}{"requests": 10, "per": time.Minute, "burst": 10})
+	viper.SetDefault("rate_limits.rest", map[string]interface{}{"requests": 120, "per": time.Minute, "burst": 60})
+	viper.SetDefault("rate_limits.websocket", map[string]interface{}{"requests": 30, "per": time.Minute, "burst": 10})
+
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("config.ReadInConfig:: error loading config - %v", err)
 	}
@@ -55,5 +212,9 @@ func ReadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("config.Unmarshal:: error unmarshling config - %v", err)
 	}
 
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
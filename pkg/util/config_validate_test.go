@@ -0,0 +1,87 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	validConfig := func(update func(c *Config)) Config {
+		c := Config{
+			GroupMaxMembers: 3,
+			PasswordHasher:  "argon2id",
+			BcryptCost:      bcrypt.DefaultCost,
+			Argon2id:        DefaultArgon2idParams,
+		}
+
+		if update != nil {
+			update(&c)
+		}
+
+		return c
+	}
+
+	testCases := []struct {
+		name     string
+		config   Config
+		wantsErr bool
+	}{
+		{
+			name:   "allows the defaults with groups of the smallest size",
+			config: validConfig(nil),
+		},
+		{
+			name:     "fails if groups could not have enough members",
+			config:   validConfig(func(c *Config) { c.GroupMaxMembers = 2 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails if the password hasher is not supported",
+			config:   validConfig(func(c *Config) { c.PasswordHasher = "md5" }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails if the bcrypt cost is below the minimum",
+			config:   validConfig(func(c *Config) { c.BcryptCost = bcrypt.MinCost - 1 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails if the bcrypt cost is above the maximum",
+			config:   validConfig(func(c *Config) { c.BcryptCost = bcrypt.MaxCost + 1 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails without argon2id iterations",
+			config:   validConfig(func(c *Config) { c.Argon2id.Iterations = 0 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails without argon2id parallelism",
+			config:   validConfig(func(c *Config) { c.Argon2id.Parallelism = 0 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails if the argon2id memory would be raised",
+			config:   validConfig(func(c *Config) { c.Argon2id.Memory = 15 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails if the argon2id salt is too short",
+			config:   validConfig(func(c *Config) { c.Argon2id.SaltLength = 4 }),
+			wantsErr: true,
+		},
+		{
+			name:     "fails if the argon2id key is too short",
+			config:   validConfig(func(c *Config) { c.Argon2id.KeyLength = 8 }),
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			assert.Equal(t, tc.wantsErr, err != nil, "Validate() error = %v", err)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	// ErrInvalidEncryptionKeySize is returned if the encryption key is not of expected size
+	ErrInvalidEncryptionKeySize = fmt.Errorf("util.encrypt: key must be %d characters", chacha20poly1305.KeySize)
+
+	// ErrInvalidCiphertext is returned if the ciphertext cannot be decrypted with the key
+	ErrInvalidCiphertext = errors.New("util.encrypt: invalid ciphertext")
+)
+
+// Encrypter encrypts secrets that have to be stored but must be readable again, such as two-factor secrets,
+// using XChaCha20-Poly1305
+type Encrypter struct {
+	key []byte
+}
+
+// Encrypt returns the base64 encoded nonce and ciphertext of the plaintext
+func (e *Encrypter) Encrypt(plaintext []byte) (string, error) {
+	aead, err := chacha20poly1305.NewX(e.key)
+	if err != nil {
+		return "", fmt.Errorf("util.Encrypt:: error creating cipher - %v", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("util.Encrypt:: error generating nonce - %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decrypt returns the plaintext of the value returned by Encrypt
+func (e *Encrypter) Decrypt(ciphertext string) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(e.key)
+	if err != nil {
+		return nil, fmt.Errorf("util.Decrypt:: error creating cipher - %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
+
+// NewEncrypter creates a new Encrypter using the key provided
+func NewEncrypter(key string) (*Encrypter, error) {
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, ErrInvalidEncryptionKeySize
+	}
+
+	return &Encrypter{
+		key: []byte(key),
+	}, nil
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"chatapp/repository/factory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncrypter_Decrypt(t *testing.T) {
+	_, err := NewEncrypter(factory.RandomString(31))
+	assert.ErrorIs(t, err, ErrInvalidEncryptionKeySize)
+
+	encrypter, err := NewEncrypter(factory.RandomString(32))
+	assert.NoError(t, err)
+
+	ciphertext, err := encrypter.Encrypt([]byte("secret"))
+	assert.NoError(t, err)
+	assert.NotContains(t, ciphertext, "secret")
+
+	plaintext, err := encrypter.Decrypt(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	other, err := NewEncrypter(factory.RandomString(32))
+	assert.NoError(t, err)
+
+	_, err = other.Decrypt(ciphertext)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
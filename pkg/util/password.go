@@ -1,13 +1,82 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
-// HashPassword returns the bcrypt hash of the password
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+var (
+	// ErrMismatchedHashAndPassword is returned when the password does not match the hash
+	ErrMismatchedHashAndPassword = bcrypt.ErrMismatchedHashAndPassword
+
+	// ErrUnsupportedHash is returned when the hash was not created by any of the supported algorithms
+	ErrUnsupportedHash = errors.New("util.password: unsupported password hash")
+)
+
+// Hasher hashes passwords using a single algorithm. The algorithm and its parameters are encoded in the hash so that
+// hashes created with other parameters can still be verified.
+type Hasher interface {
+	// Hash returns the hash of the password
+	Hash(password string) (string, error)
+	// Compare checks if the password matches the hash
+	Compare(hashedPassword, password string) error
+	// Recognizes checks if the hash was created by the algorithm
+	Recognizes(hashedPassword string) bool
+	// NeedsRehash checks if the hash was created with parameters other than the current ones
+	NeedsRehash(hashedPassword string) bool
+}
+
+// PasswordHasher hashes passwords using the preferred Hasher and verifies hashes created by any of its hashers
+type PasswordHasher struct {
+	preferred Hasher
+	hashers   []Hasher
+}
+
+// Hash returns the hash of the password created by the preferred Hasher
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+// Compare checks if the password matches the hash using the Hasher that created it
+func (h *PasswordHasher) Compare(hashedPassword, password string) error {
+	for _, hasher := range h.hashers {
+		if hasher.Recognizes(hashedPassword) {
+			return hasher.Compare(hashedPassword, password)
+		}
+	}
+
+	return ErrUnsupportedHash
+}
+
+// NeedsRehash checks if the hash should be replaced because it was not created by the preferred Hasher or its
+// parameters have since changed
+func (h *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if !h.preferred.Recognizes(hashedPassword) {
+		return true
+	}
+
+	return h.preferred.NeedsRehash(hashedPassword)
+}
+
+// NewPasswordHasher creates a new PasswordHasher. Hashes created by the preferred Hasher or the other hashers
+// provided can be verified.
+func NewPasswordHasher(preferred Hasher, others ...Hasher) *PasswordHasher {
+	return &PasswordHasher{
+		preferred: preferred,
+		hashers:   append([]Hasher{preferred}, others...),
+	}
+}
+
+// BcryptHasher hashes passwords using bcrypt
+type BcryptHasher struct {
+	cost int
+}
+
+// Hash returns the bcrypt hash of the password
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	if err != nil {
 		return "", fmt.Errorf("util.password:: error hashing password - %v", err)
 	}
@@ -15,7 +84,40 @@ func HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
+// Compare compares the bcrypt hash with the plain text password
+func (h *BcryptHasher) Compare(hashedPassword, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+}
+
+// Recognizes checks if the hash is a bcrypt hash
+func (h *BcryptHasher) Recognizes(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") || strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+// NeedsRehash checks if the hash was created with a different cost
+func (h *BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != h.cost
+}
+
+// NewBcryptHasher creates a new BcryptHasher using the cost provided
+func NewBcryptHasher(cost int) Hasher {
+	return &BcryptHasher{
+		cost: cost,
+	}
+}
+
+// defaultPasswordHasher creates bcrypt hashes and verifies both bcrypt and argon2id hashes
+var defaultPasswordHasher = NewPasswordHasher(NewBcryptHasher(bcrypt.DefaultCost),
+	NewArgon2idHasher(DefaultArgon2idParams))
+
+// HashPassword returns the bcrypt hash of the password
+func HashPassword(password string) (string, error) {
+	return defaultPasswordHasher.Hash(password)
+}
+
 // CompareHashAndPassword compares the hashed password with the plain text password
 func CompareHashAndPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return defaultPasswordHasher.Compare(hashedPassword, password)
 }
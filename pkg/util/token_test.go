@@ -0,0 +1,27 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGenerateRandomToken(t *testing.T) {
+	token, err := GenerateRandomToken(32)
+	assert.NoError(t, err)
+	assert.Len(t, token, 43)
+
+	other, err := GenerateRandomToken(32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestHashToken(t *testing.T) {
+	token, err := GenerateRandomToken(32)
+	assert.NoError(t, err)
+
+	hashedToken := HashToken(token)
+
+	assert.Len(t, hashedToken, 64)
+	assert.Equal(t, hashedToken, HashToken(token))
+	assert.NotEqual(t, hashedToken, HashToken(token+"x"))
+}
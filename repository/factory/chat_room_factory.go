@@ -0,0 +1,21 @@
+package factory
+
+import (
+	"chatapp/pkg/models"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
+	"time"
+)
+
+// NewChatRoom creates a random chat room owned by the user provided
+func NewChatRoom(userID uint64) *models.ChatRoom {
+	now := time.Now()
+
+	return &models.ChatRoom{
+		UUID:      uuid.New(),
+		Name:      gofakeit.Company(),
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,22 @@
+package factory
+
+import (
+	"chatapp/pkg/models"
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
+	"time"
+)
+
+// NewMessage creates a random message for the chat room and user provided
+func NewMessage(chatRoomID, userID uint64) *models.Message {
+	now := time.Now()
+
+	return &models.Message{
+		UUID:       uuid.New(),
+		ChatRoomID: chatRoomID,
+		UserID:     userID,
+		Body:       gofakeit.Sentence(10),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
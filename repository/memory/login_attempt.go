@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/loginattempt"
+	"context"
+	"sync"
+	"time"
+)
+
+// loginAttemptRepo implements loginattempt.Repository. Failures are lost on restart and are not shared between
+// instances, so it is only suitable for local development and single instance deployments.
+type loginAttemptRepo struct {
+	mu         sync.Mutex
+	attempts   map[string]*models.LoginAttempt
+	lastPruned time.Time
+}
+
+// Find fetches the models.LoginAttempt for the key
+func (r *loginAttemptRepo) Find(_ context.Context, key string) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	found := *attempt
+	return &found, nil
+}
+
+// prune removes the attempts whose failures have been forgotten and are no longer locked, at most once every
+// pruneInterval. It must be called with the lock held.
+func (r *loginAttemptRepo) prune(now, resetBefore time.Time) {
+	if now.Sub(r.lastPruned) < pruneInterval {
+		return
+	}
+
+	for key, attempt := range r.attempts {
+		if attempt.LastFailedAt.Before(resetBefore) && attempt.RetryAfter(now) == 0 {
+			delete(r.attempts, key)
+		}
+	}
+
+	r.lastPruned = now
+}
+
+// RecordFailure counts a failed login for the key, starting over if the last failure was before resetBefore
+func (r *loginAttemptRepo) RecordFailure(_ context.Context, key string, failedAt, resetBefore time.Time) (
+	*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(failedAt, resetBefore)
+
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.LastFailedAt.Before(resetBefore) {
+		attempt = &models.LoginAttempt{
+			Key: key,
+		}
+
+		r.attempts[key] = attempt
+	}
+
+	attempt.Failures++
+	attempt.LastFailedAt = failedAt
+
+	found := *attempt
+	return &found, nil
+}
+
+// Lock stops logins for the key until the time provided
+func (r *loginAttemptRepo) Lock(_ context.Context, key string, lockedUntil time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempt, ok := r.attempts[key]; ok {
+		attempt.LockedUntil = &lockedUntil
+	}
+
+	return nil
+}
+
+// Delete forgets the failed logins for the key
+func (r *loginAttemptRepo) Delete(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// NewLoginAttemptRepository creates a new in-memory login attempt repository
+func NewLoginAttemptRepository() loginattempt.Repository {
+	return &loginAttemptRepo{
+		attempts: make(map[string]*models.LoginAttempt),
+	}
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptRepo_RecordFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginAttemptRepository()
+
+	now := time.Now()
+	resetBefore := now.Add(-time.Hour)
+
+	attempt, err := repo.RecordFailure(ctx, "username:jay", now.Add(-2*time.Hour), resetBefore.Add(-2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+
+	assert.NoError(t, repo.Lock(ctx, "username:jay", now.Add(time.Minute)))
+
+	attempt, err = repo.RecordFailure(ctx, "username:jay", now, resetBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures, "failures before resetBefore are forgotten")
+	assert.Nil(t, attempt.LockedUntil)
+
+	attempt, err = repo.RecordFailure(ctx, "username:jay", now, resetBefore)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempt.Failures)
+
+	assert.NoError(t, repo.Delete(ctx, "username:jay"))
+
+	_, err = repo.Find(ctx, "username:jay")
+	assert.ErrorIs(t, err, models.ErrNoRecord)
+}
+
+func TestLoginAttemptRepo_RecordFailure_PrunesStale(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLoginAttemptRepository().(*loginAttemptRepo)
+
+	now := time.Now()
+	resetBefore := now.Add(-time.Hour)
+
+	_, err := repo.RecordFailure(ctx, "username:jay", now.Add(-2*time.Hour), resetBefore.Add(-2*time.Hour))
+	assert.NoError(t, err)
+
+	repo.lastPruned = now
+
+	_, err = repo.RecordFailure(ctx, "username:kim", now, resetBefore)
+	assert.NoError(t, err)
+	assert.Len(t, repo.attempts, 2, "stale attempts are kept until the next prune")
+
+	repo.lastPruned = now.Add(-pruneInterval)
+
+	_, err = repo.RecordFailure(ctx, "username:kim", now, resetBefore)
+	assert.NoError(t, err)
+	assert.Len(t, repo.attempts, 1)
+}
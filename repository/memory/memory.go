@@ -0,0 +1,7 @@
+package memory
+
+import "time"
+
+// pruneInterval is how often the repositories remove the entries that have expired. Pruning scans every entry so
+// it is not done on every write.
+const pruneInterval = time.Minute
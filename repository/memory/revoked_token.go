@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"chatapp/services/revocation"
+	"context"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+// revokedTokenRepo implements revocation.Repository. Revocations are lost on restart and are not shared between
+// instances, so it is only suitable for local development and single instance deployments.
+type revokedTokenRepo struct {
+	mu         sync.RWMutex
+	revoked    map[uuid.UUID]time.Time
+	lastPruned time.Time
+}
+
+// prune removes the revocations of the tokens that have expired, at most once every pruneInterval. It must be called
+// with the lock held.
+func (r *revokedTokenRepo) prune(now time.Time) {
+	if now.Sub(r.lastPruned) < pruneInterval {
+		return
+	}
+
+	for id, expiresAt := range r.revoked {
+		if !now.Before(expiresAt) {
+			delete(r.revoked, id)
+		}
+	}
+
+	r.lastPruned = now
+}
+
+// Revoke stores the token or session uuid until it expires
+func (r *revokedTokenRepo) Revoke(_ context.Context, id uuid.UUID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(time.Now())
+
+	r.revoked[id] = expiresAt
+	return nil
+}
+
+// IsRevoked checks if any of the token or session uuids has been revoked and has not expired yet
+func (r *revokedTokenRepo) IsRevoked(_ context.Context, ids ...uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+
+	for _, id := range ids {
+		if expiresAt, ok := r.revoked[id]; ok && now.Before(expiresAt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// NewRevokedTokenRepository creates a new in-memory revoked token repository
+func NewRevokedTokenRepository() revocation.Repository {
+	return &revokedTokenRepo{
+		revoked: make(map[uuid.UUID]time.Time),
+	}
+}
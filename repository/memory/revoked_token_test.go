@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRevokedTokenRepo_IsRevoked(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRevokedTokenRepository()
+
+	revoked := uuid.New()
+	expired := uuid.New()
+
+	assert.NoError(t, repo.Revoke(ctx, revoked, time.Now().Add(time.Minute)))
+	assert.NoError(t, repo.Revoke(ctx, expired, time.Now().Add(-time.Minute)))
+
+	testCases := []struct {
+		name      string
+		tokenUUID uuid.UUID
+		wants     bool
+	}{
+		{
+			name:      "reports a revoked token",
+			tokenUUID: revoked,
+			wants:     true,
+		},
+		{
+			name:      "ignores a revoked token that has expired",
+			tokenUUID: expired,
+			wants:     false,
+		},
+		{
+			name:      "ignores a token that was never revoked",
+			tokenUUID: uuid.New(),
+			wants:     false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := repo.IsRevoked(ctx, tc.tokenUUID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wants, got)
+		})
+	}
+}
+
+func TestRevokedTokenRepo_Revoke_PrunesExpired(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRevokedTokenRepository().(*revokedTokenRepo)
+
+	assert.NoError(t, repo.Revoke(ctx, uuid.New(), time.Now().Add(-time.Minute)))
+	assert.NoError(t, repo.Revoke(ctx, uuid.New(), time.Now().Add(-time.Minute)))
+	assert.Len(t, repo.revoked, 2, "expired tokens are kept until the next prune")
+
+	repo.lastPruned = time.Now().Add(-pruneInterval)
+
+	assert.NoError(t, repo.Revoke(ctx, uuid.New(), time.Now().Add(time.Minute)))
+	assert.Len(t, repo.revoked, 1)
+}
@@ -21,51 +21,219 @@ const (
 	queryChatRoomCreate = `INSERT INTO chat_rooms (uuid, name, users_count, is_private, user_id, created_at, updated_at)
 	VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	queryChatRoomFindByID = `SELECT id, uuid, name, users_count, is_private, created_at, updated_at
+	queryChatRoomCreateWithMembers = `INSERT INTO chat_rooms (uuid, name, kind, member_key, users_count, is_private,
+		user_id, created_at, updated_at)
+	VALUES (?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)`
+
+	queryChatRoomFindByID = `SELECT id, uuid, name, kind, users_count, is_private, created_at, updated_at
 	FROM chat_rooms WHERE id = ?
 		AND deleted_at IS NULL`
 
-	queryChatRoomFindByUUID = `SELECT id, uuid, name, users_count, is_private, created_at, updated_at
+	queryChatRoomFindByUUID = `SELECT id, uuid, name, kind, users_count, is_private, created_at, updated_at
 	FROM chat_rooms WHERE uuid = ?
 		AND deleted_at IS NULL`
 
+	queryChatRoomFindByMemberKey = `SELECT id, uuid, name, kind, users_count, is_private, created_at, updated_at
+	FROM chat_rooms WHERE member_key = ?
+		AND deleted_at IS NULL`
+
 	queryChatRoomSoftDelete = `UPDATE chat_rooms SET deleted_at = ? WHERE id = ?`
 
-	queryChatRoomFindByUserID = `SELECT id, uuid, name, users_count, is_private, created_at, updated_at
-	FROM chat_rooms WHERE user_id = ?
+	queryChatRoomFindByUserID = `SELECT chat_rooms.id, chat_rooms.uuid, chat_rooms.name, chat_rooms.kind,
+		chat_rooms.users_count, chat_rooms.is_private, chat_rooms.created_at, chat_rooms.updated_at
+	FROM chat_rooms
+		INNER JOIN chat_room_members ON chat_room_members.chat_room_id = chat_rooms.id
+	WHERE chat_room_members.user_id = ?
+		AND chat_rooms.kind = ?
+		AND chat_rooms.deleted_at IS NULL
+	ORDER BY chat_rooms.id`
+
+	// queryChatRoomFindDirectByUserID aliases the other participant's columns so that they are scanned into
+	// models.DirectChatRoom Participant, conversations with deleted accounts are left out
+	queryChatRoomFindDirectByUserID = `SELECT chat_rooms.id, chat_rooms.uuid, chat_rooms.kind, chat_rooms.users_count,
+		chat_rooms.is_private, chat_rooms.created_at, chat_rooms.updated_at, users.id AS "participant.id",
+		users.username AS "participant.username", users.display_name AS "participant.display_name",
+		users.avatar_url AS "participant.avatar_url", users.bio AS "participant.bio",
+		users.status_text AS "participant.status_text", users.status_emoji AS "participant.status_emoji",
+		users.created_at AS "participant.created_at", users.updated_at AS "participant.updated_at"
+	FROM chat_rooms
+		INNER JOIN chat_room_members ON chat_room_members.chat_room_id = chat_rooms.id
+		INNER JOIN chat_room_members AS participants ON participants.chat_room_id = chat_rooms.id
+			AND participants.user_id <> chat_room_members.user_id
+		INNER JOIN users ON users.id = participants.user_id
+			AND users.deleted_at IS NULL
+	WHERE chat_room_members.user_id = ?
+		AND chat_rooms.kind = ?
+		AND chat_rooms.deleted_at IS NULL
+	ORDER BY chat_rooms.id`
+
+	queryChatRoomUpdateName = `UPDATE chat_rooms SET name = ?, updated_at = ?
+	WHERE id = ?
+		AND deleted_at IS NULL`
+
+	queryChatRoomUpdateMemberKey = `UPDATE chat_rooms SET member_key = NULLIF(?, ''), updated_at = ?
+	WHERE id = ?
+		AND deleted_at IS NULL`
+
+	queryChatRoomConvertToRoom = `UPDATE chat_rooms SET name = ?, kind = ?, member_key = NULL, user_id = ?, updated_at = ?
+	WHERE id = ?
+		AND kind = ?
 		AND deleted_at IS NULL`
+
+	queryChatRoomMemberCreate = `INSERT INTO chat_room_members (chat_room_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`
+
+	queryChatRoomMemberFind = `SELECT chat_room_members.chat_room_id, chat_room_members.user_id, users.username,
+		chat_room_members.role, chat_room_members.created_at
+	FROM chat_room_members
+		INNER JOIN users ON users.id = chat_room_members.user_id
+		INNER JOIN chat_rooms ON chat_rooms.id = chat_room_members.chat_room_id
+	WHERE chat_room_members.chat_room_id = ?
+		AND chat_room_members.user_id = ?
+		AND chat_rooms.deleted_at IS NULL`
+
+	queryChatRoomMemberUpdateRole = `UPDATE chat_room_members SET role = ? WHERE chat_room_id = ? AND user_id = ?`
+
+	queryChatRoomMemberDelete = `DELETE FROM chat_room_members WHERE chat_room_id = ? AND user_id = ?`
+
+	queryChatRoomMemberIDsForUpdate = `SELECT user_id FROM chat_room_members WHERE chat_room_id = ? FOR UPDATE`
+
+	queryChatRoomIncrementUsersCount = `UPDATE chat_rooms SET users_count = users_count + 1, updated_at = ? WHERE id = ?`
+
+	queryChatRoomAddUsersCount = `UPDATE chat_rooms SET users_count = users_count + ?, updated_at = ? WHERE id = ?`
+
+	queryChatRoomDecrementUsersCount = `UPDATE chat_rooms SET users_count = users_count - 1, updated_at = ?
+	WHERE id = ?
+		AND users_count > 0`
+
+	queryChatRoomMembersFindByChatRoomID = `SELECT chat_room_members.chat_room_id, chat_room_members.user_id,
+		users.username, chat_room_members.role, chat_room_members.created_at
+	FROM chat_room_members
+		INNER JOIN users ON users.id = chat_room_members.user_id
+	WHERE chat_room_members.chat_room_id = ?
+		AND users.deleted_at IS NULL
+	ORDER BY chat_room_members.created_at`
+
+	queryChatRoomInviteCreate = `INSERT INTO chat_room_invites (chat_room_id, user_id, token_hash, max_uses, uses, expires_at,
+		created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	queryChatRoomInviteFindByTokenHashForUpdate = `SELECT chat_room_invites.id, chat_room_invites.chat_room_id,
+		chat_room_invites.user_id, chat_room_invites.token_hash, chat_room_invites.max_uses, chat_room_invites.uses,
+		chat_room_invites.expires_at, chat_room_invites.created_at
+	FROM chat_room_invites
+		INNER JOIN chat_rooms ON chat_rooms.id = chat_room_invites.chat_room_id
+	WHERE chat_room_invites.token_hash = ?
+		AND chat_rooms.deleted_at IS NULL
+	FOR UPDATE`
+
+	queryChatRoomInviteIncrementUses = `UPDATE chat_room_invites SET uses = uses + 1 WHERE id = ?`
+
+	queryChatRoomMemberExists = `SELECT EXISTS(SELECT 1 FROM chat_room_members WHERE chat_room_id = ? AND user_id = ?)`
 )
 
-// Create adds a new models.ChatRoom
+// isDuplicateEntryError checks if the error was caused by a unique constraint violation
+func isDuplicateEntryError(err error) bool {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		return mysqlErr.Number == models.MySQLDuplicateEntryNumber
+	}
+
+	return false
+}
+
+// isForeignKeyViolationError checks if the error was caused by referencing a record that does not exist
+func isForeignKeyViolationError(err error) bool {
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		return mysqlErr.Number == models.MySQLForeignKeyViolationNumber
+	}
+
+	return false
+}
+
+// Create adds a new models.ChatRoom and makes its creator the first member
 func (r *chatRoomRepo) Create(ctx context.Context, room *models.ChatRoom) (*models.ChatRoom, error) {
-	stmt, err := r.db.PrepareContext(ctx, queryChatRoomCreate)
+	room.UsersCount = 1
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, queryChatRoomCreate, room.UUID, room.Name, room.UsersCount, room.IsPrivate,
+			room.UserID, room.CreatedAt, room.UpdatedAt)
+
+		if err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			return fmt.Errorf("chatRoomRepo.Create:: error inserting record - %v", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.Create:: error getting id - %v", err)
+		}
+
+		room.ID = uint64(id)
+
+		_, err = tx.ExecContext(ctx, queryChatRoomMemberCreate, room.ID, room.UserID, models.ChatRoomRoleOwner,
+			room.CreatedAt)
+
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.Create:: error inserting member - %v", err)
+		}
+
+		return nil
+	})
+
 	if err != nil {
-		return nil, fmt.Errorf("chatRoomRepo.Create:: error creating prepared stmt - %v", err)
+		return nil, err
 	}
 
-	defer func(stmt *sql.Stmt) {
-		_ = stmt.Close()
-	}(stmt)
+	return room, nil
+}
 
-	result, err := stmt.ExecContext(ctx, room.UUID, room.Name, room.UsersCount, room.IsPrivate, room.UserID,
-		room.CreatedAt, room.UpdatedAt)
+// CreateWithMembers adds a new models.ChatRoom of any kind with the users provided as its members. A
+// models.ErrDuplicateRecord is returned if a chat room with the same member key exists.
+func (r *chatRoomRepo) CreateWithMembers(ctx context.Context, room *models.ChatRoom, userIDs []uint64) (
+	*models.ChatRoom, error) {
+	room.UsersCount = uint(len(userIDs))
 
-	if err != nil {
-		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-			if mysqlErr.Number == models.MySQLDuplicateEntryNumber {
-				return nil, models.ErrDuplicateRecord
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, queryChatRoomCreateWithMembers, room.UUID, room.Name, room.Kind,
+			room.MemberKey, room.UsersCount, room.IsPrivate, room.UserID, room.CreatedAt, room.UpdatedAt)
+
+		if err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
 			}
+
+			return fmt.Errorf("chatRoomRepo.CreateWithMembers:: error inserting record - %v", err)
 		}
 
-		return nil, fmt.Errorf("chatRoomRepo.Create:: error inserting record - %v", err)
-	}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.CreateWithMembers:: error getting id - %v", err)
+		}
+
+		room.ID = uint64(id)
+
+		for _, userID := range userIDs {
+			_, err := tx.ExecContext(ctx, queryChatRoomMemberCreate, room.ID, userID, models.ChatRoomRoleMember,
+				room.CreatedAt)
+
+			if err != nil {
+				if isForeignKeyViolationError(err) {
+					return models.ErrNoRecord
+				}
+
+				return fmt.Errorf("chatRoomRepo.CreateWithMembers:: error inserting member - %v", err)
+			}
+		}
+
+		return nil
+	})
 
-	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, fmt.Errorf("chatRoomRepo.Create:: error getting id - %v", err)
+		return nil, err
 	}
 
-	room.ID = uint64(id)
 	return room, nil
 }
 
@@ -99,15 +267,30 @@ func (r *chatRoomRepo) FindByUUID(ctx context.Context, uuid string) (*models.Cha
 	return foundRoom, nil
 }
 
-// CheckIfExists looks up if a given column exists
-func (r *chatRoomRepo) CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error) {
-	var exists bool
+// FindByMemberKey fetches the models.ChatRoom shared by the members the key was created for
+func (r *chatRoomRepo) FindByMemberKey(ctx context.Context, memberKey string) (*models.ChatRoom, error) {
+	foundRoom := &models.ChatRoom{}
+
+	if err := r.db.GetContext(ctx, foundRoom, queryChatRoomFindByMemberKey, memberKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
 
-	if err := r.db.GetContext(ctx, &exists, fmt.Sprintf(queryUsersCheckIfExists, column, value)); err != nil {
-		return false, fmt.Errorf("chatRoomRepo.CheckIfExists:: error executing query - %v", err)
+		return nil, fmt.Errorf("chatRoomRepo.FindByMemberKey:: error finding chat room - %v", err)
 	}
 
-	return exists, nil
+	return foundRoom, nil
+}
+
+// Exists checks if a chat room matching every filter exists, only the models.ChatRoomColumn columns can be
+// filtered by
+func (r *chatRoomRepo) Exists(ctx context.Context, filters ...models.Filter) (bool, error) {
+	found, err := exists(ctx, r.db, "chat_rooms", chatRoomFilterColumns, filters)
+	if err != nil {
+		return false, fmt.Errorf("chatRoomRepo.Exists:: %w", err)
+	}
+
+	return found, nil
 }
 
 // SoftDelete marks the given models.ChatRoom as deleted
@@ -131,11 +314,13 @@ func (r *chatRoomRepo) SoftDelete(ctx context.Context, id uint64) error {
 	return nil
 }
 
-// GetUserChatRooms returns  []models.ChatRoom for the models.User
-func (r *chatRoomRepo) GetUserChatRooms(ctx context.Context, userID uint64) ([]models.ChatRoom, error) {
+// GetUserChatRooms returns  []models.ChatRoom of the kind provided for the models.User
+func (r *chatRoomRepo) GetUserChatRooms(ctx context.Context, userID uint64, kind models.ChatRoomKind) (
+	[]models.ChatRoom, error) {
 	var chatRooms []models.ChatRoom
 
-	if err := r.db.SelectContext(ctx, &chatRooms, queryChatRoomFindByUserID, userID); err != nil {
+	err := r.db.SelectContext(ctx, &chatRooms, queryChatRoomFindByUserID, userID, kind)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoRecord
 		}
@@ -150,6 +335,356 @@ func (r *chatRoomRepo) GetUserChatRooms(ctx context.Context, userID uint64) ([]m
 	return chatRooms, nil
 }
 
+// GetUserDirectChatRooms returns the []models.DirectChatRoom the models.User is a participant of
+func (r *chatRoomRepo) GetUserDirectChatRooms(ctx context.Context, userID uint64) ([]models.DirectChatRoom, error) {
+	var chatRooms []models.DirectChatRoom
+
+	err := r.db.SelectContext(ctx, &chatRooms, queryChatRoomFindDirectByUserID, userID, models.ChatRoomKindDirect)
+	if err != nil {
+		return nil, fmt.Errorf("chatRoomRepo.GetUserDirectChatRooms:: error getting user chatrooms - %v", err)
+	}
+
+	if len(chatRooms) == 0 {
+		return []models.DirectChatRoom{}, nil
+	}
+
+	return chatRooms, nil
+}
+
+// AddMember adds the models.User to the models.ChatRoom members with the role provided and updates the users count
+func (r *chatRoomRepo) AddMember(ctx context.Context, chatRoomID, userID uint64, role models.ChatRoomRole) error {
+	now := time.Now()
+
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, queryChatRoomMemberCreate, chatRoomID, userID, role, now); err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			if isForeignKeyViolationError(err) {
+				return models.ErrNoRecord
+			}
+
+			return fmt.Errorf("chatRoomRepo.AddMember:: error inserting record - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomIncrementUsersCount, now, chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.AddMember:: error updating users count - %v", err)
+		}
+
+		return nil
+	})
+}
+
+// AddGroupMembers adds users to the group and replaces its member key in a single transaction. The members are
+// locked while add picks the users to add from the current members so concurrent changes cannot get around its
+// rules. A models.ErrDuplicateRecord is returned if another chat room has the resulting member key.
+func (r *chatRoomRepo) AddGroupMembers(ctx context.Context, chatRoomID uint64,
+	add func(current []uint64) ([]uint64, error)) error {
+	now := time.Now()
+
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var current []uint64
+
+		if err := tx.SelectContext(ctx, &current, queryChatRoomMemberIDsForUpdate, chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.AddGroupMembers:: error locking members - %v", err)
+		}
+
+		userIDs, err := add(current)
+		if err != nil {
+			return err
+		}
+
+		memberKey := models.GroupMemberKey(append(append([]uint64{}, current...), userIDs...))
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomUpdateMemberKey, memberKey, now, chatRoomID); err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			return fmt.Errorf("chatRoomRepo.AddGroupMembers:: error updating member key - %v", err)
+		}
+
+		for _, userID := range userIDs {
+			_, err := tx.ExecContext(ctx, queryChatRoomMemberCreate, chatRoomID, userID, models.ChatRoomRoleMember, now)
+			if err != nil {
+				if isDuplicateEntryError(err) {
+					return models.ErrDuplicateRecord
+				}
+
+				if isForeignKeyViolationError(err) {
+					return models.ErrNoRecord
+				}
+
+				return fmt.Errorf("chatRoomRepo.AddGroupMembers:: error inserting member - %v", err)
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomAddUsersCount, len(userIDs), now, chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.AddGroupMembers:: error updating users count - %v", err)
+		}
+
+		return nil
+	})
+}
+
+// RemoveMember removes the models.User from the models.ChatRoom members and updates the users count
+func (r *chatRoomRepo) RemoveMember(ctx context.Context, chatRoomID, userID uint64) error {
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, queryChatRoomMemberDelete, chatRoomID, userID)
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveMember:: error deleting record - %v", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveMember:: error getting affected rows - %v", err)
+		}
+
+		if affected == 0 {
+			return models.ErrNoRecord
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomDecrementUsersCount, time.Now(), chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveMember:: error updating users count - %v", err)
+		}
+
+		return nil
+	})
+}
+
+// GetMembers returns []models.ChatRoomMember for the models.ChatRoom
+func (r *chatRoomRepo) GetMembers(ctx context.Context, chatRoomID uint64) ([]models.ChatRoomMember, error) {
+	var members []models.ChatRoomMember
+
+	if err := r.db.SelectContext(ctx, &members, queryChatRoomMembersFindByChatRoomID, chatRoomID); err != nil {
+		return nil, fmt.Errorf("chatRoomRepo.GetMembers:: error getting chat room members - %v", err)
+	}
+
+	if len(members) == 0 {
+		return []models.ChatRoomMember{}, nil
+	}
+
+	return members, nil
+}
+
+// GetMember fetches the models.ChatRoomMember for the models.User
+func (r *chatRoomRepo) GetMember(ctx context.Context, chatRoomID, userID uint64) (*models.ChatRoomMember, error) {
+	member := &models.ChatRoomMember{}
+
+	if err := r.db.GetContext(ctx, member, queryChatRoomMemberFind, chatRoomID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("chatRoomRepo.GetMember:: error finding member - %v", err)
+	}
+
+	return member, nil
+}
+
+// UpdateMemberRole changes the role the models.User has in the models.ChatRoom
+func (r *chatRoomRepo) UpdateMemberRole(ctx context.Context, chatRoomID, userID uint64, role models.ChatRoomRole) error {
+	result, err := r.db.ExecContext(ctx, queryChatRoomMemberUpdateRole, role, chatRoomID, userID)
+	if err != nil {
+		return fmt.Errorf("chatRoomRepo.UpdateMemberRole:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("chatRoomRepo.UpdateMemberRole:: error getting affected rows - %v", err)
+	}
+
+	if affected == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// UpdateName renames the models.ChatRoom
+func (r *chatRoomRepo) UpdateName(ctx context.Context, id uint64, name string) error {
+	result, err := r.db.ExecContext(ctx, queryChatRoomUpdateName, name, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("chatRoomRepo.UpdateName:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("chatRoomRepo.UpdateName:: error getting affected rows - %v", err)
+	}
+
+	if affected == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// RemoveGroupMember removes the models.User from the group and updates its member key to the members who remain,
+// the key is removed if another group has the same members. The members are locked while check decides whether the
+// user can leave so concurrent changes cannot get around it.
+func (r *chatRoomRepo) RemoveGroupMember(ctx context.Context, chatRoomID, userID uint64,
+	check func(remaining []uint64) error) error {
+	now := time.Now()
+
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var memberIDs []uint64
+
+		if err := tx.SelectContext(ctx, &memberIDs, queryChatRoomMemberIDsForUpdate, chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveGroupMember:: error locking members - %v", err)
+		}
+
+		remaining := make([]uint64, 0, len(memberIDs))
+		for _, id := range memberIDs {
+			if id != userID {
+				remaining = append(remaining, id)
+			}
+		}
+
+		if len(remaining) == len(memberIDs) {
+			return models.ErrNoRecord
+		}
+
+		if err := check(remaining); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomMemberDelete, chatRoomID, userID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveGroupMember:: error deleting member - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomDecrementUsersCount, now, chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveGroupMember:: error updating users count - %v", err)
+		}
+
+		_, err := tx.ExecContext(ctx, queryChatRoomUpdateMemberKey, models.GroupMemberKey(remaining), now, chatRoomID)
+		if err == nil {
+			return nil
+		}
+
+		if !isDuplicateEntryError(err) {
+			return fmt.Errorf("chatRoomRepo.RemoveGroupMember:: error updating member key - %v", err)
+		}
+
+		// Only the failed statement is rolled back so the transaction can carry on without a key
+		if _, err := tx.ExecContext(ctx, queryChatRoomUpdateMemberKey, "", now, chatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RemoveGroupMember:: error removing member key - %v", err)
+		}
+
+		return nil
+	})
+}
+
+// ConvertToRoom turns the group models.ChatRoom into a named room owned by the models.User who converted it
+func (r *chatRoomRepo) ConvertToRoom(ctx context.Context, id, userID uint64, name string) error {
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, queryChatRoomConvertToRoom, name, models.ChatRoomKindRoom, userID,
+			time.Now(), id, models.ChatRoomKindGroup)
+
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.ConvertToRoom:: error updating record - %v", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("chatRoomRepo.ConvertToRoom:: error getting affected rows - %v", err)
+		}
+
+		if affected == 0 {
+			return models.ErrNoRecord
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomMemberUpdateRole, models.ChatRoomRoleOwner, id, userID); err != nil {
+			return fmt.Errorf("chatRoomRepo.ConvertToRoom:: error updating member role - %v", err)
+		}
+
+		return nil
+	})
+}
+
+// IsMember checks if the models.User has joined the models.ChatRoom
+func (r *chatRoomRepo) IsMember(ctx context.Context, chatRoomID, userID uint64) (bool, error) {
+	var exists bool
+
+	if err := r.db.GetContext(ctx, &exists, queryChatRoomMemberExists, chatRoomID, userID); err != nil {
+		return false, fmt.Errorf("chatRoomRepo.IsMember:: error executing query - %v", err)
+	}
+
+	return exists, nil
+}
+
+// CreateInvite adds a new models.ChatRoomInvite
+func (r *chatRoomRepo) CreateInvite(ctx context.Context, invite *models.ChatRoomInvite) (*models.ChatRoomInvite, error) {
+	result, err := r.db.ExecContext(ctx, queryChatRoomInviteCreate, invite.ChatRoomID, invite.UserID, invite.TokenHash,
+		invite.MaxUses, invite.Uses, invite.ExpiresAt, invite.CreatedAt)
+
+	if err != nil {
+		if isDuplicateEntryError(err) {
+			return nil, models.ErrDuplicateRecord
+		}
+
+		return nil, fmt.Errorf("chatRoomRepo.CreateInvite:: error inserting record - %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("chatRoomRepo.CreateInvite:: error getting id - %v", err)
+	}
+
+	invite.ID = uint64(id)
+	return invite, nil
+}
+
+// RedeemInvite adds the models.User to the members of the models.ChatRoom the invite is for and uses it up.
+// The invite row is locked for the duration of the transaction so concurrent redemptions cannot exceed max uses.
+func (r *chatRoomRepo) RedeemInvite(ctx context.Context, tokenHash string, userID uint64) (*models.ChatRoomInvite, error) {
+	invite := &models.ChatRoomInvite{}
+	now := time.Now()
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, invite, queryChatRoomInviteFindByTokenHashForUpdate, tokenHash); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrNoRecord
+			}
+
+			return fmt.Errorf("chatRoomRepo.RedeemInvite:: error finding invite - %v", err)
+		}
+
+		if err := invite.CanBeRedeemed(now); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, queryChatRoomMemberCreate, invite.ChatRoomID, userID, models.ChatRoomRoleMember,
+			now)
+
+		if err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			return fmt.Errorf("chatRoomRepo.RedeemInvite:: error inserting member - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomIncrementUsersCount, now, invite.ChatRoomID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RedeemInvite:: error updating users count - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryChatRoomInviteIncrementUses, invite.ID); err != nil {
+			return fmt.Errorf("chatRoomRepo.RedeemInvite:: error updating invite uses - %v", err)
+		}
+
+		invite.Uses++
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invite, nil
+}
+
 // NewChatRoomRepository creates a new chat room repository
 func NewChatRoomRepository(db *sqlx.DB) chatroom.Repository {
 	return &chatRoomRepo{
@@ -0,0 +1,752 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/repository/factory"
+	"chatapp/repository/mockdb"
+	"chatapp/services/chatroom"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestChatRoomRepo_Create(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+	fakeRoom := factory.NewChatRoom(1)
+
+	testCases := []struct {
+		name     string
+		repo     chatroom.Repository
+		mock     func()
+		wants    *models.ChatRoom
+		wantsErr bool
+	}{
+		{
+			name: "creates a new chat room with the creator as a member",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomCreate)).
+					WithArgs(fakeRoom.UUID, fakeRoom.Name, uint(1), fakeRoom.IsPrivate, fakeRoom.UserID,
+						fakeRoom.CreatedAt, fakeRoom.UpdatedAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+					WithArgs(uint64(1), fakeRoom.UserID, models.ChatRoomRoleOwner, fakeRoom.CreatedAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wants: &models.ChatRoom{
+				ID:         1,
+				UUID:       fakeRoom.UUID,
+				Name:       fakeRoom.Name,
+				UsersCount: 1,
+				UserID:     fakeRoom.UserID,
+				CreatedAt:  fakeRoom.CreatedAt,
+				UpdatedAt:  fakeRoom.UpdatedAt,
+			},
+			wantsErr: false,
+		},
+		{
+			name: "rolls back if the creator cannot be added as a member",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomCreate)).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+					WillReturnError(errInvalidSQLQuery)
+				mock.ExpectRollback()
+			},
+			wants:    nil,
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			room := *fakeRoom
+
+			got, err := tc.repo.Create(context.Background(), &room)
+			if (err != nil) != tc.wantsErr {
+				t.Errorf("Create() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tc.wants) {
+				t.Errorf("Create() = %v, wants %v", got, tc.wants)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Create() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_CreateWithMembers(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	fakeRoom := factory.NewChatRoom(1)
+	fakeRoom.Name = ""
+	fakeRoom.Kind = models.ChatRoomKindDirect
+	fakeRoom.MemberKey = models.DirectMemberKey(1, 2)
+	fakeRoom.IsPrivate = true
+
+	testCases := []struct {
+		name     string
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "creates the chat room with every user as a member",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomCreateWithMembers)).
+					WithArgs(fakeRoom.UUID, "", models.ChatRoomKindDirect, "dm:1:2", uint(2), true, uint64(1),
+						fakeRoom.CreatedAt, fakeRoom.UpdatedAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+
+				for _, userID := range []uint64{1, 2} {
+					mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+						WithArgs(uint64(1), userID, models.ChatRoomRoleMember, fakeRoom.CreatedAt).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fails if a chat room with the member key exists",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomCreateWithMembers)).
+					WillReturnError(errMySQLDuplicateEntry)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrDuplicateRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			room := *fakeRoom
+
+			got, err := repo.CreateWithMembers(context.Background(), &room, []uint64{1, 2})
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("CreateWithMembers() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && (got.ID != 1 || got.UsersCount != 2) {
+				t.Errorf("CreateWithMembers() = %v, wants id 1 with 2 users", got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("CreateWithMembers() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_GetUserDirectChatRooms(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "kind", "users_count", "participant.id", "participant.username",
+		"participant.display_name"}).
+		AddRow(1, models.ChatRoomKindDirect, 2, 2, "jwambugu", "Jay")
+
+	mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomFindDirectByUserID)).
+		WithArgs(uint64(1), models.ChatRoomKindDirect).
+		WillReturnRows(rows)
+
+	got, err := repo.GetUserDirectChatRooms(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetUserDirectChatRooms() unexpected error - %v", err)
+	}
+
+	wants := []models.DirectChatRoom{
+		{
+			ChatRoom: models.ChatRoom{ID: 1, Kind: models.ChatRoomKindDirect, UsersCount: 2},
+			Participant: models.User{
+				ID:          2,
+				Username:    "jwambugu",
+				UserProfile: models.UserProfile{DisplayName: "Jay"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, wants) {
+		t.Errorf("GetUserDirectChatRooms() = %v, wants %v", got, wants)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetUserDirectChatRooms() unmet expectations - %v", err)
+	}
+}
+
+func TestChatRoomRepo_GetUserDirectChatRooms_DeletedParticipant(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	// The only participant has been deleted so the join on the active users finds nothing
+	mock.ExpectQuery(`INNER JOIN users ON users\.id = participants\.user_id\s+AND users\.deleted_at IS NULL`).
+		WithArgs(uint64(1), models.ChatRoomKindDirect).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "users_count", "participant.id"}))
+
+	got, err := repo.GetUserDirectChatRooms(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetUserDirectChatRooms() unexpected error - %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("GetUserDirectChatRooms() = %v, wants no chat rooms", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetUserDirectChatRooms() unmet expectations - %v", err)
+	}
+}
+
+func TestChatRoomRepo_AddMember(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	testCases := []struct {
+		name     string
+		repo     chatroom.Repository
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "adds the member and increments the users count",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+					WithArgs(uint64(1), uint64(2), models.ChatRoomRoleMember, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomIncrementUsersCount)).
+					WithArgs(sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantsErr: nil,
+		},
+		{
+			name: "returns a duplicate error if the user is already a member",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+					WithArgs(uint64(1), uint64(2), models.ChatRoomRoleMember, sqlmock.AnyArg()).
+					WillReturnError(errMySQLDuplicateEntry)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrDuplicateRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := tc.repo.AddMember(context.Background(), 1, 2, models.ChatRoomRoleMember)
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("AddMember() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("AddMember() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_AddGroupMembers(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+	memberKey := models.GroupMemberKey([]uint64{1, 2, 3, 4})
+	errTooLarge := errors.New("too large")
+
+	expectLockedMembers := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomMemberIDsForUpdate)).
+			WithArgs(uint64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1).AddRow(2).AddRow(3))
+	}
+
+	testCases := []struct {
+		name     string
+		add      error
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "adds the members and updates the member key from the locked members",
+			mock: func() {
+				expectLockedMembers()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomUpdateMemberKey)).
+					WithArgs(memberKey, sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+					WithArgs(uint64(1), uint64(4), models.ChatRoomRoleMember, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomAddUsersCount)).
+					WithArgs(1, sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fails if another chat room has the member key",
+			mock: func() {
+				expectLockedMembers()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomUpdateMemberKey)).
+					WillReturnError(errMySQLDuplicateEntry)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrDuplicateRecord,
+		},
+		{
+			name: "adds nobody if the rules reject the members",
+			add:  errTooLarge,
+			mock: func() {
+				expectLockedMembers()
+				mock.ExpectRollback()
+			},
+			wantsErr: errTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := repo.AddGroupMembers(context.Background(), 1, func(current []uint64) ([]uint64, error) {
+				if !reflect.DeepEqual(current, []uint64{1, 2, 3}) {
+					t.Errorf("AddGroupMembers() current = %v, wants %v", current, []uint64{1, 2, 3})
+				}
+
+				return []uint64{4}, tc.add
+			})
+
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("AddGroupMembers() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("AddGroupMembers() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_ConvertToRoom(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	testCases := []struct {
+		name     string
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "converts the group and makes the user the owner",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomConvertToRoom)).
+					WithArgs("General", models.ChatRoomKindRoom, uint64(2), sqlmock.AnyArg(), uint64(1),
+						models.ChatRoomKindGroup).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberUpdateRole)).
+					WithArgs(models.ChatRoomRoleOwner, uint64(1), uint64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fails if the chat room is not a group",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomConvertToRoom)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := repo.ConvertToRoom(context.Background(), 1, 2, "General")
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("ConvertToRoom() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("ConvertToRoom() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_RemoveMember(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	testCases := []struct {
+		name     string
+		repo     chatroom.Repository
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "removes the member and decrements the users count",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberDelete)).
+					WithArgs(uint64(1), uint64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomDecrementUsersCount)).
+					WithArgs(sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantsErr: nil,
+		},
+		{
+			name: "returns no record if the user is not a member",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberDelete)).
+					WithArgs(uint64(1), uint64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := tc.repo.RemoveMember(context.Background(), 1, 2)
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("RemoveMember() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RemoveMember() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_RemoveGroupMember(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+	remainingKey := models.GroupMemberKey([]uint64{1, 2, 3})
+	errTooSmall := errors.New("too small")
+
+	expectLockedMembers := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomMemberIDsForUpdate)).
+			WithArgs(uint64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1).AddRow(2).AddRow(3).AddRow(4))
+	}
+
+	expectRemoved := func() {
+		mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberDelete)).
+			WithArgs(uint64(1), uint64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(queryChatRoomDecrementUsersCount)).
+			WithArgs(sqlmock.AnyArg(), uint64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	testCases := []struct {
+		name     string
+		userID   uint64
+		check    error
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name:   "removes the member and updates the member key in one transaction",
+			userID: 4,
+			mock: func() {
+				expectLockedMembers()
+				expectRemoved()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomUpdateMemberKey)).
+					WithArgs(remainingKey, sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "removes the member key if another group has the remaining members",
+			userID: 4,
+			mock: func() {
+				expectLockedMembers()
+				expectRemoved()
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomUpdateMemberKey)).
+					WithArgs(remainingKey, sqlmock.AnyArg(), uint64(1)).
+					WillReturnError(errMySQLDuplicateEntry)
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomUpdateMemberKey)).
+					WithArgs("", sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:   "keeps the member if the check fails",
+			userID: 4,
+			check:  errTooSmall,
+			mock: func() {
+				expectLockedMembers()
+				mock.ExpectRollback()
+			},
+			wantsErr: errTooSmall,
+		},
+		{
+			name:   "fails if the user is not a member",
+			userID: 5,
+			mock: func() {
+				expectLockedMembers()
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := repo.RemoveGroupMember(context.Background(), 1, tc.userID, func(remaining []uint64) error {
+				return tc.check
+			})
+
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("RemoveGroupMember() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RemoveGroupMember() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestChatRoomRepo_IsMember(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	for _, wants := range []bool{true, false} {
+		mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomMemberExists)).
+			WithArgs(uint64(1), uint64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(wants))
+
+		got, err := repo.IsMember(context.Background(), 1, 2)
+		if err != nil {
+			t.Fatalf("IsMember() unexpected error - %v", err)
+		}
+
+		if got != wants {
+			t.Errorf("IsMember() = %v, wants %v", got, wants)
+		}
+	}
+}
+
+func TestChatRoomRepo_Exists(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM chat_rooms WHERE name = ? AND user_id = ?)`)).
+		WithArgs("general", uint64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	got, err := repo.Exists(context.Background(), models.Where(models.ChatRoomColumnName, "general"),
+		models.Where(models.ChatRoomColumnUserID, uint64(1)))
+	if err != nil {
+		t.Fatalf("Exists() unexpected error - %v", err)
+	}
+
+	if !got {
+		t.Errorf("Exists() = %v, wants %v", got, true)
+	}
+
+	if _, err := repo.Exists(context.Background(), models.Where("password", "secret")); !errors.Is(err,
+		models.ErrInvalidFilter) {
+		t.Errorf("Exists() error = %v, wantsErr = %v", err, models.ErrInvalidFilter)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Exists() unmet expectations - %v", err)
+	}
+}
+
+func TestChatRoomRepo_RedeemInvite(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewChatRoomRepository(db)
+	inviteColumns := []string{"id", "chat_room_id", "user_id", "token_hash", "max_uses", "uses", "expires_at",
+		"created_at"}
+
+	now := time.Now()
+
+	newRows := func(maxUses, uses uint, expiresAt time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows(inviteColumns).AddRow(1, 1, 1, "hash", maxUses, uses, expiresAt, now)
+	}
+
+	testCases := []struct {
+		name      string
+		repo      chatroom.Repository
+		mock      func()
+		wantsUses uint
+		wantsErr  error
+	}{
+		{
+			name: "adds the member and uses up the invite",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomInviteFindByTokenHashForUpdate)).
+					WithArgs("hash").
+					WillReturnRows(newRows(1, 0, now.Add(time.Hour)))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomMemberCreate)).
+					WithArgs(uint64(1), uint64(2), models.ChatRoomRoleMember, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomIncrementUsersCount)).
+					WithArgs(sqlmock.AnyArg(), uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryChatRoomInviteIncrementUses)).
+					WithArgs(uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantsUses: 1,
+		},
+		{
+			name: "fails if the invite has expired",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomInviteFindByTokenHashForUpdate)).
+					WithArgs("hash").
+					WillReturnRows(newRows(0, 3, now.Add(-time.Hour)))
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrInviteExpired,
+		},
+		{
+			name: "fails if the invite has no uses left",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomInviteFindByTokenHashForUpdate)).
+					WithArgs("hash").
+					WillReturnRows(newRows(1, 1, now.Add(time.Hour)))
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrInviteExhausted,
+		},
+		{
+			name: "fails if the invite does not exist",
+			repo: repo,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryChatRoomInviteFindByTokenHashForUpdate)).
+					WithArgs("hash").
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := tc.repo.RedeemInvite(context.Background(), "hash", 2)
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("RedeemInvite() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && got.Uses != tc.wantsUses {
+				t.Errorf("RedeemInvite() uses = %v, wants %v", got.Uses, tc.wantsUses)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RedeemInvite() unmet expectations - %v", err)
+			}
+		})
+	}
+}
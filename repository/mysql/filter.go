@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+// queryExists checks if the table has a record matching the conditions. Only the table names and the conditions
+// built by filterColumns.where are formatted into it, the values are always passed as arguments.
+const queryExists = `SELECT EXISTS(SELECT 1 FROM %s WHERE %s)`
+
+// filterColumns maps the models.Filter columns a table can be filtered by to its SQL columns
+type filterColumns map[string]string
+
+var (
+	userFilterColumns = filterColumns{
+		models.UserColumnID:       "id",
+		models.UserColumnUsername: "username",
+	}
+
+	chatRoomFilterColumns = filterColumns{
+		models.ChatRoomColumnID:     "id",
+		models.ChatRoomColumnUUID:   "uuid",
+		models.ChatRoomColumnName:   "name",
+		models.ChatRoomColumnUserID: "user_id",
+	}
+)
+
+// where builds the parameterized conditions matching every filter. It fails if no filters are provided or a filter
+// uses a column that is not allowed.
+func (c filterColumns) where(filters []models.Filter) (string, []interface{}, error) {
+	if len(filters) == 0 {
+		return "", nil, fmt.Errorf("%w: no filters provided", models.ErrInvalidFilter)
+	}
+
+	conditions := make([]string, len(filters))
+	args := make([]interface{}, len(filters))
+
+	for i, filter := range filters {
+		column, ok := c[filter.Column]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: cannot filter by %q", models.ErrInvalidFilter, filter.Column)
+		}
+
+		conditions[i] = column + " = ?"
+		args[i] = filter.Value
+	}
+
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// exists checks if the table has a record matching every filter
+func exists(ctx context.Context, db *sqlx.DB, table string, columns filterColumns, filters []models.Filter) (
+	bool, error) {
+	conditions, args, err := columns.where(filters)
+	if err != nil {
+		return false, err
+	}
+
+	var found bool
+
+	if err := db.GetContext(ctx, &found, fmt.Sprintf(queryExists, table, conditions), args...); err != nil {
+		return false, fmt.Errorf("mysql.exists:: error executing query - %v", err)
+	}
+
+	return found, nil
+}
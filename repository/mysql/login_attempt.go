@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/loginattempt"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// loginAttemptRepo implements loginattempt.Repository
+type loginAttemptRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryLoginAttemptFind = `SELECT attempt_key, failures, last_failed_at, locked_until
+	FROM login_attempts
+	WHERE attempt_key = ?`
+
+	queryLoginAttemptDeleteStale = `DELETE FROM login_attempts
+	WHERE last_failed_at < ?
+		AND (locked_until IS NULL OR locked_until <= ?)`
+
+	// The assignments are evaluated from left to right so last_failed_at has to be updated last
+	queryLoginAttemptRecordFailure = `INSERT INTO login_attempts (attempt_key, failures, last_failed_at)
+	VALUES (?, 1, ?)
+	ON DUPLICATE KEY UPDATE failures     = IF(last_failed_at < ?, 1, failures + 1),
+							locked_until = IF(last_failed_at < ?, NULL, locked_until),
+							last_failed_at = VALUES(last_failed_at)`
+
+	queryLoginAttemptLock = `UPDATE login_attempts SET locked_until = ? WHERE attempt_key = ?`
+
+	queryLoginAttemptDelete = `DELETE FROM login_attempts WHERE attempt_key = ?`
+)
+
+// Find fetches the models.LoginAttempt for the key
+func (r *loginAttemptRepo) Find(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	attempt := &models.LoginAttempt{}
+
+	if err := r.db.GetContext(ctx, attempt, queryLoginAttemptFind, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("loginAttemptRepo.Find:: error finding login attempt - %v", err)
+	}
+
+	return attempt, nil
+}
+
+// RecordFailure counts a failed login for the key, starting over if the last failure was before resetBefore
+func (r *loginAttemptRepo) RecordFailure(ctx context.Context, key string, failedAt, resetBefore time.Time) (
+	*models.LoginAttempt, error) {
+	// There is no scheduled job for login attempts so the rows whose failures have been forgotten are deleted here,
+	// the last_failed_at index keeps the delete to those rows
+	if _, err := r.db.ExecContext(ctx, queryLoginAttemptDeleteStale, resetBefore, failedAt); err != nil {
+		return nil, fmt.Errorf("loginAttemptRepo.RecordFailure:: error deleting stale records - %v", err)
+	}
+
+	attempt := &models.LoginAttempt{}
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, queryLoginAttemptRecordFailure, key, failedAt, resetBefore, resetBefore)
+		if err != nil {
+			return fmt.Errorf("loginAttemptRepo.RecordFailure:: error upserting record - %v", err)
+		}
+
+		if err := tx.GetContext(ctx, attempt, queryLoginAttemptFind, key); err != nil {
+			return fmt.Errorf("loginAttemptRepo.RecordFailure:: error finding login attempt - %v", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+// Lock stops logins for the key until the time provided
+func (r *loginAttemptRepo) Lock(ctx context.Context, key string, lockedUntil time.Time) error {
+	if _, err := r.db.ExecContext(ctx, queryLoginAttemptLock, lockedUntil, key); err != nil {
+		return fmt.Errorf("loginAttemptRepo.Lock:: error updating record - %v", err)
+	}
+
+	return nil
+}
+
+// Delete forgets the failed logins for the key
+func (r *loginAttemptRepo) Delete(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, queryLoginAttemptDelete, key); err != nil {
+		return fmt.Errorf("loginAttemptRepo.Delete:: error deleting record - %v", err)
+	}
+
+	return nil
+}
+
+// NewLoginAttemptRepository creates a new login attempt repository
+func NewLoginAttemptRepository(db *sqlx.DB) loginattempt.Repository {
+	return &loginAttemptRepo{
+		db: db,
+	}
+}
@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"chatapp/repository/mockdb"
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptRepo_RecordFailure(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewLoginAttemptRepository(db)
+
+	failedAt := time.Now()
+	resetBefore := failedAt.Add(-time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(queryLoginAttemptDeleteStale)).
+		WithArgs(resetBefore, failedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(queryLoginAttemptRecordFailure)).
+		WithArgs("username:jay", failedAt, resetBefore, resetBefore).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(queryLoginAttemptFind)).
+		WithArgs("username:jay").
+		WillReturnRows(sqlmock.NewRows([]string{"attempt_key", "failures", "last_failed_at", "locked_until"}).
+			AddRow("username:jay", 3, failedAt, nil))
+	mock.ExpectCommit()
+
+	got, err := repo.RecordFailure(context.Background(), "username:jay", failedAt, resetBefore)
+	if err != nil {
+		t.Fatalf("RecordFailure() unexpected error - %v", err)
+	}
+
+	if got.Failures != 3 {
+		t.Errorf("RecordFailure() failures = %d, wants %d", got.Failures, 3)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("RecordFailure() unmet expectations - %v", err)
+	}
+}
@@ -0,0 +1,464 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"chatapp/services/message"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// messageRepo implements message.Repository
+type messageRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryMessageCreate = `INSERT INTO messages (uuid, chat_room_id, user_id, body, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+
+	queryMessageFindByID = `SELECT id, uuid, chat_room_id, user_id, body, edited_at, created_at, updated_at, deleted_at,
+		deleted_at IS NOT NULL AS deleted
+	FROM messages WHERE id = ?`
+
+	queryMessageFindLatest = `SELECT id, uuid, chat_room_id, user_id, IF(deleted_at IS NULL, body, '') AS body, edited_at,
+		created_at, updated_at, deleted_at, deleted_at IS NOT NULL AS deleted
+	FROM messages WHERE chat_room_id = ?
+	ORDER BY id DESC
+	LIMIT ?`
+
+	queryMessageFindBefore = `SELECT id, uuid, chat_room_id, user_id, IF(deleted_at IS NULL, body, '') AS body, edited_at,
+		created_at, updated_at, deleted_at, deleted_at IS NOT NULL AS deleted
+	FROM messages WHERE chat_room_id = ?
+		AND id < ?
+	ORDER BY id DESC
+	LIMIT ?`
+
+	queryMessageFindAfter = `SELECT id, uuid, chat_room_id, user_id, IF(deleted_at IS NULL, body, '') AS body, edited_at,
+		created_at, updated_at, deleted_at, deleted_at IS NOT NULL AS deleted
+	FROM messages WHERE chat_room_id = ?
+		AND id > ?
+	ORDER BY id ASC
+	LIMIT ?`
+
+	queryMessageFindForUpdate = `SELECT id, uuid, chat_room_id, user_id, body, edited_at, created_at, updated_at, deleted_at,
+		deleted_at IS NOT NULL AS deleted
+	FROM messages WHERE id = ? AND deleted_at IS NULL
+	FOR UPDATE`
+
+	queryMessageUpdateBody = `UPDATE messages SET body = ?, edited_at = ?, updated_at = ? WHERE id = ?`
+
+	queryMessageRevisionCreate = `INSERT INTO message_revisions (message_id, body, created_at) VALUES (?, ?, ?)`
+
+	queryMessageSoftDelete = `UPDATE messages SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`
+
+	queryMessageFindDeletedIDs = `SELECT id FROM messages WHERE deleted_at < ?
+	ORDER BY id
+	LIMIT ?
+	FOR UPDATE`
+
+	queryMessageRevisionsPurge = `DELETE FROM message_revisions WHERE message_id IN (?)`
+
+	queryMessageReactionsPurge = `DELETE FROM message_reactions WHERE message_id IN (?)`
+
+	queryMessagePurge = `DELETE FROM messages WHERE id IN (?)`
+
+	queryMessageRevisionsFindByMessageID = `SELECT id, message_id, body, created_at
+	FROM message_revisions WHERE message_id = ?
+	ORDER BY id`
+
+	queryMessageFindIDForUpdate = `SELECT id FROM messages WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+
+	queryMessageReactionCreate = `INSERT INTO message_reactions (message_id, user_id, emoji, created_at)
+	VALUES (?, ?, ?, ?)`
+
+	queryMessageReactionDelete = `DELETE FROM message_reactions WHERE message_id = ? AND user_id = ? AND emoji = ?`
+
+	queryMessageReactionCount = `SELECT message_id, emoji, COUNT(*) AS count, COALESCE(MAX(user_id = ?), 0) AS reacted_by_me
+	FROM message_reactions WHERE message_id = ? AND emoji = ?
+	GROUP BY message_id, emoji`
+
+	// queryMessageReactionCounts orders each message's emoji by the first time anyone reacted with it
+	queryMessageReactionCounts = `SELECT message_id, emoji, COUNT(*) AS count, MAX(user_id = ?) AS reacted_by_me
+	FROM message_reactions WHERE message_id IN (?)
+	GROUP BY message_id, emoji
+	ORDER BY message_id, MIN(id)`
+)
+
+// Create adds a new models.Message
+func (r *messageRepo) Create(ctx context.Context, message *models.Message) (*models.Message, error) {
+	stmt, err := r.db.PrepareContext(ctx, queryMessageCreate)
+	if err != nil {
+		return nil, fmt.Errorf("messageRepo.Create:: error creating prepared stmt - %v", err)
+	}
+
+	defer func(stmt *sql.Stmt) {
+		_ = stmt.Close()
+	}(stmt)
+
+	result, err := stmt.ExecContext(ctx, message.UUID, message.ChatRoomID, message.UserID, message.Body,
+		message.CreatedAt, message.UpdatedAt)
+
+	if err != nil {
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+			if mysqlErr.Number == models.MySQLDuplicateEntryNumber {
+				return nil, models.ErrDuplicateRecord
+			}
+		}
+
+		return nil, fmt.Errorf("messageRepo.Create:: error inserting record - %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("messageRepo.Create:: error getting id - %v", err)
+	}
+
+	message.ID = uint64(id)
+	return message, nil
+}
+
+// FindByID fetches a models.Message using the id provided
+func (r *messageRepo) FindByID(ctx context.Context, id uint64) (*models.Message, error) {
+	foundMessage := &models.Message{}
+
+	if err := r.db.GetContext(ctx, foundMessage, queryMessageFindByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("messageRepo.FindByID:: error finding message - %v", err)
+	}
+
+	return foundMessage, nil
+}
+
+// GetChatRoomMessages returns []models.Message sent to the models.ChatRoom ordered from the newest to the oldest.
+// It fetches one message more than params.Limit so that the caller can tell if there are more to page through.
+func (r *messageRepo) GetChatRoomMessages(ctx context.Context, chatRoomID uint64, params pagination.Params) (
+	[]models.Message, error) {
+	var (
+		messages []models.Message
+		err      error
+	)
+
+	limit := params.Limit + 1
+
+	switch {
+	case params.Before != 0:
+		err = r.db.SelectContext(ctx, &messages, queryMessageFindBefore, chatRoomID, params.Before, limit)
+	case params.After != 0:
+		err = r.db.SelectContext(ctx, &messages, queryMessageFindAfter, chatRoomID, params.After, limit)
+	default:
+		err = r.db.SelectContext(ctx, &messages, queryMessageFindLatest, chatRoomID, limit)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("messageRepo.GetChatRoomMessages:: error getting chat room messages - %v", err)
+	}
+
+	if len(messages) == 0 {
+		return []models.Message{}, nil
+	}
+
+	if params.After != 0 {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	return messages, nil
+}
+
+// UpdateBody locks the models.Message, runs check against it and, if it passes and the body has changed, keeps the
+// previous body as a models.MessageRevision before replacing it. The message is returned as it is once the
+// transaction ends.
+func (r *messageRepo) UpdateBody(ctx context.Context, id uint64, body string, editedAt time.Time,
+	check func(message *models.Message) error) (*models.Message, error) {
+	message := &models.Message{}
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, message, queryMessageFindForUpdate, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrNoRecord
+			}
+
+			return fmt.Errorf("messageRepo.UpdateBody:: error finding message - %v", err)
+		}
+
+		if err := check(message); err != nil {
+			return err
+		}
+
+		if message.Body == body {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, queryMessageRevisionCreate, id, message.Body, editedAt); err != nil {
+			return fmt.Errorf("messageRepo.UpdateBody:: error inserting revision - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryMessageUpdateBody, body, editedAt, editedAt, id); err != nil {
+			return fmt.Errorf("messageRepo.UpdateBody:: error updating record - %v", err)
+		}
+
+		message.Body = body
+		message.EditedAt = &editedAt
+		message.UpdatedAt = editedAt
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// GetRevisions returns the []models.MessageRevision of the models.Message from the oldest to the newest
+func (r *messageRepo) GetRevisions(ctx context.Context, messageID uint64) ([]models.MessageRevision, error) {
+	var revisions []models.MessageRevision
+
+	if err := r.db.SelectContext(ctx, &revisions, queryMessageRevisionsFindByMessageID, messageID); err != nil {
+		return nil, fmt.Errorf("messageRepo.GetRevisions:: error getting message revisions - %v", err)
+	}
+
+	if len(revisions) == 0 {
+		return []models.MessageRevision{}, nil
+	}
+
+	return revisions, nil
+}
+
+// SoftDelete marks the given models.Message as deleted, it is kept in the chat room history as a tombstone
+func (r *messageRepo) SoftDelete(ctx context.Context, id uint64, deletedAt time.Time) error {
+	stmt, err := r.db.PrepareContext(ctx, queryMessageSoftDelete)
+	if err != nil {
+		return fmt.Errorf("messageRepo.SoftDelete:: error creating prepared stmt - %v", err)
+	}
+
+	defer func(stmt *sql.Stmt) {
+		_ = stmt.Close()
+	}(stmt)
+
+	result, err := stmt.ExecContext(ctx, deletedAt, deletedAt, id)
+	if err != nil {
+		return fmt.Errorf("messageRepo.SoftDelete:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("messageRepo.SoftDelete:: error getting affected rows - %v", err)
+	}
+
+	if affected == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes up to limit of the messages deleted before the time provided along with their
+// revisions and reactions and returns how many messages were removed. Callers purge in batches until fewer than
+// limit are removed so a single transaction never holds locks on every deleted message.
+func (r *messageRepo) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var ids []uint64
+		if err := tx.SelectContext(ctx, &ids, queryMessageFindDeletedIDs, before, limit); err != nil {
+			return fmt.Errorf("messageRepo.PurgeDeleted:: error getting deleted messages - %v", err)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, purge := range []struct {
+			query string
+			name  string
+		}{
+			{queryMessageRevisionsPurge, "revisions"},
+			{queryMessageReactionsPurge, "reactions"},
+		} {
+			query, args, err := sqlx.In(purge.query, ids)
+			if err != nil {
+				return fmt.Errorf("messageRepo.PurgeDeleted:: error building %s query - %v", purge.name, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+				return fmt.Errorf("messageRepo.PurgeDeleted:: error deleting %s - %v", purge.name, err)
+			}
+		}
+
+		query, args, err := sqlx.In(queryMessagePurge, ids)
+		if err != nil {
+			return fmt.Errorf("messageRepo.PurgeDeleted:: error building query - %v", err)
+		}
+
+		result, err := tx.ExecContext(ctx, tx.Rebind(query), args...)
+		if err != nil {
+			return fmt.Errorf("messageRepo.PurgeDeleted:: error deleting records - %v", err)
+		}
+
+		purged, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("messageRepo.PurgeDeleted:: error getting affected rows - %v", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// AddReaction adds a models.MessageReaction and returns the models.ReactionCount of its emoji read in the same
+// transaction, a user can only react to a message with each emoji once. The message is locked first so it cannot
+// be deleted while the reaction is added, models.ErrNoRecord is returned if it already has been.
+func (r *messageRepo) AddReaction(ctx context.Context, reaction *models.MessageReaction) (*models.ReactionCount,
+	error) {
+	var count *models.ReactionCount
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var messageID uint64
+
+		if err := tx.GetContext(ctx, &messageID, queryMessageFindIDForUpdate, reaction.MessageID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrNoRecord
+			}
+
+			return fmt.Errorf("messageRepo.AddReaction:: error finding message - %v", err)
+		}
+
+		result, err := tx.ExecContext(ctx, queryMessageReactionCreate, reaction.MessageID, reaction.UserID,
+			reaction.Emoji, reaction.CreatedAt)
+		if err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			if isForeignKeyViolationError(err) {
+				return models.ErrNoRecord
+			}
+
+			return fmt.Errorf("messageRepo.AddReaction:: error inserting record - %v", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("messageRepo.AddReaction:: error getting id - %v", err)
+		}
+
+		reaction.ID = uint64(id)
+
+		count, err = reactionCount(ctx, tx, reaction.MessageID, reaction.UserID, reaction.Emoji)
+		if err != nil {
+			return fmt.Errorf("messageRepo.AddReaction:: %v", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// RemoveReaction removes the user's reaction to the message with the emoji and returns the models.ReactionCount of
+// the emoji read in the same transaction
+func (r *messageRepo) RemoveReaction(ctx context.Context, messageID, userID uint64, emoji string) (
+	*models.ReactionCount, error) {
+	var count *models.ReactionCount
+
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, queryMessageReactionDelete, messageID, userID, emoji)
+		if err != nil {
+			return fmt.Errorf("messageRepo.RemoveReaction:: error deleting record - %v", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("messageRepo.RemoveReaction:: error getting affected rows - %v", err)
+		}
+
+		if affected == 0 {
+			return models.ErrNoRecord
+		}
+
+		count, err = reactionCount(ctx, tx, messageID, userID, emoji)
+		if err != nil {
+			return fmt.Errorf("messageRepo.RemoveReaction:: %v", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// reactionCount returns the models.ReactionCount of the emoji on the message as seen by the transaction, it is zero
+// if nobody reacted with it
+func reactionCount(ctx context.Context, tx *sqlx.Tx, messageID, userID uint64, emoji string) (*models.ReactionCount,
+	error) {
+	count := &models.ReactionCount{}
+
+	err := tx.GetContext(ctx, count, queryMessageReactionCount, userID, messageID, emoji)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &models.ReactionCount{MessageID: messageID, Emoji: emoji}, nil
+		}
+
+		return nil, fmt.Errorf("error getting reaction count - %v", err)
+	}
+
+	return count, nil
+}
+
+// GetReactionCounts returns the []models.ReactionCount of every message provided, flagging the emoji the user with
+// the userID reacted with
+func (r *messageRepo) GetReactionCounts(ctx context.Context, messageIDs []uint64, userID uint64) (
+	[]models.ReactionCount, error) {
+	if len(messageIDs) == 0 {
+		return []models.ReactionCount{}, nil
+	}
+
+	query, args, err := sqlx.In(queryMessageReactionCounts, userID, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("messageRepo.GetReactionCounts:: error building query - %v", err)
+	}
+
+	var counts []models.ReactionCount
+
+	if err := r.db.SelectContext(ctx, &counts, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("messageRepo.GetReactionCounts:: error getting reaction counts - %v", err)
+	}
+
+	if len(counts) == 0 {
+		return []models.ReactionCount{}, nil
+	}
+
+	return counts, nil
+}
+
+// NewMessageRepository creates a new message repository
+func NewMessageRepository(db *sqlx.DB) message.Repository {
+	return &messageRepo{
+		db: db,
+	}
+}
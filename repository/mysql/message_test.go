@@ -0,0 +1,664 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"chatapp/repository/factory"
+	"chatapp/repository/mockdb"
+	"chatapp/services/message"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var messageColumns = []string{"id", "uuid", "chat_room_id", "user_id", "body", "created_at", "updated_at"}
+
+func TestMessageRepo_Create(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+	fakeMessage := factory.NewMessage(1, 1)
+
+	testCases := []struct {
+		name     string
+		repo     message.Repository
+		mock     func()
+		actual   *models.Message
+		wants    *models.Message
+		wantsErr bool
+	}{
+		{
+			name: "creates a new message",
+			repo: repo,
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageCreate)
+
+				mock.ExpectPrepare(query).
+					ExpectExec().
+					WithArgs(fakeMessage.UUID, fakeMessage.ChatRoomID, fakeMessage.UserID, fakeMessage.Body,
+						fakeMessage.CreatedAt, fakeMessage.UpdatedAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			actual: fakeMessage,
+			wants: &models.Message{
+				ID:         1,
+				UUID:       fakeMessage.UUID,
+				ChatRoomID: fakeMessage.ChatRoomID,
+				UserID:     fakeMessage.UserID,
+				Body:       fakeMessage.Body,
+				CreatedAt:  fakeMessage.CreatedAt,
+				UpdatedAt:  fakeMessage.UpdatedAt,
+			},
+			wantsErr: false,
+		},
+		{
+			name:   "fails to create message because of invalid SQL query",
+			repo:   repo,
+			actual: fakeMessage,
+			mock: func() {
+				mock.ExpectPrepare("INSERTS INTO messages").
+					ExpectExec().
+					WillReturnError(errInvalidSQLQuery)
+			},
+			wants:    nil,
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := tc.repo.Create(context.Background(), tc.actual)
+			if (err != nil) != tc.wantsErr {
+				t.Errorf("Create() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tc.wants) {
+				t.Errorf("Create() = %v, wants %v", got, tc.wants)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_FindByID(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+	fakeMessage := factory.NewMessage(1, 1)
+	fakeMessage.ID = 1
+
+	rows := sqlmock.NewRows(messageColumns).
+		AddRow(fakeMessage.ID, fakeMessage.UUID.String(), fakeMessage.ChatRoomID, fakeMessage.UserID,
+			fakeMessage.Body, fakeMessage.CreatedAt, fakeMessage.UpdatedAt)
+
+	testCases := []struct {
+		name     string
+		repo     message.Repository
+		mock     func()
+		id       uint64
+		wants    *models.Message
+		wantsErr bool
+	}{
+		{
+			name: "finds message by id",
+			repo: repo,
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageFindByID)
+				mock.ExpectQuery(query).WithArgs(uint64(1)).WillReturnRows(rows)
+			},
+			id:       uint64(1),
+			wants:    fakeMessage,
+			wantsErr: false,
+		},
+		{
+			name: "returns no records if message does not exist",
+			repo: repo,
+			id:   uint64(10),
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageFindByID)
+				mock.ExpectQuery(query).WithArgs(uint64(10)).WillReturnError(sql.ErrNoRows)
+			},
+			wants:    nil,
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := tc.repo.FindByID(context.Background(), tc.id)
+			if (err != nil) != tc.wantsErr {
+				t.Errorf("FindByID() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tc.wants) {
+				t.Errorf("FindByID() = %v, wants %v", got, tc.wants)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_GetChatRoomMessages(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+
+	fakeMessages := make([]models.Message, 3)
+	for i := range fakeMessages {
+		fakeMessages[i] = *factory.NewMessage(1, 1)
+		fakeMessages[i].ID = uint64(i + 1)
+	}
+
+	newRows := func(messages ...models.Message) *sqlmock.Rows {
+		rows := sqlmock.NewRows(messageColumns)
+
+		for _, m := range messages {
+			rows.AddRow(m.ID, m.UUID.String(), m.ChatRoomID, m.UserID, m.Body, m.CreatedAt, m.UpdatedAt)
+		}
+
+		return rows
+	}
+
+	testCases := []struct {
+		name     string
+		repo     message.Repository
+		mock     func()
+		params   pagination.Params
+		wants    []models.Message
+		wantsErr bool
+	}{
+		{
+			name: "returns the latest chat room messages",
+			repo: repo,
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageFindLatest)
+				mock.ExpectQuery(query).
+					WithArgs(uint64(1), 3).
+					WillReturnRows(newRows(fakeMessages[2], fakeMessages[1]))
+			},
+			params:   pagination.Params{Limit: 2},
+			wants:    []models.Message{fakeMessages[2], fakeMessages[1]},
+			wantsErr: false,
+		},
+		{
+			name: "returns the chat room messages before the cursor",
+			repo: repo,
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageFindBefore)
+				mock.ExpectQuery(query).
+					WithArgs(uint64(1), uint64(3), 3).
+					WillReturnRows(newRows(fakeMessages[1], fakeMessages[0]))
+			},
+			params:   pagination.Params{Before: 3, Limit: 2},
+			wants:    []models.Message{fakeMessages[1], fakeMessages[0]},
+			wantsErr: false,
+		},
+		{
+			name: "returns the chat room messages after the cursor from the newest",
+			repo: repo,
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageFindAfter)
+				mock.ExpectQuery(query).
+					WithArgs(uint64(1), uint64(1), 3).
+					WillReturnRows(newRows(fakeMessages[1], fakeMessages[2]))
+			},
+			params:   pagination.Params{After: 1, Limit: 2},
+			wants:    []models.Message{fakeMessages[2], fakeMessages[1]},
+			wantsErr: false,
+		},
+		{
+			name: "returns an empty slice if the chat room has no messages",
+			repo: repo,
+			mock: func() {
+				query := regexp.QuoteMeta(queryMessageFindLatest)
+				mock.ExpectQuery(query).WithArgs(uint64(1), 3).WillReturnRows(newRows())
+			},
+			params:   pagination.Params{Limit: 2},
+			wants:    []models.Message{},
+			wantsErr: false,
+		},
+		{
+			name: "fails to get messages because of invalid SQL query",
+			repo: repo,
+			mock: func() {
+				mock.ExpectQuery("SELECTS (.+) FROM messages").WillReturnError(errInvalidSQLQuery)
+			},
+			params:   pagination.Params{Limit: 2},
+			wants:    nil,
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := tc.repo.GetChatRoomMessages(context.Background(), 1, tc.params)
+			if (err != nil) != tc.wantsErr {
+				t.Errorf("GetChatRoomMessages() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && !reflect.DeepEqual(got, tc.wants) {
+				t.Errorf("GetChatRoomMessages() = %v, wants %v", got, tc.wants)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_UpdateBody(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+	editedAt := time.Now()
+	errNotAuthor := errors.New("not the author")
+
+	messageRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "body", "deleted"}).AddRow(1, 2, "original", false)
+	}
+
+	testCases := []struct {
+		name       string
+		body       string
+		check      error
+		mock       func()
+		wantsBody  string
+		wantsEdits bool
+		wantsErr   error
+	}{
+		{
+			name: "keeps the previous body as a revision and updates the message",
+			body: "edited",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnRows(messageRows())
+				mock.ExpectExec(regexp.QuoteMeta(queryMessageRevisionCreate)).
+					WithArgs(uint64(1), "original", editedAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryMessageUpdateBody)).
+					WithArgs("edited", editedAt, editedAt, uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantsBody:  "edited",
+			wantsEdits: true,
+		},
+		{
+			name: "does not update the message if the body has not changed",
+			body: "original",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnRows(messageRows())
+				mock.ExpectCommit()
+			},
+			wantsBody: "original",
+		},
+		{
+			name:  "does not update the message if the check fails",
+			body:  "edited",
+			check: errNotAuthor,
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnRows(messageRows())
+				mock.ExpectRollback()
+			},
+			wantsErr: errNotAuthor,
+		},
+		{
+			name: "fails if the message does not exist",
+			body: "edited",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := repo.UpdateBody(context.Background(), 1, tc.body, editedAt, func(m *models.Message) error {
+				if m.UserID != 2 {
+					t.Errorf("UpdateBody() checked user id = %v, wants %v", m.UserID, 2)
+				}
+
+				return tc.check
+			})
+
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("UpdateBody() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err == nil && (got.Body != tc.wantsBody || (got.EditedAt != nil) != tc.wantsEdits) {
+				t.Errorf("UpdateBody() = %+v, wants body %q and edited %v", got, tc.wantsBody, tc.wantsEdits)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("UpdateBody() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_SoftDelete(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+	deletedAt := time.Now()
+
+	testCases := []struct {
+		name     string
+		affected int64
+		wantsErr error
+	}{
+		{name: "marks the message as deleted", affected: 1},
+		{name: "fails if the message does not exist or was already deleted", wantsErr: models.ErrNoRecord},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectPrepare(regexp.QuoteMeta(queryMessageSoftDelete)).
+				ExpectExec().
+				WithArgs(deletedAt, deletedAt, uint64(1)).
+				WillReturnResult(sqlmock.NewResult(0, tc.affected))
+
+			err := repo.SoftDelete(context.Background(), 1, deletedAt)
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("SoftDelete() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("SoftDelete() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_PurgeDeleted(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+	before := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name  string
+		mock  func()
+		wants int64
+	}{
+		{
+			name: "purges a batch of deleted messages with their revisions and reactions",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindDeletedIDs)).
+					WithArgs(before, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(4).AddRow(7))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM message_revisions WHERE message_id IN (?, ?, ?)")).
+					WithArgs(uint64(1), uint64(4), uint64(7)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM message_reactions WHERE message_id IN (?, ?, ?)")).
+					WithArgs(uint64(1), uint64(4), uint64(7)).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM messages WHERE id IN (?, ?, ?)")).
+					WithArgs(uint64(1), uint64(4), uint64(7)).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+			},
+			wants: 3,
+		},
+		{
+			name: "purges nothing if no messages are past the retention period",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindDeletedIDs)).
+					WithArgs(before, 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			purged, err := repo.PurgeDeleted(context.Background(), before, 3)
+			if err != nil {
+				t.Fatalf("PurgeDeleted() unexpected error - %v", err)
+			}
+
+			if purged != tc.wants {
+				t.Errorf("PurgeDeleted() = %v, wants %v", purged, tc.wants)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("PurgeDeleted() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_AddReaction(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+	createdAt := time.Now()
+
+	testCases := []struct {
+		name     string
+		deleted  bool
+		err      error
+		wantsErr error
+	}{
+		{name: "adds the reaction"},
+		{
+			name:     "fails if the message was deleted before it was locked",
+			deleted:  true,
+			wantsErr: models.ErrNoRecord,
+		},
+		{
+			name:     "fails if the user already reacted with the emoji",
+			err:      &mysql.MySQLError{Number: models.MySQLDuplicateEntryNumber},
+			wantsErr: models.ErrDuplicateRecord,
+		},
+		{
+			name:     "fails if the user does not exist",
+			err:      &mysql.MySQLError{Number: models.MySQLForeignKeyViolationNumber},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			lock := mock.ExpectQuery(regexp.QuoteMeta(queryMessageFindIDForUpdate)).WithArgs(uint64(1))
+
+			switch {
+			case tc.deleted:
+				lock.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			case tc.err != nil:
+				lock.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(queryMessageReactionCreate)).
+					WithArgs(uint64(1), uint64(2), "👍", createdAt).
+					WillReturnError(tc.err)
+				mock.ExpectRollback()
+			default:
+				lock.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(queryMessageReactionCreate)).
+					WithArgs(uint64(1), uint64(2), "👍", createdAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageReactionCount)).
+					WithArgs(uint64(2), uint64(1), "👍").
+					WillReturnRows(sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted_by_me"}).
+						AddRow(1, "👍", 3, 1))
+				mock.ExpectCommit()
+			}
+
+			count, err := repo.AddReaction(context.Background(), &models.MessageReaction{
+				MessageID: 1,
+				UserID:    2,
+				Emoji:     "👍",
+				CreatedAt: createdAt,
+			})
+
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("AddReaction() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			wants := &models.ReactionCount{MessageID: 1, Emoji: "👍", Count: 3, ReactedByMe: true}
+			if tc.wantsErr == nil && !reflect.DeepEqual(count, wants) {
+				t.Errorf("AddReaction() = %+v, wants %+v", count, wants)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("AddReaction() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_RemoveReaction(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+
+	testCases := []struct {
+		name     string
+		affected int64
+		rows     *sqlmock.Rows
+		wants    *models.ReactionCount
+		wantsErr error
+	}{
+		{
+			name:     "removes the reaction and counts the others",
+			affected: 1,
+			rows: sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted_by_me"}).
+				AddRow(1, "👍", 1, 0),
+			wants: &models.ReactionCount{MessageID: 1, Emoji: "👍", Count: 1},
+		},
+		{
+			name:     "removes the last reaction with the emoji",
+			affected: 1,
+			rows:     sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted_by_me"}),
+			wants:    &models.ReactionCount{MessageID: 1, Emoji: "👍"},
+		},
+		{name: "fails if the user did not react with the emoji", wantsErr: models.ErrNoRecord},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(queryMessageReactionDelete)).
+				WithArgs(uint64(1), uint64(2), "👍").
+				WillReturnResult(sqlmock.NewResult(0, tc.affected))
+
+			if tc.rows != nil {
+				mock.ExpectQuery(regexp.QuoteMeta(queryMessageReactionCount)).
+					WithArgs(uint64(2), uint64(1), "👍").
+					WillReturnRows(tc.rows)
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			count, err := repo.RemoveReaction(context.Background(), 1, 2, "👍")
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("RemoveReaction() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if !reflect.DeepEqual(count, tc.wants) {
+				t.Errorf("RemoveReaction() = %+v, wants %+v", count, tc.wants)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("RemoveReaction() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestMessageRepo_GetReactionCounts(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewMessageRepository(db)
+
+	query, _, err := sqlx.In(queryMessageReactionCounts, uint64(2), []uint64{1, 3})
+	if err != nil {
+		t.Fatalf("GetReactionCounts() error building query - %v", err)
+	}
+
+	rows := sqlmock.NewRows([]string{"message_id", "emoji", "count", "reacted_by_me"}).
+		AddRow(1, "👍", 2, 1).
+		AddRow(3, "🎉", 1, 0)
+
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(uint64(2), uint64(1), uint64(3)).
+		WillReturnRows(rows)
+
+	counts, err := repo.GetReactionCounts(context.Background(), []uint64{1, 3}, 2)
+	if err != nil {
+		t.Fatalf("GetReactionCounts() unexpected error - %v", err)
+	}
+
+	wants := []models.ReactionCount{
+		{MessageID: 1, Emoji: "👍", Count: 2, ReactedByMe: true},
+		{MessageID: 3, Emoji: "🎉", Count: 1},
+	}
+
+	if !reflect.DeepEqual(counts, wants) {
+		t.Errorf("GetReactionCounts() = %v, wants %v", counts, wants)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetReactionCounts() unmet expectations - %v", err)
+	}
+}
@@ -1,7 +1,31 @@
 package mysql
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
 
 var (
 	errInvalidSQLQuery = errors.New("invalid sql query")
 )
+
+// withTransaction runs fn inside a transaction, committing it if fn succeeds and rolling it back otherwise
+func withTransaction(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("mysql.withTransaction:: error starting transaction - %v", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("mysql.withTransaction:: error committing transaction - %v", err)
+	}
+
+	return nil
+}
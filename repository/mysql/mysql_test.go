@@ -0,0 +1,13 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	errMySQLDuplicateEntry = &mysql.MySQLError{
+		Number:  models.MySQLDuplicateEntryNumber,
+		Message: "Duplicate entry",
+	}
+)
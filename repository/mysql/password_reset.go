@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/passwordreset"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// passwordResetRepo implements passwordreset.Repository
+type passwordResetRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryPasswordResetCreate = `INSERT INTO password_resets (user_id, token_hash, expires_at, created_at)
+	VALUES (?, ?, ?, ?)`
+
+	queryPasswordResetFindByTokenHash = `SELECT id, user_id, token_hash, expires_at, used_at, created_at
+	FROM password_resets
+	WHERE token_hash = ?`
+
+	queryPasswordResetMarkUsed = `UPDATE password_resets SET used_at = ?
+	WHERE id = ?
+		AND used_at IS NULL`
+
+	queryPasswordResetInvalidateUserResets = `UPDATE password_resets SET used_at = ?
+	WHERE user_id = ?
+		AND used_at IS NULL`
+)
+
+// Create adds a new models.PasswordReset
+func (r *passwordResetRepo) Create(ctx context.Context, reset *models.PasswordReset) (*models.PasswordReset, error) {
+	result, err := r.db.ExecContext(ctx, queryPasswordResetCreate, reset.UserID, reset.TokenHash, reset.ExpiresAt,
+		reset.CreatedAt)
+
+	if err != nil {
+		if isDuplicateEntryError(err) {
+			return nil, models.ErrDuplicateRecord
+		}
+
+		if isForeignKeyViolationError(err) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("passwordResetRepo.Create:: error inserting record - %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("passwordResetRepo.Create:: error getting id - %v", err)
+	}
+
+	reset.ID = uint64(id)
+	return reset, nil
+}
+
+// FindByTokenHash fetches a models.PasswordReset using the hash of the token
+func (r *passwordResetRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	reset := &models.PasswordReset{}
+
+	if err := r.db.GetContext(ctx, reset, queryPasswordResetFindByTokenHash, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("passwordResetRepo.FindByTokenHash:: error finding password reset - %v", err)
+	}
+
+	return reset, nil
+}
+
+// MarkUsed marks the models.PasswordReset as used. It returns false if the token had already been used so that
+// concurrent uses of the same token can be detected.
+func (r *passwordResetRepo) MarkUsed(ctx context.Context, id uint64, usedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, queryPasswordResetMarkUsed, usedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("passwordResetRepo.MarkUsed:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("passwordResetRepo.MarkUsed:: error getting affected rows - %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+// InvalidateUserResets marks every unused models.PasswordReset for the models.User as used
+func (r *passwordResetRepo) InvalidateUserResets(ctx context.Context, userID uint64, at time.Time) error {
+	if _, err := r.db.ExecContext(ctx, queryPasswordResetInvalidateUserResets, at, userID); err != nil {
+		return fmt.Errorf("passwordResetRepo.InvalidateUserResets:: error updating records - %v", err)
+	}
+
+	return nil
+}
+
+// NewPasswordResetRepository creates a new password reset repository
+func NewPasswordResetRepository(db *sqlx.DB) passwordreset.Repository {
+	return &passwordResetRepo{
+		db: db,
+	}
+}
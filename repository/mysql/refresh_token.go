@@ -0,0 +1,106 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/refreshtoken"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// refreshTokenRepo implements refreshtoken.Repository
+type refreshTokenRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryRefreshTokenCreate = `INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+	VALUES (?, ?, ?, ?, ?)`
+
+	queryRefreshTokenFindByTokenHash = `SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at,
+		created_at
+	FROM refresh_tokens
+	WHERE token_hash = ?`
+
+	queryRefreshTokenMarkRotated = `UPDATE refresh_tokens SET rotated_at = ?
+	WHERE id = ?
+		AND rotated_at IS NULL
+		AND revoked_at IS NULL`
+
+	queryRefreshTokenRevokeFamily = `UPDATE refresh_tokens SET revoked_at = ?
+	WHERE family_id = ?
+		AND revoked_at IS NULL`
+)
+
+// Create adds a new models.RefreshToken
+func (r *refreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	result, err := r.db.ExecContext(ctx, queryRefreshTokenCreate, token.UserID, token.FamilyID, token.TokenHash,
+		token.ExpiresAt, token.CreatedAt)
+
+	if err != nil {
+		if isDuplicateEntryError(err) {
+			return nil, models.ErrDuplicateRecord
+		}
+
+		return nil, fmt.Errorf("refreshTokenRepo.Create:: error inserting record - %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("refreshTokenRepo.Create:: error getting id - %v", err)
+	}
+
+	token.ID = uint64(id)
+	return token, nil
+}
+
+// FindByTokenHash fetches a models.RefreshToken using the hash of the token
+func (r *refreshTokenRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	token := &models.RefreshToken{}
+
+	if err := r.db.GetContext(ctx, token, queryRefreshTokenFindByTokenHash, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("refreshTokenRepo.FindByTokenHash:: error finding refresh token - %v", err)
+	}
+
+	return token, nil
+}
+
+// MarkRotated marks the models.RefreshToken as exchanged. It returns false if the token had already been rotated
+// or revoked so that concurrent rotations of the same token can be detected.
+func (r *refreshTokenRepo) MarkRotated(ctx context.Context, id uint64, rotatedAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, queryRefreshTokenMarkRotated, rotatedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("refreshTokenRepo.MarkRotated:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("refreshTokenRepo.MarkRotated:: error getting affected rows - %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+// RevokeFamily revokes every models.RefreshToken in the family
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, queryRefreshTokenRevokeFamily, revokedAt, familyID); err != nil {
+		return fmt.Errorf("refreshTokenRepo.RevokeFamily:: error updating records - %v", err)
+	}
+
+	return nil
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *sqlx.DB) refreshtoken.Repository {
+	return &refreshTokenRepo{
+		db: db,
+	}
+}
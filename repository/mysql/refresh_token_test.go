@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"chatapp/repository/mockdb"
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenRepo_MarkRotated(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewRefreshTokenRepository(db)
+	rotatedAt := time.Now()
+
+	testCases := []struct {
+		name     string
+		affected int64
+		wants    bool
+	}{
+		{
+			name:     "marks an active token as rotated",
+			affected: 1,
+			wants:    true,
+		},
+		{
+			name:     "reports a token that was already rotated",
+			affected: 0,
+			wants:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectExec(regexp.QuoteMeta(queryRefreshTokenMarkRotated)).
+				WithArgs(rotatedAt, uint64(1)).
+				WillReturnResult(sqlmock.NewResult(0, tc.affected))
+
+			got, err := repo.MarkRotated(context.Background(), 1, rotatedAt)
+			if err != nil {
+				t.Fatalf("MarkRotated() unexpected error - %v", err)
+			}
+
+			if got != tc.wants {
+				t.Errorf("MarkRotated() = %v, wants %v", got, tc.wants)
+			}
+		})
+	}
+}
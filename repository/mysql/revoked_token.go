@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"chatapp/services/revocation"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// revokedTokenRepo implements revocation.Repository
+type revokedTokenRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryRevokedTokenCreate = `INSERT INTO revoked_tokens (uuid, expires_at) VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE expires_at = VALUES(expires_at)`
+
+	queryRevokedTokenDeleteExpired = `DELETE FROM revoked_tokens WHERE expires_at <= ?`
+
+	queryRevokedTokenExists = `SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE uuid IN (?) AND expires_at > ?)`
+)
+
+// Revoke stores the token or session uuid until it expires
+func (r *revokedTokenRepo) Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	// Tokens are only stored until they expire, so prune the expired ones as new ones come in
+	if _, err := r.db.ExecContext(ctx, queryRevokedTokenDeleteExpired, time.Now()); err != nil {
+		return fmt.Errorf("revokedTokenRepo.Revoke:: error deleting expired records - %v", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, queryRevokedTokenCreate, id, expiresAt); err != nil {
+		return fmt.Errorf("revokedTokenRepo.Revoke:: error inserting record - %v", err)
+	}
+
+	return nil
+}
+
+// IsRevoked checks if any of the token or session uuids has been revoked and has not expired yet
+func (r *revokedTokenRepo) IsRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error) {
+	var exists bool
+
+	query, args, err := sqlx.In(queryRevokedTokenExists, ids, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("revokedTokenRepo.IsRevoked:: error building query - %v", err)
+	}
+
+	if err := r.db.GetContext(ctx, &exists, r.db.Rebind(query), args...); err != nil {
+		return false, fmt.Errorf("revokedTokenRepo.IsRevoked:: error executing query - %v", err)
+	}
+
+	return exists, nil
+}
+
+// NewRevokedTokenRepository creates a new revoked token repository
+func NewRevokedTokenRepository(db *sqlx.DB) revocation.Repository {
+	return &revokedTokenRepo{
+		db: db,
+	}
+}
@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"chatapp/repository/mockdb"
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRevokedTokenRepo_Revoke(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewRevokedTokenRepository(db)
+	tokenUUID := uuid.New()
+	expiresAt := time.Now().Add(time.Minute)
+
+	mock.ExpectExec(regexp.QuoteMeta(queryRevokedTokenDeleteExpired)).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(queryRevokedTokenCreate)).
+		WithArgs(tokenUUID, expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.Revoke(context.Background(), tokenUUID, expiresAt); err != nil {
+		t.Fatalf("Revoke() unexpected error - %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Revoke() unmet expectations - %v", err)
+	}
+}
+
+func TestRevokedTokenRepo_IsRevoked(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewRevokedTokenRepository(db)
+	tokenUUID, sessionID := uuid.New(), uuid.New()
+
+	query := regexp.QuoteMeta(strings.Replace(queryRevokedTokenExists, "IN (?)", "IN (?, ?)", 1))
+
+	for _, wants := range []bool{true, false} {
+		mock.ExpectQuery(query).
+			WithArgs(tokenUUID, sessionID, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(wants))
+
+		got, err := repo.IsRevoked(context.Background(), tokenUUID, sessionID)
+		if err != nil {
+			t.Fatalf("IsRevoked() unexpected error - %v", err)
+		}
+
+		if got != wants {
+			t.Errorf("IsRevoked() = %v, wants %v", got, wants)
+		}
+	}
+}
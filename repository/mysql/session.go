@@ -0,0 +1,119 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/session"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// sessionRepo implements session.Repository
+type sessionRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	querySessionCreate = `INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+
+	querySessionFindByID = `SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at
+	FROM sessions
+	WHERE id = ?`
+
+	querySessionFindByUserID = `SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, revoked_at
+	FROM sessions
+	WHERE user_id = ?
+		AND last_seen_at > ?
+		AND revoked_at IS NULL
+	ORDER BY last_seen_at DESC`
+
+	querySessionTouch = `UPDATE sessions SET last_seen_at = ? WHERE id = ?`
+
+	querySessionRevoke = `UPDATE sessions SET revoked_at = ?
+	WHERE user_id = ?
+		AND id IN (?)
+		AND revoked_at IS NULL`
+)
+
+// Create adds a new models.Session
+func (r *sessionRepo) Create(ctx context.Context, session *models.Session) (*models.Session, error) {
+	_, err := r.db.ExecContext(ctx, querySessionCreate, session.ID, session.UserID, session.UserAgent,
+		session.IPAddress, session.CreatedAt, session.LastSeenAt)
+
+	if err != nil {
+		if isDuplicateEntryError(err) {
+			return nil, models.ErrDuplicateRecord
+		}
+
+		return nil, fmt.Errorf("sessionRepo.Create:: error inserting record - %v", err)
+	}
+
+	return session, nil
+}
+
+// FindByID fetches a models.Session using the id provided
+func (r *sessionRepo) FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	foundSession := &models.Session{}
+
+	if err := r.db.GetContext(ctx, foundSession, querySessionFindByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("sessionRepo.FindByID:: error finding session - %v", err)
+	}
+
+	return foundSession, nil
+}
+
+// GetUserSessions returns the models.User sessions that have not been revoked and were last seen after the time
+// provided, from the most to the least recently used
+func (r *sessionRepo) GetUserSessions(ctx context.Context, userID uint64, lastSeenAfter time.Time) (
+	[]models.Session, error) {
+	var sessions []models.Session
+
+	if err := r.db.SelectContext(ctx, &sessions, querySessionFindByUserID, userID, lastSeenAfter); err != nil {
+		return nil, fmt.Errorf("sessionRepo.GetUserSessions:: error getting user sessions - %v", err)
+	}
+
+	if len(sessions) == 0 {
+		return []models.Session{}, nil
+	}
+
+	return sessions, nil
+}
+
+// Touch updates the last time the models.Session was used
+func (r *sessionRepo) Touch(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, querySessionTouch, lastSeenAt, id); err != nil {
+		return fmt.Errorf("sessionRepo.Touch:: error updating record - %v", err)
+	}
+
+	return nil
+}
+
+// Revoke marks the models.User sessions as revoked
+func (r *sessionRepo) Revoke(ctx context.Context, userID uint64, ids []uuid.UUID, revokedAt time.Time) error {
+	query, args, err := sqlx.In(querySessionRevoke, revokedAt, userID, ids)
+	if err != nil {
+		return fmt.Errorf("sessionRepo.Revoke:: error building query - %v", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("sessionRepo.Revoke:: error updating records - %v", err)
+	}
+
+	return nil
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sqlx.DB) session.Repository {
+	return &sessionRepo{
+		db: db,
+	}
+}
@@ -0,0 +1,149 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/twofactor"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// twoFactorRepo implements twofactor.Repository
+type twoFactorRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryTwoFactorFind = `SELECT user_id, secret, confirmed_at, last_used_step, created_at
+	FROM two_factors
+	WHERE user_id = ?`
+
+	queryTwoFactorSave = `INSERT INTO two_factors (user_id, secret, created_at) VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE secret = VALUES(secret), confirmed_at = NULL, last_used_step = 0,
+		created_at = VALUES(created_at)`
+
+	queryTwoFactorConfirm = `UPDATE two_factors SET confirmed_at = ? WHERE user_id = ?`
+
+	queryTwoFactorUseStep = `UPDATE two_factors SET last_used_step = ?
+	WHERE user_id = ?
+		AND last_used_step < ?`
+
+	queryTwoFactorDelete = `DELETE FROM two_factors WHERE user_id = ?`
+
+	queryTwoFactorRecoveryCodeCreate = `INSERT INTO two_factor_recovery_codes (user_id, code_hash, created_at)
+	VALUES (?, ?, ?)`
+
+	queryTwoFactorRecoveryCodeUse = `UPDATE two_factor_recovery_codes SET used_at = ?
+	WHERE user_id = ?
+		AND code_hash = ?
+		AND used_at IS NULL`
+
+	queryTwoFactorRecoveryCodeDeleteAll = `DELETE FROM two_factor_recovery_codes WHERE user_id = ?`
+)
+
+// Find fetches the models.TwoFactor for the user
+func (r *twoFactorRepo) Find(ctx context.Context, userID uint64) (*models.TwoFactor, error) {
+	twoFactor := &models.TwoFactor{}
+
+	if err := r.db.GetContext(ctx, twoFactor, queryTwoFactorFind, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("twoFactorRepo.Find:: error finding two factor - %v", err)
+	}
+
+	return twoFactor, nil
+}
+
+// Save stores the unconfirmed models.TwoFactor, replacing any earlier one
+func (r *twoFactorRepo) Save(ctx context.Context, twoFactor *models.TwoFactor) error {
+	_, err := r.db.ExecContext(ctx, queryTwoFactorSave, twoFactor.UserID, twoFactor.Secret, twoFactor.CreatedAt)
+	if err != nil {
+		if isForeignKeyViolationError(err) {
+			return models.ErrNoRecord
+		}
+
+		return fmt.Errorf("twoFactorRepo.Save:: error upserting record - %v", err)
+	}
+
+	return nil
+}
+
+// Confirm enables the models.TwoFactor and replaces the recovery codes with the hashes provided
+func (r *twoFactorRepo) Confirm(ctx context.Context, userID uint64, confirmedAt time.Time,
+	recoveryCodeHashes []string) error {
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, queryTwoFactorConfirm, confirmedAt, userID); err != nil {
+			return fmt.Errorf("twoFactorRepo.Confirm:: error updating record - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryTwoFactorRecoveryCodeDeleteAll, userID); err != nil {
+			return fmt.Errorf("twoFactorRepo.Confirm:: error deleting recovery codes - %v", err)
+		}
+
+		for _, codeHash := range recoveryCodeHashes {
+			if _, err := tx.ExecContext(ctx, queryTwoFactorRecoveryCodeCreate, userID, codeHash, confirmedAt); err != nil {
+				return fmt.Errorf("twoFactorRepo.Confirm:: error inserting recovery code - %v", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// UseStep records the TOTP step used, it returns false if a code for the step or a later one was already used
+func (r *twoFactorRepo) UseStep(ctx context.Context, userID uint64, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, queryTwoFactorUseStep, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("twoFactorRepo.UseStep:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("twoFactorRepo.UseStep:: error getting affected rows - %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+// UseRecoveryCode marks the recovery code as used, it returns false if it does not exist or was already used
+func (r *twoFactorRepo) UseRecoveryCode(ctx context.Context, userID uint64, codeHash string, usedAt time.Time) (
+	bool, error) {
+	result, err := r.db.ExecContext(ctx, queryTwoFactorRecoveryCodeUse, usedAt, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("twoFactorRepo.UseRecoveryCode:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("twoFactorRepo.UseRecoveryCode:: error getting affected rows - %v", err)
+	}
+
+	return affected == 1, nil
+}
+
+// Delete removes the models.TwoFactor and its recovery codes
+func (r *twoFactorRepo) Delete(ctx context.Context, userID uint64) error {
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, queryTwoFactorRecoveryCodeDeleteAll, userID); err != nil {
+			return fmt.Errorf("twoFactorRepo.Delete:: error deleting recovery codes - %v", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryTwoFactorDelete, userID); err != nil {
+			return fmt.Errorf("twoFactorRepo.Delete:: error deleting record - %v", err)
+		}
+
+		return nil
+	})
+}
+
+// NewTwoFactorRepository creates a new two factor repository
+func NewTwoFactorRepository(db *sqlx.DB) twofactor.Repository {
+	return &twoFactorRepo{
+		db: db,
+	}
+}
@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"chatapp/repository/mockdb"
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestTwoFactorRepo_Confirm(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewTwoFactorRepository(db)
+	confirmedAt := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(queryTwoFactorConfirm)).
+		WithArgs(confirmedAt, uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(queryTwoFactorRecoveryCodeDeleteAll)).
+		WithArgs(uint64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		mock.ExpectExec(regexp.QuoteMeta(queryTwoFactorRecoveryCodeCreate)).
+			WithArgs(uint64(1), hash, confirmedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	mock.ExpectCommit()
+
+	if err := repo.Confirm(context.Background(), 1, confirmedAt, []string{"hash-1", "hash-2"}); err != nil {
+		t.Fatalf("Confirm() unexpected error - %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Confirm() unmet expectations - %v", err)
+	}
+}
+
+func TestTwoFactorRepo_UseStep(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewTwoFactorRepository(db)
+
+	for affected, wants := range []bool{false, true} {
+		mock.ExpectExec(regexp.QuoteMeta(queryTwoFactorUseStep)).
+			WithArgs(int64(100), uint64(1), int64(100)).
+			WillReturnResult(sqlmock.NewResult(0, int64(affected)))
+
+		got, err := repo.UseStep(context.Background(), 1, 100)
+		if err != nil {
+			t.Fatalf("UseStep() unexpected error - %v", err)
+		}
+
+		if got != wants {
+			t.Errorf("UseStep() = %v, wants %v", got, wants)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("UseStep() unmet expectations - %v", err)
+	}
+}
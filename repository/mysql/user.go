@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
 	"chatapp/services/user"
 	"context"
 	"database/sql"
@@ -9,6 +10,8 @@ import (
 	"fmt"
 	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	"strings"
+	"time"
 )
 
 // userRepo implements user.Repository
@@ -19,21 +22,64 @@ type userRepo struct {
 const (
 	queryUsersCreate = `INSERT INTO users (username, password, created_at, updated_at) VALUES (?, ?, ?, ?)`
 
-	queryUsersFindByID = `SELECT id, username, created_at, updated_at
+	queryUsersFindByID = `SELECT id, username, display_name, avatar_url, bio, status_text, status_emoji, created_at,
+		updated_at
 	FROM users
 	WHERE id = ?
 	  AND deleted_at IS NULL`
 
-	queryUsersFindByUsername = `SELECT id, username, created_at, updated_at, deleted_at
+	queryUsersFindByUsername = `SELECT id, username, display_name, avatar_url, bio, status_text, status_emoji, created_at,
+		updated_at, deleted_at
 	FROM users
 	WHERE username = ?
 	  AND deleted_at IS NULL`
 
-	queryUsersCheckIfExists = `SELECT EXISTS(SELECT 1 FROM users WHERE %s = '%s')`
-
 	queryUsersFindIDAndPassword = `SELECT id, password FROM users
 		WHERE username = ?
 		  AND deleted_at IS NULL`
+
+	queryUsersFindPasswordByID = `SELECT password FROM users
+		WHERE id = ?
+		  AND deleted_at IS NULL`
+
+	queryUsersUpdatePassword = `UPDATE users SET password = ?, updated_at = ?
+		WHERE id = ?
+		  AND deleted_at IS NULL`
+
+	queryUsersFindAll = `SELECT id, username, display_name, avatar_url, bio, status_text, status_emoji, created_at,
+		updated_at
+	FROM users
+	WHERE deleted_at IS NULL
+	  AND id > ?
+	ORDER BY id
+	LIMIT ?`
+
+	// queryUsersSearch matches the username and the display name prefix separately so that each uses its index
+	queryUsersSearch = `(SELECT id, username, display_name, avatar_url, bio, status_text, status_emoji, created_at,
+		updated_at
+	FROM users
+	WHERE username LIKE ?
+	  AND deleted_at IS NULL
+	  AND id > ?
+	ORDER BY id
+	LIMIT ?)
+	UNION
+	(SELECT id, username, display_name, avatar_url, bio, status_text, status_emoji, created_at, updated_at
+	FROM users
+	WHERE display_name LIKE ?
+	  AND deleted_at IS NULL
+	  AND id > ?
+	ORDER BY id
+	LIMIT ?)
+	ORDER BY id
+	LIMIT ?`
+
+	queryUsersFindIDForUpdate = `SELECT id FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+
+	queryUsersUpdateProfile = `UPDATE users
+	SET display_name = ?, avatar_url = ?, bio = ?, status_text = ?, status_emoji = ?, updated_at = ?
+	WHERE id = ?
+	  AND deleted_at IS NULL`
 )
 
 // Create inserts a new user record
@@ -97,15 +143,15 @@ func (r *userRepo) FindByUsername(ctx context.Context, username string) (*models
 	return foundUser, nil
 }
 
-// CheckIfExists looks up if a given column exists
-func (r *userRepo) CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error) {
-	var exists bool
-
-	if err := r.db.GetContext(ctx, &exists, fmt.Sprintf(queryUsersCheckIfExists, column, value)); err != nil {
-		return false, fmt.Errorf("userRepo.CheckIfExists:: error executing query - %v", err)
+// Exists checks if a user matching every filter exists, only the models.UserColumn columns can be filtered by.
+// Soft deleted users are included since their usernames cannot be reused.
+func (r *userRepo) Exists(ctx context.Context, filters ...models.Filter) (bool, error) {
+	found, err := exists(ctx, r.db, "users", userFilterColumns, filters)
+	if err != nil {
+		return false, fmt.Errorf("userRepo.Exists:: %w", err)
 	}
 
-	return exists, nil
+	return found, nil
 }
 
 // GetIDAndPassword returns the id and password for the user to be user for logging in
@@ -123,6 +169,99 @@ func (r *userRepo) GetIDAndPassword(ctx context.Context, username string) (*mode
 	return foundUser, nil
 }
 
+// GetPassword returns the hashed password for the user with the provided ID
+func (r *userRepo) GetPassword(ctx context.Context, id uint64) (string, error) {
+	var password string
+
+	if err := r.db.GetContext(ctx, &password, queryUsersFindPasswordByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", models.ErrNoRecord
+		}
+
+		return "", fmt.Errorf("userRepo.GetPassword:: error finding user - %v", err)
+	}
+
+	return password, nil
+}
+
+// UpdatePassword replaces the hashed password for the user with the provided ID
+func (r *userRepo) UpdatePassword(ctx context.Context, id uint64, password string, updatedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, queryUsersUpdatePassword, password, updatedAt, id)
+	if err != nil {
+		return fmt.Errorf("userRepo.UpdatePassword:: error updating record - %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("userRepo.UpdatePassword:: error getting affected rows - %v", err)
+	}
+
+	if affected == 0 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
+
+// likePrefix returns the LIKE pattern matching values starting with the prefix, the wildcards in the prefix are
+// escaped so that they are matched literally
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// Search returns the []models.User whose username or display name starts with the query ordered by id. It fetches
+// one user more than params.Limit after params.After so that the caller can tell if there are more to page through.
+func (r *userRepo) Search(ctx context.Context, query string, params pagination.Params) ([]models.User, error) {
+	var (
+		users []models.User
+		err   error
+	)
+
+	limit := params.Limit + 1
+
+	if query == "" {
+		err = r.db.SelectContext(ctx, &users, queryUsersFindAll, params.After, limit)
+	} else {
+		pattern := likePrefix(query)
+		err = r.db.SelectContext(ctx, &users, queryUsersSearch, pattern, params.After, limit, pattern, params.After,
+			limit, limit)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("userRepo.Search:: error searching users - %v", err)
+	}
+
+	if len(users) == 0 {
+		return []models.User{}, nil
+	}
+
+	return users, nil
+}
+
+// UpdateProfile replaces the models.UserProfile for the user with the provided ID. The user is looked up first since
+// MySQL reports no affected rows when the profile saved is the same as the current one.
+func (r *userRepo) UpdateProfile(ctx context.Context, id uint64, profile models.UserProfile, updatedAt time.Time) error {
+	return withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var foundID uint64
+
+		if err := tx.GetContext(ctx, &foundID, queryUsersFindIDForUpdate, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.ErrNoRecord
+			}
+
+			return fmt.Errorf("userRepo.UpdateProfile:: error finding user - %v", err)
+		}
+
+		_, err := tx.ExecContext(ctx, queryUsersUpdateProfile, profile.DisplayName, profile.AvatarURL, profile.Bio,
+			profile.StatusText, profile.StatusEmoji, updatedAt, id)
+		if err != nil {
+			return fmt.Errorf("userRepo.UpdateProfile:: error updating record - %v", err)
+		}
+
+		return nil
+	})
+}
+
 // NewUserRepository creates a new user repository
 func NewUserRepository(db *sqlx.DB) user.Repository {
 	return &userRepo{
@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/services/identity"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+)
+
+// userIdentityRepo implements identity.Repository
+type userIdentityRepo struct {
+	db *sqlx.DB
+}
+
+const (
+	queryUserIdentityFindBySubject = `SELECT id, user_id, provider, subject, email, created_at
+	FROM user_identities
+	WHERE provider = ?
+		AND subject = ?`
+
+	queryUserIdentityCreate = `INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+	VALUES (?, ?, ?, ?, ?)`
+)
+
+// FindBySubject fetches the models.UserIdentity of the provider's user
+func (r *userIdentityRepo) FindBySubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	userIdentity := &models.UserIdentity{}
+
+	if err := r.db.GetContext(ctx, userIdentity, queryUserIdentityFindBySubject, provider, subject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("userIdentityRepo.FindBySubject:: error finding identity - %v", err)
+	}
+
+	return userIdentity, nil
+}
+
+// CreateWithUser adds the models.User and links the models.UserIdentity to them
+func (r *userIdentityRepo) CreateWithUser(ctx context.Context, user *models.User, userIdentity *models.UserIdentity) (
+	*models.UserIdentity, error) {
+	err := withTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, queryUsersCreate, user.Username, user.Password, user.CreatedAt,
+			user.UpdatedAt)
+		if err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			return fmt.Errorf("userIdentityRepo.CreateWithUser:: error inserting user - %v", err)
+		}
+
+		userID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("userIdentityRepo.CreateWithUser:: error getting user id - %v", err)
+		}
+
+		result, err = tx.ExecContext(ctx, queryUserIdentityCreate, userID, userIdentity.Provider,
+			userIdentity.Subject, userIdentity.Email, userIdentity.CreatedAt)
+		if err != nil {
+			if isDuplicateEntryError(err) {
+				return models.ErrDuplicateRecord
+			}
+
+			return fmt.Errorf("userIdentityRepo.CreateWithUser:: error inserting identity - %v", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("userIdentityRepo.CreateWithUser:: error getting identity id - %v", err)
+		}
+
+		user.ID = uint64(userID)
+		userIdentity.ID = uint64(id)
+		userIdentity.UserID = user.ID
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return userIdentity, nil
+}
+
+// Create links the models.UserIdentity to an existing models.User
+func (r *userIdentityRepo) Create(ctx context.Context, userIdentity *models.UserIdentity) (*models.UserIdentity,
+	error) {
+	result, err := r.db.ExecContext(ctx, queryUserIdentityCreate, userIdentity.UserID, userIdentity.Provider,
+		userIdentity.Subject, userIdentity.Email, userIdentity.CreatedAt)
+	if err != nil {
+		if isDuplicateEntryError(err) {
+			return nil, models.ErrDuplicateRecord
+		}
+
+		if isForeignKeyViolationError(err) {
+			return nil, models.ErrNoRecord
+		}
+
+		return nil, fmt.Errorf("userIdentityRepo.Create:: error inserting identity - %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("userIdentityRepo.Create:: error getting identity id - %v", err)
+	}
+
+	userIdentity.ID = uint64(id)
+	return userIdentity, nil
+}
+
+// NewUserIdentityRepository creates a new user identity repository
+func NewUserIdentityRepository(db *sqlx.DB) identity.Repository {
+	return &userIdentityRepo{
+		db: db,
+	}
+}
@@ -0,0 +1,141 @@
+package mysql
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/repository/mockdb"
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestUserIdentityRepo_CreateWithUser(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewUserIdentityRepository(db)
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "creates the user and links the identity",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryUsersCreate)).
+					WithArgs("jay", "", now, now).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta(queryUserIdentityCreate)).
+					WithArgs(int64(1), "company", "248289761001", "jay@example.com", now).
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "returns a duplicate error if the username is taken",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(queryUsersCreate)).
+					WithArgs("jay", "", now, now).
+					WillReturnError(errMySQLDuplicateEntry)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrDuplicateRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := repo.CreateWithUser(context.Background(), &models.User{
+				Username:  "jay",
+				CreatedAt: now,
+				UpdatedAt: now,
+			}, &models.UserIdentity{
+				Provider:  "company",
+				Subject:   "248289761001",
+				Email:     "jay@example.com",
+				CreatedAt: now,
+			})
+
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("CreateWithUser() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && (got.ID != 2 || got.UserID != 1) {
+				t.Errorf("CreateWithUser() = %+v, wants id 2 and user id 1", got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("CreateWithUser() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestUserIdentityRepo_Create(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewUserIdentityRepository(db)
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		err      error
+		wantsErr error
+	}{
+		{name: "links the identity to the user"},
+		{
+			name:     "returns a duplicate error if the identity is already linked",
+			err:      errMySQLDuplicateEntry,
+			wantsErr: models.ErrDuplicateRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exec := mock.ExpectExec(regexp.QuoteMeta(queryUserIdentityCreate)).
+				WithArgs(uint64(1), "company", "248289761001", "jay@example.com", now)
+
+			if tc.err != nil {
+				exec.WillReturnError(tc.err)
+			} else {
+				exec.WillReturnResult(sqlmock.NewResult(2, 1))
+			}
+
+			got, err := repo.Create(context.Background(), &models.UserIdentity{
+				UserID:    1,
+				Provider:  "company",
+				Subject:   "248289761001",
+				Email:     "jay@example.com",
+				CreatedAt: now,
+			})
+
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("Create() error = %v, wantsErr = %v", err, tc.wantsErr)
+				return
+			}
+
+			if err == nil && got.ID != 2 {
+				t.Errorf("Create() id = %v, wants %v", got.ID, 2)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Create() unmet expectations - %v", err)
+			}
+		})
+	}
+}
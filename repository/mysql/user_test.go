@@ -2,17 +2,19 @@ package mysql
 
 import (
 	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
 	"chatapp/repository/factory"
 	"chatapp/repository/mockdb"
 	"chatapp/services/user"
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func TestUserRepo_Create(t *testing.T) {
@@ -245,68 +247,70 @@ func TestUserRepo_FindByUsername(t *testing.T) {
 	}
 }
 
-func TestUserRepo_CheckIfExists(t *testing.T) {
+func TestUserRepo_Exists(t *testing.T) {
 	db, mock := mockdb.NewMock()
 	defer func(db *sqlx.DB) {
 		_ = db.Close()
 	}(db)
 
 	repo := NewUserRepository(db)
-	fakeUser := factory.NewUser()
-	fakeUser.ID = 1
-	fakeUser.Username = "jwambugu"
-
-	//rows := sqlmock.NewRows([]string{"id", "username"}).AddRow(fakeUser.ID, fakeUser.Username)
+	query := regexp.QuoteMeta(`SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)`)
 
 	testCases := []struct {
 		name     string
 		repo     user.Repository
 		mock     func()
-		column   string
-		value    string
+		filters  []models.Filter
 		wants    bool
-		wantsErr bool
+		wantsErr error
+		// failsQuery is set when the query itself fails, the error is not returned as a sentinel error
+		failsQuery bool
 	}{
 		{
 			name: "finds a record, returns true",
 			repo: repo,
 			mock: func() {
-				query := regexp.QuoteMeta(fmt.Sprintf(queryUsersCheckIfExists, "username", "jwambugu"))
-
-				row := sqlmock.NewRows([]string{"exists"}).AddRow(true)
-				mock.ExpectQuery(query).WillReturnRows(row)
+				mock.ExpectQuery(query).
+					WithArgs("jwambugu").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 			},
-			column:   "username",
-			value:    "jwambugu",
-			wants:    true,
-			wantsErr: false,
+			filters: []models.Filter{models.Where(models.UserColumnUsername, "jwambugu")},
+			wants:   true,
 		},
 		{
 			name: "no existing record is found, returns false",
 			repo: repo,
 			mock: func() {
-				query := regexp.QuoteMeta(fmt.Sprintf(queryUsersCheckIfExists, "username", "jwambugu"))
-
-				row := sqlmock.NewRows([]string{"exists"}).AddRow(false)
-				mock.ExpectQuery(query).WillReturnRows(row)
+				mock.ExpectQuery(query).
+					WithArgs("jwambugu' OR '1'='1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 			},
-			column:   "username",
-			value:    "jwambugu",
-			wants:    false,
-			wantsErr: false,
+			filters: []models.Filter{models.Where(models.UserColumnUsername, "jwambugu' OR '1'='1")},
+			wants:   false,
+		},
+		{
+			name:     "fails to filter by a column that is not allowed",
+			repo:     repo,
+			mock:     func() {},
+			filters:  []models.Filter{models.Where("1 = 1 OR username", "jwambugu")},
+			wantsErr: models.ErrInvalidFilter,
 		},
 		{
 			name: "fails to execute because of invalid SQL query",
 			repo: repo,
 			mock: func() {
-				mock.ExpectQuery("SELECTS (.+) FROM users").
-					WithArgs(fakeUser.Username).
+				mock.ExpectQuery(query).
+					WithArgs("jwambugu").
 					WillReturnError(errInvalidSQLQuery)
 			},
-			column:   "username",
-			value:    "jwambugu",
-			wants:    false,
-			wantsErr: true,
+			filters:    []models.Filter{models.Where(models.UserColumnUsername, "jwambugu")},
+			failsQuery: true,
+		},
+		{
+			name:     "fails if no filters are provided",
+			repo:     repo,
+			mock:     func() {},
+			wantsErr: models.ErrInvalidFilter,
 		},
 	}
 
@@ -314,14 +318,22 @@ func TestUserRepo_CheckIfExists(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mock()
 
-			got, err := tc.repo.CheckIfExists(context.Background(), tc.column, tc.value)
-			if (err != nil) != tc.wantsErr {
-				t.Errorf("CheckIfExists() error = %v, wantsErr = %v", err, tc.wantsErr)
+			got, err := tc.repo.Exists(context.Background(), tc.filters...)
+			if tc.failsQuery && err == nil {
+				t.Errorf("Exists() error = nil, wants the query error")
+			}
+
+			if !tc.failsQuery && !errors.Is(err, tc.wantsErr) {
+				t.Errorf("Exists() error = %v, wantsErr = %v", err, tc.wantsErr)
 				return
 			}
 
-			if err == nil && !reflect.DeepEqual(got, tc.wants) {
-				t.Errorf("CheckIfExists() = %v, wants %v", got, tc.wants)
+			if got != tc.wants {
+				t.Errorf("Exists() = %v, wants %v", got, tc.wants)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Exists() unmet expectations - %v", err)
 			}
 		})
 	}
@@ -403,3 +415,191 @@ func TestUserRepo_GetIDAndPassword(t *testing.T) {
 		})
 	}
 }
+
+func TestUserRepo_UpdatePassword(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewUserRepository(db)
+	updatedAt := time.Now()
+
+	testCases := []struct {
+		name     string
+		repo     user.Repository
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "updates the user password",
+			repo: repo,
+			mock: func() {
+				mock.ExpectExec(regexp.QuoteMeta(queryUsersUpdatePassword)).
+					WithArgs("hashed", updatedAt, uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "returns no records if user does not exist",
+			repo: repo,
+			mock: func() {
+				mock.ExpectExec(regexp.QuoteMeta(queryUsersUpdatePassword)).
+					WithArgs("hashed", updatedAt, uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := tc.repo.UpdatePassword(context.Background(), 1, "hashed", updatedAt)
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("UpdatePassword() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+		})
+	}
+}
+
+func TestUserRepo_UpdateProfile(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewUserRepository(db)
+	updatedAt := time.Now()
+	profile := models.UserProfile{
+		DisplayName: "Jay",
+		AvatarURL:   "https://example.com/jay.png",
+		Bio:         "Gopher",
+		StatusText:  "Writing code",
+		StatusEmoji: "💻",
+	}
+
+	testCases := []struct {
+		name     string
+		mock     func()
+		wantsErr error
+	}{
+		{
+			name: "updates the profile",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryUsersFindIDForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(queryUsersUpdateProfile)).
+					WithArgs(profile.DisplayName, profile.AvatarURL, profile.Bio, profile.StatusText,
+						profile.StatusEmoji, updatedAt, uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "succeeds if the profile has not changed",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryUsersFindIDForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectExec(regexp.QuoteMeta(queryUsersUpdateProfile)).
+					WithArgs(profile.DisplayName, profile.AvatarURL, profile.Bio, profile.StatusText,
+						profile.StatusEmoji, updatedAt, uint64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "fails if the user does not exist",
+			mock: func() {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta(queryUsersFindIDForUpdate)).
+					WithArgs(uint64(1)).
+					WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			wantsErr: models.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			err := repo.UpdateProfile(context.Background(), 1, profile, updatedAt)
+			if !errors.Is(err, tc.wantsErr) {
+				t.Errorf("UpdateProfile() error = %v, wantsErr = %v", err, tc.wantsErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("UpdateProfile() unmet expectations - %v", err)
+			}
+		})
+	}
+}
+
+func TestUserRepo_Search(t *testing.T) {
+	db, mock := mockdb.NewMock()
+	defer func(db *sqlx.DB) {
+		_ = db.Close()
+	}(db)
+
+	repo := NewUserRepository(db)
+	columns := []string{"id", "username", "display_name", "created_at", "updated_at"}
+	now := time.Now()
+
+	testCases := []struct {
+		name  string
+		query string
+		mock  func()
+		wants int
+	}{
+		{
+			name:  "lists every user if the query is empty",
+			query: "",
+			mock: func() {
+				mock.ExpectQuery(regexp.QuoteMeta(queryUsersFindAll)).
+					WithArgs(uint64(5), 3).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(6, "jay", "", now, now).
+						AddRow(7, "wambugu", "", now, now))
+			},
+			wants: 2,
+		},
+		{
+			name:  "escapes the wildcards in the query",
+			query: `50%_off\`,
+			mock: func() {
+				pattern := `50\%\_off\\%`
+
+				mock.ExpectQuery(regexp.QuoteMeta(queryUsersSearch)).
+					WithArgs(pattern, uint64(5), 3, pattern, uint64(5), 3, 3).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wants: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mock()
+
+			got, err := repo.Search(context.Background(), tc.query, pagination.Params{After: 5, Limit: 2})
+			if err != nil {
+				t.Fatalf("Search() unexpected error - %v", err)
+			}
+
+			if len(got) != tc.wants {
+				t.Errorf("Search() returned %d users, wants %d", len(got), tc.wants)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Search() unmet expectations - %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package chatroom
+
+import "chatapp/pkg/models"
+
+// Permission is an action a models.ChatRoomMember can perform on a models.ChatRoom
+type Permission string
+
+const (
+	// PermissionDelete allows deleting the chat room
+	PermissionDelete Permission = "delete"
+
+	// PermissionRename allows changing the chat room name
+	PermissionRename Permission = "rename"
+
+	// PermissionInvite allows adding other users to the chat room
+	PermissionInvite Permission = "invite"
+
+	// PermissionKick allows removing less privileged members from the chat room
+	PermissionKick Permission = "kick"
+
+	// PermissionManageRoles allows changing the roles of the other members
+	PermissionManageRoles Permission = "manage_roles"
+
+	// PermissionDeleteMessages allows deleting the messages other members have sent
+	PermissionDeleteMessages Permission = "delete_messages"
+)
+
+// rolePermissions lists what each models.ChatRoomRole is allowed to do
+var rolePermissions = map[models.ChatRoomRole]map[Permission]bool{
+	models.ChatRoomRoleOwner: {
+		PermissionDelete:         true,
+		PermissionRename:         true,
+		PermissionInvite:         true,
+		PermissionKick:           true,
+		PermissionManageRoles:    true,
+		PermissionDeleteMessages: true,
+	},
+	models.ChatRoomRoleAdmin: {
+		PermissionRename:         true,
+		PermissionInvite:         true,
+		PermissionKick:           true,
+		PermissionDeleteMessages: true,
+	},
+	models.ChatRoomRoleMember: {},
+}
+
+// Can checks if the role has been granted the permission
+func Can(role models.ChatRoomRole, permission Permission) bool {
+	return rolePermissions[role][permission]
+}
@@ -8,9 +8,25 @@ import (
 // Repository provides an interface for interacting with the database.
 type Repository interface {
 	Create(ctx context.Context, room *models.ChatRoom) (*models.ChatRoom, error)
+	CreateWithMembers(ctx context.Context, room *models.ChatRoom, userIDs []uint64) (*models.ChatRoom, error)
 	FindByID(ctx context.Context, id uint64) (*models.ChatRoom, error)
 	FindByUUID(ctx context.Context, uuid string) (*models.ChatRoom, error)
-	CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error)
+	FindByMemberKey(ctx context.Context, memberKey string) (*models.ChatRoom, error)
+	Exists(ctx context.Context, filters ...models.Filter) (bool, error)
 	SoftDelete(ctx context.Context, id uint64) error
-	GetUserChatRooms(ctx context.Context, userID uint64) ([]models.ChatRoom, error)
+	GetUserChatRooms(ctx context.Context, userID uint64, kind models.ChatRoomKind) ([]models.ChatRoom, error)
+	GetUserDirectChatRooms(ctx context.Context, userID uint64) ([]models.DirectChatRoom, error)
+	AddMember(ctx context.Context, chatRoomID, userID uint64, role models.ChatRoomRole) error
+	AddGroupMembers(ctx context.Context, chatRoomID uint64, add func(current []uint64) ([]uint64, error)) error
+	RemoveMember(ctx context.Context, chatRoomID, userID uint64) error
+	GetMembers(ctx context.Context, chatRoomID uint64) ([]models.ChatRoomMember, error)
+	GetMember(ctx context.Context, chatRoomID, userID uint64) (*models.ChatRoomMember, error)
+	UpdateMemberRole(ctx context.Context, chatRoomID, userID uint64, role models.ChatRoomRole) error
+	UpdateName(ctx context.Context, id uint64, name string) error
+	// RemoveGroupMember removes the member if check allows the members who would remain and updates the member key
+	RemoveGroupMember(ctx context.Context, chatRoomID, userID uint64, check func(remaining []uint64) error) error
+	ConvertToRoom(ctx context.Context, id, userID uint64, name string) error
+	IsMember(ctx context.Context, chatRoomID, userID uint64) (bool, error)
+	CreateInvite(ctx context.Context, invite *models.ChatRoomInvite) (*models.ChatRoomInvite, error)
+	RedeemInvite(ctx context.Context, tokenHash string, userID uint64) (*models.ChatRoomInvite, error)
 }
@@ -2,12 +2,49 @@ package chatroom
 
 import (
 	"chatapp/pkg/models"
+	"chatapp/pkg/util"
 	"context"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	// inviteTokenBytes is the number of random bytes used to create an invite token
+	inviteTokenBytes = 24
+
+	// minGroupMembers is the smallest group, two users have a direct conversation instead
+	minGroupMembers = 3
+)
+
+var (
+	// ErrChatRoomNotPrivate is returned when creating an invite for a chat room anyone can join
+	ErrChatRoomNotPrivate = errors.New("chatroom: invites can only be created for private chat rooms")
+
+	// ErrDirectWithSelf is returned when a user tries to start a direct conversation with themselves
+	ErrDirectWithSelf = errors.New("chatroom: cannot start a direct conversation with yourself")
+
+	// ErrGroupTooSmall is returned when a group would have less than minGroupMembers members
+	ErrGroupTooSmall = errors.New("chatroom: a group needs at least two other members")
+
+	// ErrGroupTooLarge is returned when a group would have more members than allowed
+	ErrGroupTooLarge = errors.New("chatroom: the group has too many members")
+
+	// ErrGroupExists is returned when adding members to a group would make it the same as another group
+	ErrGroupExists = errors.New("chatroom: a group with these members already exists")
+
+	// ErrNotGroup is returned when a group action is performed on another kind of chat room
+	ErrNotGroup = errors.New("chatroom: the chat room is not a group")
+
+	// errNoNewMembers stops adding group members when every user is already a member, it is reported as a
+	// models.ErrDuplicateRecord so it is not mistaken for another group having the member key
+	errNoNewMembers = errors.New("chatroom: the users are already members")
 )
 
 // service allows interaction with the Repository
 type service struct {
-	repo Repository
+	repo            Repository
+	groupMaxMembers int
 }
 
 // Create adds a new models.ChatRoom
@@ -15,6 +52,163 @@ func (s *service) Create(ctx context.Context, room *models.ChatRoom) (*models.Ch
 	return s.repo.Create(ctx, room)
 }
 
+// findOrCreateConversation returns the models.ChatRoom with the member key of the kind provided, it is created with
+// the users as its members if it does not exist. The bool reports whether it was created.
+func (s *service) findOrCreateConversation(ctx context.Context, kind models.ChatRoomKind, memberKey string,
+	userID uint64, userIDs []uint64) (*models.ChatRoom, bool, error) {
+	room, err := s.repo.FindByMemberKey(ctx, memberKey)
+	if err == nil {
+		return room, false, nil
+	}
+
+	if !errors.Is(err, models.ErrNoRecord) {
+		return nil, false, err
+	}
+
+	roomUUID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+
+	room, err = s.repo.CreateWithMembers(ctx, &models.ChatRoom{
+		UUID:      roomUUID,
+		Kind:      kind,
+		MemberKey: memberKey,
+		IsPrivate: true,
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, userIDs)
+
+	if err != nil {
+		// Another member started the conversation at the same time
+		if errors.Is(err, models.ErrDuplicateRecord) {
+			room, err = s.repo.FindByMemberKey(ctx, memberKey)
+			return room, false, err
+		}
+
+		return nil, false, err
+	}
+
+	return room, true, nil
+}
+
+// FindOrCreateDirect returns the direct models.ChatRoom the two users share, it is created the first time either of
+// them starts the conversation. The bool reports whether it was created.
+func (s *service) FindOrCreateDirect(ctx context.Context, userID, participantID uint64) (*models.ChatRoom, bool,
+	error) {
+	if userID == participantID {
+		return nil, false, ErrDirectWithSelf
+	}
+
+	return s.findOrCreateConversation(ctx, models.ChatRoomKindDirect, models.DirectMemberKey(userID, participantID),
+		userID, []uint64{userID, participantID})
+}
+
+// uniqueUserIDs returns the ids without duplicates in the order they were first listed
+func uniqueUserIDs(userIDs ...uint64) []uint64 {
+	seen := make(map[uint64]bool, len(userIDs))
+	unique := make([]uint64, 0, len(userIDs))
+
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
+// checkGroupSize makes sure a group with the number of members provided is neither a direct conversation nor
+// larger than the configured maximum
+func (s *service) checkGroupSize(members int) error {
+	if members < minGroupMembers {
+		return ErrGroupTooSmall
+	}
+
+	if members > s.groupMaxMembers {
+		return ErrGroupTooLarge
+	}
+
+	return nil
+}
+
+// FindOrCreateGroup returns the group models.ChatRoom the user shares with exactly the users provided, it is created
+// the first time any of them starts the conversation. The bool reports whether it was created.
+func (s *service) FindOrCreateGroup(ctx context.Context, userID uint64, userIDs []uint64) (*models.ChatRoom, bool,
+	error) {
+	members := uniqueUserIDs(append([]uint64{userID}, userIDs...)...)
+
+	if err := s.checkGroupSize(len(members)); err != nil {
+		return nil, false, err
+	}
+
+	return s.findOrCreateConversation(ctx, models.ChatRoomKindGroup, models.GroupMemberKey(members), userID, members)
+}
+
+// AddGroupMembers adds the users to the group models.ChatRoom if the user is one of its members. The users who are
+// already members are skipped. ErrGroupExists is returned if another group has the resulting members.
+func (s *service) AddGroupMembers(ctx context.Context, room *models.ChatRoom, userID uint64, userIDs []uint64) error {
+	if room.Kind != models.ChatRoomKindGroup {
+		return ErrNotGroup
+	}
+
+	err := s.repo.AddGroupMembers(ctx, room.ID, func(current []uint64) ([]uint64, error) {
+		isMember := false
+		for _, id := range current {
+			if id == userID {
+				isMember = true
+				break
+			}
+		}
+
+		if !isMember {
+			return nil, models.ErrForbidden
+		}
+
+		members := uniqueUserIDs(append(current, userIDs...)...)
+		if len(members) == len(current) {
+			return nil, errNoNewMembers
+		}
+
+		if err := s.checkGroupSize(len(members)); err != nil {
+			return nil, err
+		}
+
+		return members[len(current):], nil
+	})
+
+	switch {
+	case errors.Is(err, errNoNewMembers):
+		return models.ErrDuplicateRecord
+	case errors.Is(err, models.ErrDuplicateRecord):
+		return ErrGroupExists
+	default:
+		return err
+	}
+}
+
+// ConvertGroup turns the group models.ChatRoom into a named private room. The member who converts it becomes its
+// owner and the other members keep their role.
+func (s *service) ConvertGroup(ctx context.Context, room *models.ChatRoom, userID uint64, name string) error {
+	if room.Kind != models.ChatRoomKindGroup {
+		return ErrNotGroup
+	}
+
+	if _, err := s.repo.GetMember(ctx, room.ID, userID); err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return models.ErrForbidden
+		}
+
+		return err
+	}
+
+	return s.repo.ConvertToRoom(ctx, room.ID, userID, name)
+}
+
 // FindByID fetches a models.ChatRoom using the id provided
 func (s *service) FindByID(ctx context.Context, id uint64) (*models.ChatRoom, error) {
 	return s.repo.FindByID(ctx, id)
@@ -25,34 +219,248 @@ func (s *service) FindByUUID(ctx context.Context, uuid string) (*models.ChatRoom
 	return s.repo.FindByUUID(ctx, uuid)
 }
 
-// CheckIfExists looks up if a given column exists
-func (s *service) CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error) {
-	return s.repo.CheckIfExists(ctx, column, value)
+// Exists checks if a models.ChatRoom matching every filter exists, it is used to check unique fields are not taken
+func (s *service) Exists(ctx context.Context, filters ...models.Filter) (bool, error) {
+	return s.repo.Exists(ctx, filters...)
 }
 
-// SoftDelete marks the given models.ChatRoom as deleted
-func (s service) SoftDelete(ctx context.Context, id uint64) error {
+// Authorize returns the models.ChatRoomMember for the user if their role has been granted the permission.
+// models.ErrForbidden is returned if the user is not a member or their role lacks the permission.
+func (s *service) Authorize(ctx context.Context, chatRoomID, userID uint64, permission Permission) (
+	*models.ChatRoomMember, error) {
+	member, err := s.repo.GetMember(ctx, chatRoomID, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, models.ErrForbidden
+		}
+
+		return nil, err
+	}
+
+	if !Can(member.Role, permission) {
+		return nil, models.ErrForbidden
+	}
+
+	return member, nil
+}
+
+// SoftDelete marks the given models.ChatRoom as deleted if the user is allowed to
+func (s *service) SoftDelete(ctx context.Context, id, userID uint64) error {
+	if _, err := s.Authorize(ctx, id, userID, PermissionDelete); err != nil {
+		return err
+	}
+
 	return s.repo.SoftDelete(ctx, id)
 }
 
-// GetUserChatRooms returns  []models.ChatRoom for the models.User
-func (s *service) GetUserChatRooms(ctx context.Context, userID uint64) ([]models.ChatRoom, error) {
-	return s.repo.GetUserChatRooms(ctx, userID)
+// Rename changes the models.ChatRoom name if the user is allowed to
+func (s *service) Rename(ctx context.Context, id, userID uint64, name string) error {
+	if _, err := s.Authorize(ctx, id, userID, PermissionRename); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateName(ctx, id, name)
+}
+
+// GetUserChatRooms returns  []models.ChatRoom of the kind provided for the models.User
+func (s *service) GetUserChatRooms(ctx context.Context, userID uint64, kind models.ChatRoomKind) (
+	[]models.ChatRoom, error) {
+	return s.repo.GetUserChatRooms(ctx, userID, kind)
+}
+
+// GetUserDirectChatRooms returns the []models.DirectChatRoom the models.User is a participant of
+func (s *service) GetUserDirectChatRooms(ctx context.Context, userID uint64) ([]models.DirectChatRoom, error) {
+	return s.repo.GetUserDirectChatRooms(ctx, userID)
+}
+
+// CanView checks if the models.User can see the models.ChatRoom. Private rooms are only visible to their members.
+func (s *service) CanView(ctx context.Context, room *models.ChatRoom, userID uint64) (bool, error) {
+	if !room.IsPrivate {
+		return true, nil
+	}
+
+	return s.repo.IsMember(ctx, room.ID, userID)
+}
+
+// AddMember adds the models.User to the models.ChatRoom members. Private rooms can only be joined using an invite.
+func (s *service) AddMember(ctx context.Context, room *models.ChatRoom, userID uint64) error {
+	if room.IsPrivate {
+		return models.ErrForbidden
+	}
+
+	return s.repo.AddMember(ctx, room.ID, userID, models.ChatRoomRoleMember)
+}
+
+// RemoveMember removes the models.User from the models.ChatRoom members. The owner cannot leave their room and
+// neither participant can leave a direct conversation. A group cannot shrink below minGroupMembers, it keeps the member
+// key of the members who remain unless another group has the same members.
+func (s *service) RemoveMember(ctx context.Context, room *models.ChatRoom, userID uint64) error {
+	if room.Kind == models.ChatRoomKindDirect {
+		return models.ErrForbidden
+	}
+
+	member, err := s.repo.GetMember(ctx, room.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == models.ChatRoomRoleOwner {
+		return models.ErrForbidden
+	}
+
+	if room.Kind != models.ChatRoomKindGroup {
+		return s.repo.RemoveMember(ctx, room.ID, userID)
+	}
+
+	return s.repo.RemoveGroupMember(ctx, room.ID, userID, func(remaining []uint64) error {
+		if len(remaining) < minGroupMembers {
+			return ErrGroupTooSmall
+		}
+
+		return nil
+	})
+}
+
+// InviteMember adds another models.User to the models.ChatRoom members if the inviter is allowed to
+func (s *service) InviteMember(ctx context.Context, chatRoomID, inviterID, userID uint64) error {
+	if _, err := s.Authorize(ctx, chatRoomID, inviterID, PermissionInvite); err != nil {
+		return err
+	}
+
+	return s.repo.AddMember(ctx, chatRoomID, userID, models.ChatRoomRoleMember)
+}
+
+// KickMember removes a less privileged models.ChatRoomMember if the kicker is allowed to
+func (s *service) KickMember(ctx context.Context, chatRoomID, kickerID, userID uint64) error {
+	kicker, err := s.Authorize(ctx, chatRoomID, kickerID, PermissionKick)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.repo.GetMember(ctx, chatRoomID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !kicker.Role.Outranks(member.Role) {
+		return models.ErrForbidden
+	}
+
+	return s.repo.RemoveMember(ctx, chatRoomID, userID)
+}
+
+// UpdateMemberRole changes the role of a models.ChatRoomMember if the user is allowed to.
+// Ownership cannot be granted or taken away.
+func (s *service) UpdateMemberRole(ctx context.Context, chatRoomID, userID, memberID uint64,
+	role models.ChatRoomRole) error {
+	if _, err := s.Authorize(ctx, chatRoomID, userID, PermissionManageRoles); err != nil {
+		return err
+	}
+
+	if role == models.ChatRoomRoleOwner {
+		return models.ErrForbidden
+	}
+
+	member, err := s.repo.GetMember(ctx, chatRoomID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == models.ChatRoomRoleOwner {
+		return models.ErrForbidden
+	}
+
+	return s.repo.UpdateMemberRole(ctx, chatRoomID, memberID, role)
+}
+
+// CreateInvite creates a models.ChatRoomInvite for the private models.ChatRoom if the user is allowed to invite.
+// The plain text token is only available on the returned invite, only its hash is stored.
+func (s *service) CreateInvite(ctx context.Context, room *models.ChatRoom, userID uint64,
+	invite *models.ChatRoomInvite) (*models.ChatRoomInvite, error) {
+	if _, err := s.Authorize(ctx, room.ID, userID, PermissionInvite); err != nil {
+		return nil, err
+	}
+
+	if !room.IsPrivate {
+		return nil, ErrChatRoomNotPrivate
+	}
+
+	token, err := util.GenerateRandomToken(inviteTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := models.DefaultChatRoomInviteExpiry
+	if invite.ExpiresIn > 0 {
+		expiresIn = time.Duration(invite.ExpiresIn) * time.Second
+	}
+
+	now := time.Now()
+
+	invite.ChatRoomID = room.ID
+	invite.UserID = userID
+	invite.Token = token
+	invite.TokenHash = util.HashToken(token)
+	invite.Uses = 0
+	invite.ExpiresAt = now.Add(expiresIn)
+	invite.CreatedAt = now
+
+	return s.repo.CreateInvite(ctx, invite)
+}
+
+// RedeemInvite makes the models.User a member of the models.ChatRoom the invite token was created for
+func (s *service) RedeemInvite(ctx context.Context, token string, userID uint64) (*models.ChatRoomInvite, error) {
+	return s.repo.RedeemInvite(ctx, util.HashToken(token), userID)
+}
+
+// GetMembers returns []models.ChatRoomMember for the models.ChatRoom
+func (s *service) GetMembers(ctx context.Context, chatRoomID uint64) ([]models.ChatRoomMember, error) {
+	return s.repo.GetMembers(ctx, chatRoomID)
+}
+
+// GetMember fetches the models.ChatRoomMember for the models.User
+func (s *service) GetMember(ctx context.Context, chatRoomID, userID uint64) (*models.ChatRoomMember, error) {
+	return s.repo.GetMember(ctx, chatRoomID, userID)
+}
+
+// IsMember checks if the models.User has joined the models.ChatRoom
+func (s *service) IsMember(ctx context.Context, chatRoomID, userID uint64) (bool, error) {
+	return s.repo.IsMember(ctx, chatRoomID, userID)
 }
 
 // Service provides an interface for interacting with the repository
 type Service interface {
 	Create(ctx context.Context, room *models.ChatRoom) (*models.ChatRoom, error)
+	FindOrCreateDirect(ctx context.Context, userID, participantID uint64) (*models.ChatRoom, bool, error)
+	FindOrCreateGroup(ctx context.Context, userID uint64, userIDs []uint64) (*models.ChatRoom, bool, error)
+	AddGroupMembers(ctx context.Context, room *models.ChatRoom, userID uint64, userIDs []uint64) error
+	ConvertGroup(ctx context.Context, room *models.ChatRoom, userID uint64, name string) error
 	FindByID(ctx context.Context, id uint64) (*models.ChatRoom, error)
 	FindByUUID(ctx context.Context, uuid string) (*models.ChatRoom, error)
-	CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error)
-	SoftDelete(ctx context.Context, id uint64) error
-	GetUserChatRooms(ctx context.Context, userID uint64) ([]models.ChatRoom, error)
+	Exists(ctx context.Context, filters ...models.Filter) (bool, error)
+	Authorize(ctx context.Context, chatRoomID, userID uint64, permission Permission) (*models.ChatRoomMember, error)
+	SoftDelete(ctx context.Context, id, userID uint64) error
+	Rename(ctx context.Context, id, userID uint64, name string) error
+	GetUserChatRooms(ctx context.Context, userID uint64, kind models.ChatRoomKind) ([]models.ChatRoom, error)
+	GetUserDirectChatRooms(ctx context.Context, userID uint64) ([]models.DirectChatRoom, error)
+	CanView(ctx context.Context, room *models.ChatRoom, userID uint64) (bool, error)
+	AddMember(ctx context.Context, room *models.ChatRoom, userID uint64) error
+	RemoveMember(ctx context.Context, room *models.ChatRoom, userID uint64) error
+	InviteMember(ctx context.Context, chatRoomID, inviterID, userID uint64) error
+	KickMember(ctx context.Context, chatRoomID, kickerID, userID uint64) error
+	UpdateMemberRole(ctx context.Context, chatRoomID, userID, memberID uint64, role models.ChatRoomRole) error
+	CreateInvite(ctx context.Context, room *models.ChatRoom, userID uint64, invite *models.ChatRoomInvite) (
+		*models.ChatRoomInvite, error)
+	RedeemInvite(ctx context.Context, token string, userID uint64) (*models.ChatRoomInvite, error)
+	GetMembers(ctx context.Context, chatRoomID uint64) ([]models.ChatRoomMember, error)
+	GetMember(ctx context.Context, chatRoomID, userID uint64) (*models.ChatRoomMember, error)
+	IsMember(ctx context.Context, chatRoomID, userID uint64) (bool, error)
 }
 
-// NewService creates a new Service
-func NewService(repo Repository) Service {
+// NewService creates a new Service, groups can have up to groupMaxMembers members
+func NewService(repo Repository, groupMaxMembers int) Service {
 	return &service{
-		repo: repo,
+		repo:            repo,
+		groupMaxMembers: groupMaxMembers,
 	}
 }
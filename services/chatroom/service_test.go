@@ -0,0 +1,502 @@
+package chatroom
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// stubRepository keeps the chat room members in memory
+type stubRepository struct {
+	Repository
+	members map[uint64]models.ChatRoomRole
+	removed []uint64
+	deleted bool
+}
+
+func (r *stubRepository) GetMember(_ context.Context, chatRoomID, userID uint64) (*models.ChatRoomMember, error) {
+	role, ok := r.members[userID]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	return &models.ChatRoomMember{ChatRoomID: chatRoomID, UserID: userID, Role: role}, nil
+}
+
+func (r *stubRepository) RemoveMember(_ context.Context, _, userID uint64) error {
+	r.removed = append(r.removed, userID)
+	return nil
+}
+
+func (r *stubRepository) SoftDelete(context.Context, uint64) error {
+	r.deleted = true
+	return nil
+}
+
+func (r *stubRepository) UpdateMemberRole(_ context.Context, _, userID uint64, role models.ChatRoomRole) error {
+	r.members[userID] = role
+	return nil
+}
+
+const (
+	ownerID uint64 = iota + 1
+	adminID
+	memberID
+	otherMemberID
+	strangerID
+)
+
+func newStubRepository() *stubRepository {
+	return &stubRepository{
+		members: map[uint64]models.ChatRoomRole{
+			ownerID:       models.ChatRoomRoleOwner,
+			adminID:       models.ChatRoomRoleAdmin,
+			memberID:      models.ChatRoomRoleMember,
+			otherMemberID: models.ChatRoomRoleMember,
+		},
+	}
+}
+
+func TestService_SoftDelete(t *testing.T) {
+	testCases := []struct {
+		name     string
+		userID   uint64
+		wantsErr error
+	}{
+		{name: "owner can delete the chat room", userID: ownerID},
+		{name: "admin cannot delete the chat room", userID: adminID, wantsErr: models.ErrForbidden},
+		{name: "member cannot delete the chat room", userID: memberID, wantsErr: models.ErrForbidden},
+		{name: "non member cannot delete the chat room", userID: strangerID, wantsErr: models.ErrForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newStubRepository()
+			err := NewService(repo, 5).SoftDelete(context.Background(), 1, tc.userID)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				assert.False(t, repo.deleted)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, repo.deleted)
+		})
+	}
+}
+
+func TestService_KickMember(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kickerID uint64
+		userID   uint64
+		wantsErr error
+	}{
+		{name: "owner can kick an admin", kickerID: ownerID, userID: adminID},
+		{name: "admin can kick a member", kickerID: adminID, userID: memberID},
+		{name: "admin cannot kick the owner", kickerID: adminID, userID: ownerID, wantsErr: models.ErrForbidden},
+		{name: "owner cannot kick themselves", kickerID: ownerID, userID: ownerID, wantsErr: models.ErrForbidden},
+		{name: "member cannot kick a member", kickerID: memberID, userID: otherMemberID, wantsErr: models.ErrForbidden},
+		{name: "kicking a non member fails", kickerID: ownerID, userID: strangerID, wantsErr: models.ErrNoRecord},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newStubRepository()
+			err := NewService(repo, 5).KickMember(context.Background(), 1, tc.kickerID, tc.userID)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				assert.Empty(t, repo.removed)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, []uint64{tc.userID}, repo.removed)
+		})
+	}
+}
+
+func TestService_UpdateMemberRole(t *testing.T) {
+	testCases := []struct {
+		name     string
+		userID   uint64
+		memberID uint64
+		role     models.ChatRoomRole
+		wantsErr error
+	}{
+		{name: "owner can promote a member to admin", userID: ownerID, memberID: memberID, role: models.ChatRoomRoleAdmin},
+		{name: "owner can demote an admin", userID: ownerID, memberID: adminID, role: models.ChatRoomRoleMember},
+		{
+			name:     "owner cannot grant ownership",
+			userID:   ownerID,
+			memberID: adminID,
+			role:     models.ChatRoomRoleOwner,
+			wantsErr: models.ErrForbidden,
+		},
+		{
+			name:     "admin cannot change roles",
+			userID:   adminID,
+			memberID: memberID,
+			role:     models.ChatRoomRoleAdmin,
+			wantsErr: models.ErrForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newStubRepository()
+			err := NewService(repo, 5).UpdateMemberRole(context.Background(), 1, tc.userID, tc.memberID, tc.role)
+
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.role, repo.members[tc.memberID])
+		})
+	}
+}
+
+func TestService_RemoveMember_OwnerCannotLeave(t *testing.T) {
+	repo := newStubRepository()
+	svc := NewService(repo, 5)
+
+	room := &models.ChatRoom{ID: 1, Kind: models.ChatRoomKindRoom}
+
+	assert.ErrorIs(t, svc.RemoveMember(context.Background(), room, ownerID), models.ErrForbidden)
+	assert.NoError(t, svc.RemoveMember(context.Background(), room, memberID))
+	assert.Equal(t, []uint64{memberID}, repo.removed)
+}
+
+func TestService_RemoveMember_CannotLeaveDirect(t *testing.T) {
+	repo := newStubRepository()
+	room := &models.ChatRoom{ID: 1, Kind: models.ChatRoomKindDirect}
+
+	assert.ErrorIs(t, NewService(repo, 5).RemoveMember(context.Background(), room, memberID), models.ErrForbidden)
+	assert.Empty(t, repo.removed)
+}
+
+// directRepository keeps the direct chat rooms in memory by member key
+type directRepository struct {
+	Repository
+	rooms map[string]*models.ChatRoom
+	// raced creates the room when CreateWithMembers is called as if the other user had just started the conversation
+	raced bool
+}
+
+func (r *directRepository) FindByMemberKey(_ context.Context, memberKey string) (*models.ChatRoom, error) {
+	room, ok := r.rooms[memberKey]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	return room, nil
+}
+
+func (r *directRepository) CreateWithMembers(_ context.Context, room *models.ChatRoom, userIDs []uint64) (
+	*models.ChatRoom, error) {
+	if _, ok := r.rooms[room.MemberKey]; ok || r.raced {
+		r.rooms[room.MemberKey] = &models.ChatRoom{ID: 1, MemberKey: room.MemberKey}
+		return nil, models.ErrDuplicateRecord
+	}
+
+	room.ID = uint64(len(r.rooms) + 1)
+	room.UsersCount = uint(len(userIDs))
+	r.rooms[room.MemberKey] = room
+
+	return room, nil
+}
+
+func TestService_FindOrCreateDirect(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates the direct chat room once whichever user starts it", func(t *testing.T) {
+		repo := &directRepository{rooms: make(map[string]*models.ChatRoom)}
+		svc := NewService(repo, 5)
+
+		room, created, err := svc.FindOrCreateDirect(ctx, memberID, otherMemberID)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, models.ChatRoomKindDirect, room.Kind)
+		assert.True(t, room.IsPrivate)
+		assert.Equal(t, uint(2), room.UsersCount)
+
+		found, created, err := svc.FindOrCreateDirect(ctx, otherMemberID, memberID)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, room.ID, found.ID)
+		assert.Len(t, repo.rooms, 1)
+	})
+
+	t.Run("returns the chat room created by the other user at the same time", func(t *testing.T) {
+		repo := &directRepository{rooms: make(map[string]*models.ChatRoom), raced: true}
+
+		room, created, err := NewService(repo, 5).FindOrCreateDirect(ctx, memberID, otherMemberID)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, models.DirectMemberKey(otherMemberID, memberID), room.MemberKey)
+	})
+
+	t.Run("fails to start a direct conversation with yourself", func(t *testing.T) {
+		repo := &directRepository{rooms: make(map[string]*models.ChatRoom)}
+
+		_, _, err := NewService(repo, 5).FindOrCreateDirect(ctx, memberID, memberID)
+		assert.ErrorIs(t, err, ErrDirectWithSelf)
+		assert.Empty(t, repo.rooms)
+	})
+}
+
+// groupRepository keeps a single group chat room and its members in memory
+type groupRepository struct {
+	Repository
+	room    *models.ChatRoom
+	members []uint64
+	// takenKeys are the member keys of the other groups
+	takenKeys map[string]bool
+}
+
+func newGroupRepository(members ...uint64) *groupRepository {
+	return &groupRepository{
+		room: &models.ChatRoom{
+			ID:        1,
+			Kind:      models.ChatRoomKindGroup,
+			MemberKey: models.GroupMemberKey(members),
+		},
+		members:   members,
+		takenKeys: make(map[string]bool),
+	}
+}
+
+func (r *groupRepository) GetMembers(_ context.Context, chatRoomID uint64) ([]models.ChatRoomMember, error) {
+	members := make([]models.ChatRoomMember, len(r.members))
+	for i, id := range r.members {
+		members[i] = models.ChatRoomMember{ChatRoomID: chatRoomID, UserID: id, Role: models.ChatRoomRoleMember}
+	}
+
+	return members, nil
+}
+
+func (r *groupRepository) GetMember(_ context.Context, chatRoomID, userID uint64) (*models.ChatRoomMember, error) {
+	for _, id := range r.members {
+		if id == userID {
+			return &models.ChatRoomMember{ChatRoomID: chatRoomID, UserID: id, Role: models.ChatRoomRoleMember}, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *groupRepository) AddGroupMembers(_ context.Context, _ uint64,
+	add func(current []uint64) ([]uint64, error)) error {
+	userIDs, err := add(append([]uint64{}, r.members...))
+	if err != nil {
+		return err
+	}
+
+	members := append(append([]uint64{}, r.members...), userIDs...)
+
+	memberKey := models.GroupMemberKey(members)
+	if r.takenKeys[memberKey] {
+		return models.ErrDuplicateRecord
+	}
+
+	r.members = members
+	r.room.MemberKey = memberKey
+
+	return nil
+}
+
+func (r *groupRepository) RemoveMember(_ context.Context, _, userID uint64) error {
+	for i, id := range r.members {
+		if id == userID {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			return nil
+		}
+	}
+
+	return models.ErrNoRecord
+}
+
+func (r *groupRepository) RemoveGroupMember(_ context.Context, _, userID uint64,
+	check func(remaining []uint64) error) error {
+	var remaining []uint64
+
+	for _, id := range r.members {
+		if id != userID {
+			remaining = append(remaining, id)
+		}
+	}
+
+	if len(remaining) == len(r.members) {
+		return models.ErrNoRecord
+	}
+
+	if err := check(remaining); err != nil {
+		return err
+	}
+
+	r.members = remaining
+
+	if key := models.GroupMemberKey(remaining); !r.takenKeys[key] {
+		r.room.MemberKey = key
+	} else {
+		r.room.MemberKey = ""
+	}
+
+	return nil
+}
+
+func (r *groupRepository) ConvertToRoom(_ context.Context, _, userID uint64, name string) error {
+	r.room.Kind = models.ChatRoomKindRoom
+	r.room.Name = name
+	r.room.UserID = userID
+	r.room.MemberKey = ""
+
+	return nil
+}
+
+func TestService_FindOrCreateGroup(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		userIDs  []uint64
+		wantsErr error
+	}{
+		{name: "creates the group with the user as a member", userIDs: []uint64{memberID, adminID}},
+		{name: "ignores the duplicate users", userIDs: []uint64{adminID, adminID, memberID, ownerID}},
+		{name: "fails if there is only one other member", userIDs: []uint64{adminID, adminID},
+			wantsErr: ErrGroupTooSmall},
+		{name: "fails if there are too many members", userIDs: []uint64{2, 3, 4, 5, 6}, wantsErr: ErrGroupTooLarge},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &directRepository{rooms: make(map[string]*models.ChatRoom)}
+
+			room, created, err := NewService(repo, 5).FindOrCreateGroup(ctx, ownerID, tc.userIDs)
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				assert.Empty(t, repo.rooms)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, created)
+			assert.Equal(t, models.ChatRoomKindGroup, room.Kind)
+			assert.Equal(t, uint(3), room.UsersCount)
+			assert.Equal(t, models.GroupMemberKey([]uint64{memberID, adminID, ownerID}), room.MemberKey)
+		})
+	}
+
+	t.Run("returns the existing group whichever member starts it", func(t *testing.T) {
+		repo := &directRepository{rooms: make(map[string]*models.ChatRoom)}
+		svc := NewService(repo, 5)
+
+		room, _, err := svc.FindOrCreateGroup(ctx, ownerID, []uint64{adminID, memberID})
+		assert.NoError(t, err)
+
+		found, created, err := svc.FindOrCreateGroup(ctx, memberID, []uint64{ownerID, adminID})
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, room.ID, found.ID)
+	})
+}
+
+func TestService_AddGroupMembers(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("adds the new members and updates the member key", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+
+		err := NewService(repo, 5).AddGroupMembers(ctx, repo.room, memberID, []uint64{adminID, otherMemberID})
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{ownerID, adminID, memberID, otherMemberID}, repo.members)
+		assert.Equal(t, models.GroupMemberKey(repo.members), repo.room.MemberKey)
+	})
+
+	t.Run("fails if another group has the same members", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+		repo.takenKeys[models.GroupMemberKey([]uint64{ownerID, adminID, memberID, otherMemberID})] = true
+
+		err := NewService(repo, 5).AddGroupMembers(ctx, repo.room, memberID, []uint64{otherMemberID})
+		assert.ErrorIs(t, err, ErrGroupExists)
+	})
+
+	t.Run("fails if the users are already members", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+
+		err := NewService(repo, 5).AddGroupMembers(ctx, repo.room, memberID, []uint64{adminID})
+		assert.ErrorIs(t, err, models.ErrDuplicateRecord)
+	})
+
+	t.Run("fails if the group would have too many members", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+
+		err := NewService(repo, 4).AddGroupMembers(ctx, repo.room, memberID, []uint64{otherMemberID, strangerID})
+		assert.ErrorIs(t, err, ErrGroupTooLarge)
+		assert.Len(t, repo.members, 3)
+	})
+
+	t.Run("fails if the user is not a member", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+
+		err := NewService(repo, 5).AddGroupMembers(ctx, repo.room, strangerID, []uint64{otherMemberID})
+		assert.ErrorIs(t, err, models.ErrForbidden)
+	})
+
+	t.Run("fails if the chat room is not a group", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+		repo.room.Kind = models.ChatRoomKindRoom
+
+		err := NewService(repo, 5).AddGroupMembers(ctx, repo.room, memberID, []uint64{otherMemberID})
+		assert.ErrorIs(t, err, ErrNotGroup)
+	})
+}
+
+func TestService_RemoveMember_Group(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("updates the member key to the remaining members", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID, otherMemberID)
+
+		assert.NoError(t, NewService(repo, 5).RemoveMember(ctx, repo.room, otherMemberID))
+		assert.Equal(t, models.GroupMemberKey([]uint64{ownerID, adminID, memberID}), repo.room.MemberKey)
+	})
+
+	t.Run("removes the member key if another group has the remaining members", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID, otherMemberID)
+		repo.takenKeys[models.GroupMemberKey([]uint64{ownerID, adminID, memberID})] = true
+
+		assert.NoError(t, NewService(repo, 5).RemoveMember(ctx, repo.room, otherMemberID))
+		assert.Empty(t, repo.room.MemberKey)
+	})
+
+	t.Run("fails if the group would have too few members", func(t *testing.T) {
+		repo := newGroupRepository(ownerID, adminID, memberID)
+		memberKey := repo.room.MemberKey
+
+		err := NewService(repo, 5).RemoveMember(ctx, repo.room, memberID)
+		assert.ErrorIs(t, err, ErrGroupTooSmall)
+		assert.Len(t, repo.members, 3)
+		assert.Equal(t, memberKey, repo.room.MemberKey)
+	})
+}
+
+func TestService_ConvertGroup(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newGroupRepository(ownerID, adminID, memberID)
+	svc := NewService(repo, 5)
+
+	assert.ErrorIs(t, svc.ConvertGroup(ctx, repo.room, strangerID, "General"), models.ErrForbidden)
+	assert.Equal(t, models.ChatRoomKindGroup, repo.room.Kind)
+
+	assert.NoError(t, svc.ConvertGroup(ctx, repo.room, memberID, "General"))
+	assert.Equal(t, models.ChatRoomKindRoom, repo.room.Kind)
+	assert.Equal(t, memberID, repo.room.UserID)
+}
@@ -0,0 +1,15 @@
+package identity
+
+import (
+	"chatapp/pkg/models"
+	"context"
+)
+
+// Repository provides an interface for interacting with the database.
+type Repository interface {
+	FindBySubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	// CreateWithUser adds the models.User and links the models.UserIdentity to them
+	CreateWithUser(ctx context.Context, user *models.User, identity *models.UserIdentity) (*models.UserIdentity, error)
+	// Create links the models.UserIdentity to an existing models.User
+	Create(ctx context.Context, identity *models.UserIdentity) (*models.UserIdentity, error)
+}
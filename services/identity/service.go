@@ -0,0 +1,218 @@
+package identity
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/sso"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// maxUsernameAttempts is the number of usernames tried for a new user before giving up
+	maxUsernameAttempts = 10
+
+	// maxBaseUsernameLength keeps the username taken from the claims short enough to be shown, the suffix added to
+	// make a taken username unique goes on top of it
+	maxBaseUsernameLength = 30
+
+	// usernameSuffixAlphabet is what the random suffix of a taken username is made of
+	usernameSuffixAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	// usernameSuffixLength makes a collision between the suffixes tried for the same username unlikely
+	usernameSuffixLength = 6
+)
+
+var (
+	// ErrUsernameUnavailable is returned when no free username could be found for a new user
+	ErrUsernameUnavailable = errors.New("identity: no username available for the user")
+
+	// ErrIdentityLinked is returned when linking an identity that is already linked to another user
+	ErrIdentityLinked = errors.New("identity: the identity is linked to another user")
+)
+
+// service allows interaction with the Repository
+type service struct {
+	repo Repository
+}
+
+// sanitizeUsername keeps the letters, digits, dots, dashes and underscores of the username in lower case
+func sanitizeUsername(username string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(username) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+
+		if b.Len() == maxBaseUsernameLength {
+			break
+		}
+	}
+
+	return strings.Trim(b.String(), ".-_")
+}
+
+// baseUsername picks the username a new user is created with from their claims, falling back to "user" if the
+// claims have nothing usable
+func baseUsername(claims *sso.Claims) string {
+	candidates := []string{claims.PreferredUsername}
+
+	if i := strings.Index(claims.Email, "@"); i > 0 {
+		candidates = append(candidates, claims.Email[:i])
+	}
+
+	for _, candidate := range candidates {
+		if username := sanitizeUsername(candidate); models.ValidateUsername(username) == nil {
+			return username
+		}
+	}
+
+	return "user"
+}
+
+// usernameSuffix returns a random suffix used to make a taken username unique
+func usernameSuffix() (string, error) {
+	suffix := make([]byte, usernameSuffixLength)
+	max := big.NewInt(int64(len(usernameSuffixAlphabet)))
+
+	for i := range suffix {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("identity.usernameSuffix:: error generating suffix - %v", err)
+		}
+
+		suffix[i] = usernameSuffixAlphabet[n.Int64()]
+	}
+
+	return "_" + string(suffix), nil
+}
+
+// SignIn returns the id of the models.User the provider's identity is linked to. A new user is created the first
+// time the identity signs in, they have no password and can only sign in through the provider until they reset it.
+func (s *service) SignIn(ctx context.Context, provider string, claims *sso.Claims) (uint64, error) {
+	existing, err := s.repo.FindBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		return existing.UserID, nil
+	}
+
+	if !errors.Is(err, models.ErrNoRecord) {
+		return 0, err
+	}
+
+	username := baseUsername(claims)
+
+	for attempt := 0; attempt < maxUsernameAttempts; attempt++ {
+		candidate := username
+
+		// The username from the claims is tried first, a new random suffix is added every time it is taken
+		if attempt > 0 {
+			suffix, err := usernameSuffix()
+			if err != nil {
+				return 0, err
+			}
+
+			candidate += suffix
+		}
+
+		if err := models.ValidateUsername(candidate); err != nil {
+			return 0, fmt.Errorf("identity.SignIn:: invalid username %q - %v", candidate, err)
+		}
+
+		now := time.Now()
+
+		newIdentity, err := s.repo.CreateWithUser(ctx, &models.User{
+			Username:  candidate,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}, &models.UserIdentity{
+			Provider:  provider,
+			Subject:   claims.Subject,
+			Email:     claims.Email,
+			CreatedAt: now,
+		})
+
+		if err == nil {
+			return newIdentity.UserID, nil
+		}
+
+		if !errors.Is(err, models.ErrDuplicateRecord) {
+			return 0, err
+		}
+
+		// The identity may have been linked by a concurrent sign in, otherwise the username is taken
+		existing, err := s.repo.FindBySubject(ctx, provider, claims.Subject)
+		if err == nil {
+			return existing.UserID, nil
+		}
+
+		if !errors.Is(err, models.ErrNoRecord) {
+			return 0, err
+		}
+	}
+
+	return 0, ErrUsernameUnavailable
+}
+
+// Link links the provider's identity to the models.User with the userID, so they can sign in through the provider as
+// well. Linking an identity that is already linked to the user does nothing.
+func (s *service) Link(ctx context.Context, userID uint64, provider string, claims *sso.Claims) (
+	*models.UserIdentity, error) {
+	existing, err := s.repo.FindBySubject(ctx, provider, claims.Subject)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, ErrIdentityLinked
+		}
+
+		return existing, nil
+	}
+
+	if !errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	}
+
+	newIdentity, err := s.repo.Create(ctx, &models.UserIdentity{
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: time.Now(),
+	})
+
+	if err == nil {
+		return newIdentity, nil
+	}
+
+	if !errors.Is(err, models.ErrDuplicateRecord) {
+		return nil, err
+	}
+
+	// The identity was linked by a concurrent sign in or link
+	existing, err = s.repo.FindBySubject(ctx, provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.UserID != userID {
+		return nil, ErrIdentityLinked
+	}
+
+	return existing, nil
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	SignIn(ctx context.Context, provider string, claims *sso.Claims) (uint64, error)
+	Link(ctx context.Context, userID uint64, provider string, claims *sso.Claims) (*models.UserIdentity, error)
+}
+
+// NewService creates a new Service
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
@@ -0,0 +1,155 @@
+package identity
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/sso"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// stubRepository keeps the users and identities in memory
+type stubRepository struct {
+	usernames  map[string]uint64
+	identities []*models.UserIdentity
+}
+
+func newStubRepository() *stubRepository {
+	return &stubRepository{
+		usernames: make(map[string]uint64),
+	}
+}
+
+func (r *stubRepository) FindBySubject(_ context.Context, provider, subject string) (*models.UserIdentity, error) {
+	for _, userIdentity := range r.identities {
+		if userIdentity.Provider == provider && userIdentity.Subject == subject {
+			return userIdentity, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) CreateWithUser(_ context.Context, user *models.User, userIdentity *models.UserIdentity) (
+	*models.UserIdentity, error) {
+	if _, ok := r.usernames[user.Username]; ok {
+		return nil, models.ErrDuplicateRecord
+	}
+
+	user.ID = uint64(len(r.usernames) + 1)
+	r.usernames[user.Username] = user.ID
+
+	userIdentity.ID = uint64(len(r.identities) + 1)
+	userIdentity.UserID = user.ID
+	r.identities = append(r.identities, userIdentity)
+
+	return userIdentity, nil
+}
+
+func (r *stubRepository) Create(_ context.Context, userIdentity *models.UserIdentity) (*models.UserIdentity, error) {
+	if _, err := r.FindBySubject(context.Background(), userIdentity.Provider, userIdentity.Subject); err == nil {
+		return nil, models.ErrDuplicateRecord
+	}
+
+	userIdentity.ID = uint64(len(r.identities) + 1)
+	r.identities = append(r.identities, userIdentity)
+
+	return userIdentity, nil
+}
+
+func TestService_SignIn(t *testing.T) {
+	ctx := context.Background()
+	repo := newStubRepository()
+	svc := NewService(repo)
+
+	claims := &sso.Claims{
+		Subject: "248289761001",
+		Email:   "jay@example.com",
+	}
+
+	userID, err := svc.SignIn(ctx, "company", claims)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, repo.usernames["jay"], "the username is taken from the email")
+
+	again, err := svc.SignIn(ctx, "company", claims)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, again, "the identity signs in as the same user")
+
+	otherUserID, err := svc.SignIn(ctx, "google", claims)
+	assert.NoError(t, err)
+	assert.NotEqual(t, userID, otherUserID, "identities are not linked across providers")
+
+	for username, id := range repo.usernames {
+		if id == otherUserID {
+			assert.True(t, strings.HasPrefix(username, "jay_"), "a taken username gets a suffix")
+			assert.Len(t, username, len("jay_")+usernameSuffixLength)
+		}
+	}
+}
+
+func TestBaseUsername(t *testing.T) {
+	testCases := []struct {
+		name   string
+		claims *sso.Claims
+		wants  string
+	}{
+		{
+			name:   "uses the preferred username",
+			claims: &sso.Claims{PreferredUsername: "Jay.Doe", Email: "jay@example.com"},
+			wants:  "jay.doe",
+		},
+		{
+			name:   "removes the characters usernames cannot have",
+			claims: &sso.Claims{PreferredUsername: " <jay doe>/ "},
+			wants:  "jaydoe",
+		},
+		{
+			name:   "uses the email if the preferred username has nothing usable",
+			claims: &sso.Claims{PreferredUsername: "ジェイ", Email: "jay+chat@example.com"},
+			wants:  "jaychat",
+		},
+		{
+			name:   "shortens long usernames",
+			claims: &sso.Claims{PreferredUsername: strings.Repeat("j", 40)},
+			wants:  strings.Repeat("j", maxBaseUsernameLength),
+		},
+		{
+			name:   "falls back to a default username",
+			claims: &sso.Claims{Email: "@example.com"},
+			wants:  "user",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wants, baseUsername(tc.claims))
+		})
+	}
+}
+
+func TestService_Link(t *testing.T) {
+	ctx := context.Background()
+	repo := newStubRepository()
+	svc := NewService(repo)
+
+	claims := &sso.Claims{
+		Subject: "248289761001",
+		Email:   "jay@example.com",
+	}
+
+	linked, err := svc.Link(ctx, 7, "company", claims)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), linked.UserID)
+
+	again, err := svc.Link(ctx, 7, "company", claims)
+	assert.NoError(t, err)
+	assert.Equal(t, linked.ID, again.ID, "linking the same identity again does nothing")
+
+	userID, err := svc.SignIn(ctx, "company", claims)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), userID, "the linked identity signs in as the user")
+
+	_, err = svc.Link(ctx, 8, "company", claims)
+	assert.ErrorIs(t, err, ErrIdentityLinked)
+}
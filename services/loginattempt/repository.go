@@ -0,0 +1,17 @@
+package loginattempt
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"time"
+)
+
+// Repository provides an interface for interacting with the store.
+type Repository interface {
+	Find(ctx context.Context, key string) (*models.LoginAttempt, error)
+	// RecordFailure atomically counts a failed login for the key. The count starts over if the last failure was
+	// before resetBefore.
+	RecordFailure(ctx context.Context, key string, failedAt, resetBefore time.Time) (*models.LoginAttempt, error)
+	Lock(ctx context.Context, key string, lockedUntil time.Time) error
+	Delete(ctx context.Context, key string) error
+}
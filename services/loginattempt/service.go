@@ -0,0 +1,140 @@
+package loginattempt
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"errors"
+	"time"
+)
+
+// Policy decides when logins are locked out and for how long
+type Policy struct {
+	// UsernameMaxFailures is the number of failed logins allowed for a username before it is locked out
+	UsernameMaxFailures int
+	// IPMaxFailures is the number of failed logins allowed from an IP address before it is locked out
+	IPMaxFailures int
+	// BaseLockout is how long the first lockout lasts, it doubles with every failure after that
+	BaseLockout time.Duration
+	// MaxLockout caps how long a lockout can last
+	MaxLockout time.Duration
+	// ResetAfter is how long after the last failure the failures are forgotten
+	ResetAfter time.Duration
+}
+
+// lockout returns how long to lock out the key after the failures, zero if the failures are still allowed
+func (p Policy) lockout(failures, maxFailures int) time.Duration {
+	if failures < maxFailures {
+		return 0
+	}
+
+	lockout := p.BaseLockout
+
+	for i := maxFailures; i < failures; i++ {
+		lockout *= 2
+
+		if lockout >= p.MaxLockout {
+			return p.MaxLockout
+		}
+	}
+
+	return lockout
+}
+
+// service allows interaction with the Repository
+type service struct {
+	repo   Repository
+	policy Policy
+}
+
+// usernameKey returns the key failures for the username are tracked under
+func usernameKey(username string) string {
+	return "username:" + username
+}
+
+// ipKey returns the key failures from the IP address are tracked under
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check returns how long until the username can log in from the IP address, zero if it can log in now
+func (s *service) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	var retryAfter time.Duration
+
+	now := time.Now()
+
+	for _, key := range []string{usernameKey(username), ipKey(ip)} {
+		attempt, err := s.repo.Find(ctx, key)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				continue
+			}
+
+			return 0, err
+		}
+
+		if wait := attempt.RetryAfter(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RecordFailure counts a failed login for the username and the IP address, locking them out once they have failed
+// too many times. It returns how long until the username can log in from the IP address again.
+func (s *service) RecordFailure(ctx context.Context, username, ip string) (time.Duration, error) {
+	var retryAfter time.Duration
+
+	now := time.Now()
+
+	keys := []struct {
+		key         string
+		maxFailures int
+	}{
+		{key: usernameKey(username), maxFailures: s.policy.UsernameMaxFailures},
+		{key: ipKey(ip), maxFailures: s.policy.IPMaxFailures},
+	}
+
+	for _, k := range keys {
+		attempt, err := s.repo.RecordFailure(ctx, k.key, now, now.Add(-s.policy.ResetAfter))
+		if err != nil {
+			return 0, err
+		}
+
+		lockout := s.policy.lockout(attempt.Failures, k.maxFailures)
+		if lockout == 0 {
+			continue
+		}
+
+		if err := s.repo.Lock(ctx, k.key, now.Add(lockout)); err != nil {
+			return 0, err
+		}
+
+		if lockout > retryAfter {
+			retryAfter = lockout
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RecordSuccess forgets the failed logins for the username. Failures from the IP address are kept so that logging
+// into one account cannot be used to keep guessing the passwords of others.
+func (s *service) RecordSuccess(ctx context.Context, username string) error {
+	return s.repo.Delete(ctx, usernameKey(username))
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Check(ctx context.Context, username, ip string) (time.Duration, error)
+	RecordFailure(ctx context.Context, username, ip string) (time.Duration, error)
+	RecordSuccess(ctx context.Context, username string) error
+}
+
+// NewService creates a new Service which locks out logins according to the policy
+func NewService(repo Repository, policy Policy) Service {
+	return &service{
+		repo:   repo,
+		policy: policy,
+	}
+}
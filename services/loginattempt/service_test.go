@@ -0,0 +1,130 @@
+package loginattempt
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubRepository keeps the login attempts in memory
+type stubRepository struct {
+	attempts map[string]*models.LoginAttempt
+}
+
+func (r *stubRepository) Find(_ context.Context, key string) (*models.LoginAttempt, error) {
+	attempt, ok := r.attempts[key]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	found := *attempt
+	return &found, nil
+}
+
+func (r *stubRepository) RecordFailure(_ context.Context, key string, failedAt, resetBefore time.Time) (
+	*models.LoginAttempt, error) {
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.LastFailedAt.Before(resetBefore) {
+		attempt = &models.LoginAttempt{Key: key}
+		r.attempts[key] = attempt
+	}
+
+	attempt.Failures++
+	attempt.LastFailedAt = failedAt
+
+	found := *attempt
+	return &found, nil
+}
+
+func (r *stubRepository) Lock(_ context.Context, key string, lockedUntil time.Time) error {
+	r.attempts[key].LockedUntil = &lockedUntil
+	return nil
+}
+
+func (r *stubRepository) Delete(_ context.Context, key string) error {
+	delete(r.attempts, key)
+	return nil
+}
+
+var testPolicy = Policy{
+	UsernameMaxFailures: 3,
+	IPMaxFailures:       5,
+	BaseLockout:         time.Minute,
+	MaxLockout:          5 * time.Minute,
+	ResetAfter:          time.Hour,
+}
+
+func TestPolicy_lockout(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures int
+		wants    time.Duration
+	}{
+		{
+			name:     "allows failures below the limit",
+			failures: 2,
+			wants:    0,
+		},
+		{
+			name:     "locks out for the base lockout on reaching the limit",
+			failures: 3,
+			wants:    time.Minute,
+		},
+		{
+			name:     "doubles the lockout for every failure after the limit",
+			failures: 5,
+			wants:    4 * time.Minute,
+		},
+		{
+			name:     "caps the lockout",
+			failures: 50,
+			wants:    5 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wants, testPolicy.lockout(tc.failures, testPolicy.UsernameMaxFailures))
+		})
+	}
+}
+
+func TestService_RecordFailure(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(&stubRepository{attempts: map[string]*models.LoginAttempt{}}, testPolicy)
+
+	for i := 0; i < 2; i++ {
+		retryAfter, err := svc.RecordFailure(ctx, "jay", "127.0.0.1")
+		assert.NoError(t, err)
+		assert.Zero(t, retryAfter)
+	}
+
+	retryAfter, err := svc.RecordFailure(ctx, "jay", "127.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	retryAfter, err = svc.Check(ctx, "jay", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, retryAfter, float64(time.Second), "the username is locked from any IP")
+
+	retryAfter, err = svc.Check(ctx, "wambugu", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, retryAfter)
+
+	for i := 0; i < 2; i++ {
+		_, err = svc.RecordFailure(ctx, "wambugu", "127.0.0.1")
+		assert.NoError(t, err)
+	}
+
+	retryAfter, err = svc.Check(ctx, "someone", "127.0.0.1")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, retryAfter, float64(time.Second), "the IP is locked for any username")
+
+	assert.NoError(t, svc.RecordSuccess(ctx, "jay"))
+
+	retryAfter, err = svc.Check(ctx, "jay", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
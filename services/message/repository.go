@@ -0,0 +1,23 @@
+package message
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"context"
+	"time"
+)
+
+// Repository provides an interface for interacting with the database.
+type Repository interface {
+	Create(ctx context.Context, message *models.Message) (*models.Message, error)
+	FindByID(ctx context.Context, id uint64) (*models.Message, error)
+	GetChatRoomMessages(ctx context.Context, chatRoomID uint64, params pagination.Params) ([]models.Message, error)
+	UpdateBody(ctx context.Context, id uint64, body string, editedAt time.Time,
+		check func(message *models.Message) error) (*models.Message, error)
+	GetRevisions(ctx context.Context, messageID uint64) ([]models.MessageRevision, error)
+	SoftDelete(ctx context.Context, id uint64, deletedAt time.Time) error
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error)
+	AddReaction(ctx context.Context, reaction *models.MessageReaction) (*models.ReactionCount, error)
+	RemoveReaction(ctx context.Context, messageID, userID uint64, emoji string) (*models.ReactionCount, error)
+	GetReactionCounts(ctx context.Context, messageIDs []uint64, userID uint64) ([]models.ReactionCount, error)
+}
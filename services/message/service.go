@@ -0,0 +1,219 @@
+package message
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrEditWindowExpired is returned when the author edits a message after the edit window has passed
+	ErrEditWindowExpired = errors.New("message: the message can no longer be edited")
+)
+
+// purgeBatchSize is how many deleted messages are purged in each transaction
+const purgeBatchSize = 500
+
+// service allows interaction with the Repository
+type service struct {
+	repo       Repository
+	editWindow time.Duration
+}
+
+// Page is a page of a chat room's messages ordered from the newest to the oldest
+type Page struct {
+	Messages []models.Message   `json:"messages"`
+	Cursors  pagination.Cursors `json:"cursors"`
+}
+
+// Create adds a new models.Message
+func (s *service) Create(ctx context.Context, message *models.Message) (*models.Message, error) {
+	return s.repo.Create(ctx, message)
+}
+
+// FindByID fetches a models.Message using the id provided
+func (s *service) FindByID(ctx context.Context, id uint64) (*models.Message, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// GetChatRoomMessages returns a Page of the messages sent to the models.ChatRoom with their reactions, flagging the
+// ones the user with the userID reacted with
+func (s *service) GetChatRoomMessages(ctx context.Context, chatRoomID, userID uint64, params pagination.Params) (
+	*Page, error) {
+	messages, err := s.repo.GetChatRoomMessages(ctx, chatRoomID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository fetches an extra message to tell if there are more in the direction being paged
+	hasMore := len(messages) > params.Limit
+	if hasMore {
+		if params.After != 0 {
+			messages = messages[1:]
+		} else {
+			messages = messages[:params.Limit]
+		}
+	}
+
+	page := &Page{
+		Messages: messages,
+	}
+
+	if len(messages) == 0 {
+		return page, nil
+	}
+
+	if err := s.attachReactions(ctx, messages, userID); err != nil {
+		return nil, err
+	}
+
+	newest, oldest := messages[0].ID, messages[len(messages)-1].ID
+
+	// Paging forward means there are older messages, paging backward means there are newer ones
+	if hasMore || params.After != 0 {
+		page.Cursors.Next = pagination.EncodeCursor(oldest)
+	}
+
+	if (hasMore && params.After != 0) || params.Before != 0 {
+		page.Cursors.Prev = pagination.EncodeCursor(newest)
+	}
+
+	return page, nil
+}
+
+// attachReactions sets the reaction counts of the messages that have not been deleted
+func (s *service) attachReactions(ctx context.Context, messages []models.Message, userID uint64) error {
+	var ids []uint64
+
+	for _, m := range messages {
+		if m.DeletedAt == nil {
+			ids = append(ids, m.ID)
+		}
+	}
+
+	counts, err := s.repo.GetReactionCounts(ctx, ids, userID)
+	if err != nil {
+		return err
+	}
+
+	reactions := make(map[uint64][]models.ReactionCount)
+	for _, count := range counts {
+		reactions[count.MessageID] = append(reactions[count.MessageID], count)
+	}
+
+	for i := range messages {
+		messages[i].Reactions = reactions[messages[i].ID]
+	}
+
+	return nil
+}
+
+// Update replaces the body of the models.Message if the user is its author and the edit window has not passed. The
+// checks run against the locked message so a concurrent edit or delete cannot slip past them. The previous body is
+// kept as a models.MessageRevision.
+func (s *service) Update(ctx context.Context, id, userID uint64, body string) (*models.Message, error) {
+	now := time.Now()
+
+	return s.repo.UpdateBody(ctx, id, body, now, func(message *models.Message) error {
+		if message.UserID != userID {
+			return models.ErrForbidden
+		}
+
+		if now.After(message.CreatedAt.Add(s.editWindow)) {
+			return ErrEditWindowExpired
+		}
+
+		return nil
+	})
+}
+
+// GetRevisions returns the []models.MessageRevision of the models.Message from the oldest to the newest
+func (s *service) GetRevisions(ctx context.Context, messageID uint64) ([]models.MessageRevision, error) {
+	return s.repo.GetRevisions(ctx, messageID)
+}
+
+// SoftDelete marks the models.Message as deleted and returns the tombstone left in the chat room history
+func (s *service) SoftDelete(ctx context.Context, message *models.Message) (*models.Message, error) {
+	now := time.Now()
+
+	if err := s.repo.SoftDelete(ctx, message.ID, now); err != nil {
+		return nil, err
+	}
+
+	tombstone := *message
+	tombstone.Body = ""
+	tombstone.Deleted = true
+	tombstone.DeletedAt = &now
+	tombstone.UpdatedAt = now
+	tombstone.Reactions = nil
+
+	return &tombstone, nil
+}
+
+// PurgeDeleted permanently removes the messages that were deleted more than retention ago in batches and returns how
+// many were removed, including the batches purged before an error
+func (s *service) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	before := time.Now().Add(-retention)
+
+	var total int64
+	for {
+		purged, err := s.repo.PurgeDeleted(ctx, before, purgeBatchSize)
+		total += purged
+
+		if err != nil {
+			return total, err
+		}
+
+		if purged < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// React adds the user's reaction to the models.Message with the emoji and returns the updated models.ReactionCount
+// for it. Reacting with the same emoji twice returns models.ErrDuplicateRecord.
+func (s *service) React(ctx context.Context, message *models.Message, userID uint64, emoji string) (
+	*models.ReactionCount, error) {
+	if message.DeletedAt != nil {
+		return nil, models.ErrNoRecord
+	}
+
+	reaction := &models.MessageReaction{
+		MessageID: message.ID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now(),
+	}
+
+	return s.repo.AddReaction(ctx, reaction)
+}
+
+// Unreact removes the user's reaction to the models.Message with the emoji and returns the updated
+// models.ReactionCount for it
+func (s *service) Unreact(ctx context.Context, message *models.Message, userID uint64, emoji string) (
+	*models.ReactionCount, error) {
+	return s.repo.RemoveReaction(ctx, message.ID, userID, emoji)
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Create(ctx context.Context, message *models.Message) (*models.Message, error)
+	FindByID(ctx context.Context, id uint64) (*models.Message, error)
+	GetChatRoomMessages(ctx context.Context, chatRoomID, userID uint64, params pagination.Params) (*Page, error)
+	Update(ctx context.Context, id, userID uint64, body string) (*models.Message, error)
+	GetRevisions(ctx context.Context, messageID uint64) ([]models.MessageRevision, error)
+	SoftDelete(ctx context.Context, message *models.Message) (*models.Message, error)
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	React(ctx context.Context, message *models.Message, userID uint64, emoji string) (*models.ReactionCount, error)
+	Unreact(ctx context.Context, message *models.Message, userID uint64, emoji string) (*models.ReactionCount, error)
+}
+
+// NewService creates a new Service, messages can be edited by their author for editWindow after they are sent
+func NewService(repo Repository, editWindow time.Duration) Service {
+	return &service{
+		repo:       repo,
+		editWindow: editWindow,
+	}
+}
@@ -0,0 +1,423 @@
+package message
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubRepository returns the messages it holds from the newest to the oldest like the mysql repository
+type stubRepository struct {
+	Repository
+	messages  []models.Message
+	reactions []models.MessageReaction
+
+	purgeCalls int
+}
+
+func (r *stubRepository) GetChatRoomMessages(_ context.Context, _ uint64, params pagination.Params) (
+	[]models.Message, error) {
+	var found []models.Message
+
+	for _, m := range r.messages {
+		if (params.Before != 0 && m.ID >= params.Before) || (params.After != 0 && m.ID <= params.After) {
+			continue
+		}
+
+		found = append(found, m)
+	}
+
+	limit := params.Limit + 1
+	if len(found) <= limit {
+		return found, nil
+	}
+
+	// Paging after a cursor returns the messages closest to it
+	if params.After != 0 {
+		return found[len(found)-limit:], nil
+	}
+
+	return found[:limit], nil
+}
+
+func (r *stubRepository) FindByID(_ context.Context, id uint64) (*models.Message, error) {
+	for _, m := range r.messages {
+		if m.ID == id {
+			found := m
+			return &found, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) UpdateBody(_ context.Context, id uint64, body string, editedAt time.Time,
+	check func(message *models.Message) error) (*models.Message, error) {
+	for i, m := range r.messages {
+		if m.ID != id || m.DeletedAt != nil {
+			continue
+		}
+
+		if err := check(&m); err != nil {
+			return nil, err
+		}
+
+		if m.Body != body {
+			r.messages[i].Body = body
+			r.messages[i].EditedAt = &editedAt
+		}
+
+		found := r.messages[i]
+		return &found, nil
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) SoftDelete(_ context.Context, id uint64, deletedAt time.Time) error {
+	for i, m := range r.messages {
+		if m.ID == id && m.DeletedAt == nil {
+			r.messages[i].DeletedAt = &deletedAt
+			return nil
+		}
+	}
+
+	return models.ErrNoRecord
+}
+
+func (r *stubRepository) PurgeDeleted(_ context.Context, before time.Time, limit int) (int64, error) {
+	var kept []models.Message
+	var purged int64
+
+	for _, m := range r.messages {
+		if m.DeletedAt != nil && m.DeletedAt.Before(before) && purged < int64(limit) {
+			purged++
+			continue
+		}
+
+		kept = append(kept, m)
+	}
+
+	r.messages = kept
+	r.purgeCalls++
+	return purged, nil
+}
+
+func (r *stubRepository) AddReaction(_ context.Context, reaction *models.MessageReaction) (*models.ReactionCount,
+	error) {
+	found, err := r.FindByID(context.Background(), reaction.MessageID)
+	if err != nil || found.DeletedAt != nil {
+		return nil, models.ErrNoRecord
+	}
+
+	for _, existing := range r.reactions {
+		if existing.MessageID == reaction.MessageID && existing.UserID == reaction.UserID &&
+			existing.Emoji == reaction.Emoji {
+			return nil, models.ErrDuplicateRecord
+		}
+	}
+
+	r.reactions = append(r.reactions, *reaction)
+	return r.reactionCount(reaction.MessageID, reaction.UserID, reaction.Emoji), nil
+}
+
+func (r *stubRepository) RemoveReaction(_ context.Context, messageID, userID uint64, emoji string) (
+	*models.ReactionCount, error) {
+	for i, existing := range r.reactions {
+		if existing.MessageID == messageID && existing.UserID == userID && existing.Emoji == emoji {
+			r.reactions = append(r.reactions[:i], r.reactions[i+1:]...)
+			return r.reactionCount(messageID, userID, emoji), nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) reactionCount(messageID, userID uint64, emoji string) *models.ReactionCount {
+	count := &models.ReactionCount{MessageID: messageID, Emoji: emoji}
+
+	for _, reaction := range r.reactions {
+		if reaction.MessageID == messageID && reaction.Emoji == emoji {
+			count.Count++
+			count.ReactedByMe = count.ReactedByMe || reaction.UserID == userID
+		}
+	}
+
+	return count
+}
+
+func (r *stubRepository) GetReactionCounts(_ context.Context, messageIDs []uint64, userID uint64) (
+	[]models.ReactionCount, error) {
+	var counts []models.ReactionCount
+
+	for _, id := range messageIDs {
+		for _, reaction := range r.reactions {
+			if reaction.MessageID != id {
+				continue
+			}
+
+			found := false
+			for i, count := range counts {
+				if count.MessageID == id && count.Emoji == reaction.Emoji {
+					counts[i].Count++
+					counts[i].ReactedByMe = counts[i].ReactedByMe || reaction.UserID == userID
+					found = true
+				}
+			}
+
+			if !found {
+				counts = append(counts, models.ReactionCount{
+					MessageID:   id,
+					Emoji:       reaction.Emoji,
+					Count:       1,
+					ReactedByMe: reaction.UserID == userID,
+				})
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+func TestService_GetChatRoomMessages(t *testing.T) {
+	var messages []models.Message
+	for id := uint64(5); id > 0; id-- {
+		messages = append(messages, models.Message{ID: id})
+	}
+
+	svc := NewService(&stubRepository{messages: messages}, time.Minute)
+
+	testCases := []struct {
+		name      string
+		params    pagination.Params
+		wantsIDs  []uint64
+		wantsNext string
+		wantsPrev string
+	}{
+		{
+			name:      "returns the latest page with a cursor to older messages",
+			params:    pagination.Params{Limit: 2},
+			wantsIDs:  []uint64{5, 4},
+			wantsNext: pagination.EncodeCursor(4),
+		},
+		{
+			name:      "returns the page before the cursor",
+			params:    pagination.Params{Before: 4, Limit: 2},
+			wantsIDs:  []uint64{3, 2},
+			wantsNext: pagination.EncodeCursor(2),
+			wantsPrev: pagination.EncodeCursor(3),
+		},
+		{
+			name:      "returns the oldest page without a next cursor",
+			params:    pagination.Params{Before: 2, Limit: 2},
+			wantsIDs:  []uint64{1},
+			wantsPrev: pagination.EncodeCursor(1),
+		},
+		{
+			name:      "returns the page after the cursor",
+			params:    pagination.Params{After: 1, Limit: 2},
+			wantsIDs:  []uint64{3, 2},
+			wantsNext: pagination.EncodeCursor(2),
+			wantsPrev: pagination.EncodeCursor(3),
+		},
+		{
+			name:      "returns the newest page after the cursor without a prev cursor",
+			params:    pagination.Params{After: 3, Limit: 2},
+			wantsIDs:  []uint64{5, 4},
+			wantsNext: pagination.EncodeCursor(4),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := svc.GetChatRoomMessages(context.Background(), 1, 1, tc.params)
+			assert.NoError(t, err)
+
+			var ids []uint64
+			for _, m := range page.Messages {
+				ids = append(ids, m.ID)
+			}
+
+			assert.Equal(t, tc.wantsIDs, ids)
+			assert.Equal(t, tc.wantsNext, page.Cursors.Next)
+			assert.Equal(t, tc.wantsPrev, page.Cursors.Prev)
+		})
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	const authorID uint64 = 1
+
+	testCases := []struct {
+		name        string
+		userID      uint64
+		sentAt      time.Time
+		deleted     bool
+		body        string
+		wantsEdited bool
+		wantsErr    error
+	}{
+		{
+			name:        "author edits the message within the edit window",
+			userID:      authorID,
+			sentAt:      time.Now().Add(-time.Minute),
+			body:        "edited",
+			wantsEdited: true,
+		},
+		{
+			name:   "does not edit the message if the body has not changed",
+			userID: authorID,
+			sentAt: time.Now().Add(-time.Minute),
+			body:   "original",
+		},
+		{
+			name:     "fails if the user is not the author",
+			userID:   2,
+			sentAt:   time.Now().Add(-time.Minute),
+			body:     "edited",
+			wantsErr: models.ErrForbidden,
+		},
+		{
+			name:     "fails if the message has been deleted",
+			userID:   authorID,
+			sentAt:   time.Now().Add(-time.Minute),
+			deleted:  true,
+			body:     "edited",
+			wantsErr: models.ErrNoRecord,
+		},
+		{
+			name:     "fails if the edit window has passed",
+			userID:   authorID,
+			sentAt:   time.Now().Add(-16 * time.Minute),
+			body:     "edited",
+			wantsErr: ErrEditWindowExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &stubRepository{
+				messages: []models.Message{{ID: 1, UserID: authorID, Body: "original", CreatedAt: tc.sentAt}},
+			}
+
+			if tc.deleted {
+				deletedAt := time.Now()
+				repo.messages[0].DeletedAt = &deletedAt
+			}
+
+			got, err := NewService(repo, 15*time.Minute).Update(context.Background(), 1, tc.userID, tc.body)
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				assert.Equal(t, "original", repo.messages[0].Body)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.body, got.Body)
+			assert.Equal(t, tc.wantsEdited, got.EditedAt != nil)
+			assert.Equal(t, tc.wantsEdited, repo.messages[0].EditedAt != nil)
+		})
+	}
+}
+
+func TestService_SoftDelete(t *testing.T) {
+	repo := &stubRepository{
+		messages: []models.Message{{ID: 1, UserID: 1, Body: "original"}},
+	}
+
+	svc := NewService(repo, time.Minute)
+
+	tombstone, err := svc.SoftDelete(context.Background(), &repo.messages[0])
+	assert.NoError(t, err)
+	assert.Empty(t, tombstone.Body)
+	assert.True(t, tombstone.Deleted)
+	assert.NotNil(t, tombstone.DeletedAt)
+	assert.Equal(t, *tombstone.DeletedAt, tombstone.UpdatedAt)
+	assert.Equal(t, "original", repo.messages[0].Body)
+
+	_, err = svc.SoftDelete(context.Background(), &repo.messages[0])
+	assert.ErrorIs(t, err, models.ErrNoRecord)
+}
+
+func TestService_PurgeDeleted(t *testing.T) {
+	deletedAt := time.Now().Add(-2 * time.Hour)
+
+	repo := &stubRepository{}
+	for i := 1; i <= purgeBatchSize+2; i++ {
+		repo.messages = append(repo.messages, models.Message{ID: uint64(i), DeletedAt: &deletedAt})
+	}
+	repo.messages = append(repo.messages, models.Message{ID: purgeBatchSize + 3})
+
+	purged, err := NewService(repo, time.Minute).PurgeDeleted(context.Background(), time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(purgeBatchSize+2), purged)
+	assert.Equal(t, 2, repo.purgeCalls, "purges in batches until a batch is not full")
+	assert.Len(t, repo.messages, 1)
+}
+
+func TestService_GetChatRoomMessages_Reactions(t *testing.T) {
+	deletedAt := time.Now()
+
+	repo := &stubRepository{
+		messages: []models.Message{{ID: 3}, {ID: 2, DeletedAt: &deletedAt}, {ID: 1}},
+		reactions: []models.MessageReaction{
+			{MessageID: 1, UserID: 1, Emoji: "👍"},
+			{MessageID: 1, UserID: 2, Emoji: "👍"},
+			{MessageID: 1, UserID: 2, Emoji: "🎉"},
+			{MessageID: 2, UserID: 1, Emoji: "👍"},
+		},
+	}
+
+	page, err := NewService(repo, time.Minute).GetChatRoomMessages(context.Background(), 1, 1,
+		pagination.Params{Limit: 3})
+	assert.NoError(t, err)
+
+	assert.Empty(t, page.Messages[0].Reactions)
+	assert.Empty(t, page.Messages[1].Reactions, "deleted messages have no reactions")
+	assert.Equal(t, []models.ReactionCount{
+		{MessageID: 1, Emoji: "👍", Count: 2, ReactedByMe: true},
+		{MessageID: 1, Emoji: "🎉", Count: 1},
+	}, page.Messages[2].Reactions)
+}
+
+func TestService_React(t *testing.T) {
+	repo := &stubRepository{
+		messages:  []models.Message{{ID: 1}},
+		reactions: []models.MessageReaction{{MessageID: 1, UserID: 2, Emoji: "👍"}},
+	}
+
+	svc := NewService(repo, time.Minute)
+	ctx := context.Background()
+
+	count, err := svc.React(ctx, &repo.messages[0], 1, "👍")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ReactionCount{MessageID: 1, Emoji: "👍", Count: 2, ReactedByMe: true}, count)
+
+	_, err = svc.React(ctx, &repo.messages[0], 1, "👍")
+	assert.ErrorIs(t, err, models.ErrDuplicateRecord)
+
+	count, err = svc.Unreact(ctx, &repo.messages[0], 1, "👍")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ReactionCount{MessageID: 1, Emoji: "👍", Count: 1}, count)
+
+	count, err = svc.Unreact(ctx, &repo.messages[0], 2, "👍")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ReactionCount{MessageID: 1, Emoji: "👍"}, count)
+
+	_, err = svc.Unreact(ctx, &repo.messages[0], 2, "👍")
+	assert.ErrorIs(t, err, models.ErrNoRecord)
+}
+
+func TestService_React_DeletedMessage(t *testing.T) {
+	deletedAt := time.Now()
+	repo := &stubRepository{
+		messages: []models.Message{{ID: 1, DeletedAt: &deletedAt}},
+	}
+
+	_, err := NewService(repo, time.Minute).React(context.Background(), &repo.messages[0], 1, "👍")
+	assert.ErrorIs(t, err, models.ErrNoRecord)
+	assert.Empty(t, repo.reactions)
+}
@@ -0,0 +1,15 @@
+package passwordreset
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"time"
+)
+
+// Repository provides an interface for interacting with the database.
+type Repository interface {
+	Create(ctx context.Context, reset *models.PasswordReset) (*models.PasswordReset, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	MarkUsed(ctx context.Context, id uint64, usedAt time.Time) (bool, error)
+	InvalidateUserResets(ctx context.Context, userID uint64, at time.Time) error
+}
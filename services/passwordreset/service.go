@@ -0,0 +1,96 @@
+package passwordreset
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/util"
+	"context"
+	"errors"
+	"time"
+)
+
+// tokenBytes is the number of random bytes used to create a reset token
+const tokenBytes = 32
+
+var (
+	// ErrInvalidResetToken is returned when the reset token does not exist, has expired or has already been used
+	ErrInvalidResetToken = errors.New("passwordreset: reset token is invalid")
+)
+
+// service allows interaction with the Repository
+type service struct {
+	repo     Repository
+	duration time.Duration
+}
+
+// Issue creates a new reset token for the models.User and returns its plain text value. Any reset token issued
+// before is invalidated so only the latest one can be used.
+func (s *service) Issue(ctx context.Context, userID uint64) (string, *models.PasswordReset, error) {
+	token, err := util.GenerateRandomToken(tokenBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+
+	if err := s.repo.InvalidateUserResets(ctx, userID, now); err != nil {
+		return "", nil, err
+	}
+
+	reset, err := s.repo.Create(ctx, &models.PasswordReset{
+		UserID:    userID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: now.Add(s.duration),
+		CreatedAt: now,
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, reset, nil
+}
+
+// Redeem uses up the reset token and returns the models.PasswordReset it belongs to
+func (s *service) Redeem(ctx context.Context, token string) (*models.PasswordReset, error) {
+	reset, err := s.repo.FindByTokenHash(ctx, util.HashToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, ErrInvalidResetToken
+		}
+
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if !reset.CanBeRedeemed(now) {
+		return nil, ErrInvalidResetToken
+	}
+
+	used, err := s.repo.MarkUsed(ctx, reset.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request used the token between reading and marking it
+	if !used {
+		return nil, ErrInvalidResetToken
+	}
+
+	reset.UsedAt = &now
+	return reset, nil
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Issue(ctx context.Context, userID uint64) (string, *models.PasswordReset, error)
+	Redeem(ctx context.Context, token string) (*models.PasswordReset, error)
+}
+
+// NewService creates a new Service. Reset tokens expire after the duration provided.
+func NewService(repo Repository, duration time.Duration) Service {
+	return &service{
+		repo:     repo,
+		duration: duration,
+	}
+}
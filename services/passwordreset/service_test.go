@@ -0,0 +1,132 @@
+package passwordreset
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubRepository keeps the password resets in memory
+type stubRepository struct {
+	resets []*models.PasswordReset
+}
+
+func (r *stubRepository) Create(_ context.Context, reset *models.PasswordReset) (*models.PasswordReset, error) {
+	reset.ID = uint64(len(r.resets) + 1)
+	r.resets = append(r.resets, reset)
+
+	return reset, nil
+}
+
+func (r *stubRepository) FindByTokenHash(_ context.Context, tokenHash string) (*models.PasswordReset, error) {
+	for _, reset := range r.resets {
+		if reset.TokenHash == tokenHash {
+			found := *reset
+			return &found, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) MarkUsed(_ context.Context, id uint64, usedAt time.Time) (bool, error) {
+	reset := r.resets[id-1]
+	if reset.UsedAt != nil {
+		return false, nil
+	}
+
+	reset.UsedAt = &usedAt
+	return true, nil
+}
+
+func (r *stubRepository) InvalidateUserResets(_ context.Context, userID uint64, at time.Time) error {
+	for _, reset := range r.resets {
+		if reset.UserID == userID && reset.UsedAt == nil {
+			reset.UsedAt = &at
+		}
+	}
+
+	return nil
+}
+
+func TestService_Redeem(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		duration time.Duration
+		redeem   func(svc Service, token string) (*models.PasswordReset, error)
+		wantsErr error
+	}{
+		{
+			name:     "redeems a valid token",
+			duration: time.Hour,
+			redeem: func(svc Service, token string) (*models.PasswordReset, error) {
+				return svc.Redeem(ctx, token)
+			},
+		},
+		{
+			name:     "fails to redeem an expired token",
+			duration: -time.Hour,
+			redeem: func(svc Service, token string) (*models.PasswordReset, error) {
+				return svc.Redeem(ctx, token)
+			},
+			wantsErr: ErrInvalidResetToken,
+		},
+		{
+			name:     "fails to redeem a used token",
+			duration: time.Hour,
+			redeem: func(svc Service, token string) (*models.PasswordReset, error) {
+				if _, err := svc.Redeem(ctx, token); err != nil {
+					return nil, err
+				}
+
+				return svc.Redeem(ctx, token)
+			},
+			wantsErr: ErrInvalidResetToken,
+		},
+		{
+			name:     "fails to redeem a token replaced by a newer one",
+			duration: time.Hour,
+			redeem: func(svc Service, token string) (*models.PasswordReset, error) {
+				if _, _, err := svc.Issue(ctx, 1); err != nil {
+					return nil, err
+				}
+
+				return svc.Redeem(ctx, token)
+			},
+			wantsErr: ErrInvalidResetToken,
+		},
+		{
+			name:     "fails to redeem an unknown token",
+			duration: time.Hour,
+			redeem: func(svc Service, token string) (*models.PasswordReset, error) {
+				return svc.Redeem(ctx, token+"x")
+			},
+			wantsErr: ErrInvalidResetToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(&stubRepository{}, tc.duration)
+
+			token, issued, err := svc.Issue(ctx, 1)
+			assert.NoError(t, err)
+			assert.NotEqual(t, token, issued.TokenHash)
+
+			got, err := tc.redeem(svc, token)
+			if tc.wantsErr != nil {
+				assert.ErrorIs(t, err, tc.wantsErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), got.UserID)
+			assert.NotNil(t, got.UsedAt)
+		})
+	}
+}
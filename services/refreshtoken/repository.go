@@ -0,0 +1,16 @@
+package refreshtoken
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Repository provides an interface for interacting with the database.
+type Repository interface {
+	Create(ctx context.Context, token *models.RefreshToken) (*models.RefreshToken, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	MarkRotated(ctx context.Context, id uint64, rotatedAt time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID, revokedAt time.Time) error
+}
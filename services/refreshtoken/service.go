@@ -0,0 +1,140 @@
+package refreshtoken
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/util"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// tokenBytes is the number of random bytes used to create a refresh token
+const tokenBytes = 32
+
+var (
+	// ErrInvalidRefreshToken is returned when the refresh token does not exist, has expired or was revoked
+	ErrInvalidRefreshToken = errors.New("refreshtoken: refresh token is invalid")
+
+	// ErrRefreshTokenReused is returned when a refresh token that has already been rotated is used again.
+	// The whole family is revoked since either the user or an attacker is holding a stolen token.
+	ErrRefreshTokenReused = errors.New("refreshtoken: refresh token has already been used")
+)
+
+// service allows interaction with the Repository
+type service struct {
+	repo     Repository
+	duration time.Duration
+}
+
+// create stores a new refresh token in the family and returns its plain text value
+func (s *service) create(ctx context.Context, userID uint64, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	token, err := util.GenerateRandomToken(tokenBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+
+	refreshToken, err := s.repo.Create(ctx, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: now.Add(s.duration),
+		CreatedAt: now,
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, refreshToken, nil
+}
+
+// Issue creates the first refresh token of a new family for the models.User. The family id is the id of the
+// models.Session the token is issued for.
+func (s *service) Issue(ctx context.Context, userID uint64, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	return s.create(ctx, userID, familyID)
+}
+
+// Rotate exchanges the refresh token for a new one in the same family. Reusing a rotated token revokes the family.
+func (s *service) Rotate(ctx context.Context, token string) (string, *models.RefreshToken, error) {
+	current, err := s.repo.FindByTokenHash(ctx, util.HashToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return "", nil, ErrInvalidRefreshToken
+		}
+
+		return "", nil, err
+	}
+
+	now := time.Now()
+
+	if current.RotatedAt != nil {
+		return "", nil, s.revokeReusedFamily(ctx, current.FamilyID, now)
+	}
+
+	if !current.IsActive(now) {
+		return "", nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := s.repo.MarkRotated(ctx, current.ID, now)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Another request rotated the token between reading and marking it
+	if !rotated {
+		return "", nil, s.revokeReusedFamily(ctx, current.FamilyID, now)
+	}
+
+	return s.create(ctx, current.UserID, current.FamilyID)
+}
+
+// revokeReusedFamily revokes every token in the family after a rotated token was reused
+func (s *service) revokeReusedFamily(ctx context.Context, familyID uuid.UUID, now time.Time) error {
+	if err := s.repo.RevokeFamily(ctx, familyID, now); err != nil {
+		return err
+	}
+
+	return ErrRefreshTokenReused
+}
+
+// RevokeFamily revokes every token created for the same login
+func (s *service) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return s.repo.RevokeFamily(ctx, familyID, time.Now())
+}
+
+// Revoke revokes the family of the refresh token as long as it belongs to the models.User
+func (s *service) Revoke(ctx context.Context, token string, userID uint64) error {
+	refreshToken, err := s.repo.FindByTokenHash(ctx, util.HashToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return ErrInvalidRefreshToken
+		}
+
+		return err
+	}
+
+	if refreshToken.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+
+	return s.repo.RevokeFamily(ctx, refreshToken.FamilyID, time.Now())
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Issue(ctx context.Context, userID uint64, familyID uuid.UUID) (string, *models.RefreshToken, error)
+	Rotate(ctx context.Context, token string) (string, *models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	Revoke(ctx context.Context, token string, userID uint64) error
+}
+
+// NewService creates a new Service that issues refresh tokens valid for the duration provided
+func NewService(repo Repository, duration time.Duration) Service {
+	return &service{
+		repo:     repo,
+		duration: duration,
+	}
+}
@@ -0,0 +1,111 @@
+package refreshtoken
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubRepository keeps the refresh tokens in memory
+type stubRepository struct {
+	tokens []*models.RefreshToken
+}
+
+func (r *stubRepository) Create(_ context.Context, token *models.RefreshToken) (*models.RefreshToken, error) {
+	token.ID = uint64(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+
+	return token, nil
+}
+
+func (r *stubRepository) FindByTokenHash(_ context.Context, tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) MarkRotated(_ context.Context, id uint64, rotatedAt time.Time) (bool, error) {
+	token := r.tokens[id-1]
+	if token.RotatedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+
+	token.RotatedAt = &rotatedAt
+	return true, nil
+}
+
+func (r *stubRepository) RevokeFamily(_ context.Context, familyID uuid.UUID, revokedAt time.Time) error {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+
+	return nil
+}
+
+func TestService_Rotate(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubRepository{}
+	svc := NewService(repo, time.Hour)
+
+	token, issued, err := svc.Issue(ctx, 1, uuid.New())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	assert.NotEqual(t, token, issued.TokenHash)
+
+	rotatedToken, rotated, err := svc.Rotate(ctx, token)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, rotatedToken)
+	assert.Equal(t, issued.FamilyID, rotated.FamilyID)
+	assert.Equal(t, uint64(1), rotated.UserID)
+
+	_, _, err = svc.Rotate(ctx, rotatedToken)
+	assert.NoError(t, err)
+}
+
+func TestService_Rotate_DetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubRepository{}
+	svc := NewService(repo, time.Hour)
+
+	token, _, err := svc.Issue(ctx, 1, uuid.New())
+	assert.NoError(t, err)
+
+	rotatedToken, _, err := svc.Rotate(ctx, token)
+	assert.NoError(t, err)
+
+	_, _, err = svc.Rotate(ctx, token)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// The token issued by the legitimate rotation is revoked along with the rest of the family
+	_, _, err = svc.Rotate(ctx, rotatedToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	for _, token := range repo.tokens {
+		assert.NotNil(t, token.RevokedAt)
+	}
+}
+
+func TestService_Rotate_Invalid(t *testing.T) {
+	ctx := context.Background()
+
+	_, _, err := NewService(&stubRepository{}, time.Hour).Rotate(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	svc := NewService(&stubRepository{}, -time.Minute)
+
+	token, _, err := svc.Issue(ctx, 1, uuid.New())
+	assert.NoError(t, err)
+
+	_, _, err = svc.Rotate(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
@@ -0,0 +1,14 @@
+package revocation
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Repository provides an interface for storing revoked access tokens. Both access token and session UUIDs are
+// stored so that every access token issued for a session can be revoked at once.
+type Repository interface {
+	Revoke(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, ids ...uuid.UUID) (bool, error)
+}
@@ -0,0 +1,49 @@
+package revocation
+
+import (
+	"chatapp/pkg/accesstoken"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// service allows interaction with the Repository
+type service struct {
+	repo                Repository
+	accessTokenDuration time.Duration
+}
+
+// Revoke stops the access token from being accepted until it expires
+func (s *service) Revoke(ctx context.Context, payload *accesstoken.Payload) error {
+	return s.repo.Revoke(ctx, payload.UUID, payload.ExpiresAt)
+}
+
+// RevokeSession stops every access token issued for the session from being accepted. The session is kept for as
+// long as the access tokens issued before now can be valid.
+func (s *service) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	return s.repo.Revoke(ctx, sessionID, time.Now().Add(s.accessTokenDuration))
+}
+
+// IsRevoked checks if the access token or the session it was issued for has been revoked
+func (s *service) IsRevoked(ctx context.Context, payload *accesstoken.Payload) (bool, error) {
+	if payload.SessionID == uuid.Nil {
+		return s.repo.IsRevoked(ctx, payload.UUID)
+	}
+
+	return s.repo.IsRevoked(ctx, payload.UUID, payload.SessionID)
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Revoke(ctx context.Context, payload *accesstoken.Payload) error
+	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	IsRevoked(ctx context.Context, payload *accesstoken.Payload) (bool, error)
+}
+
+// NewService creates a new Service for access tokens valid for the duration provided
+func NewService(repo Repository, accessTokenDuration time.Duration) Service {
+	return &service{
+		repo:                repo,
+		accessTokenDuration: accessTokenDuration,
+	}
+}
@@ -0,0 +1,17 @@
+package session
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Repository provides an interface for interacting with the database.
+type Repository interface {
+	Create(ctx context.Context, session *models.Session) (*models.Session, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error)
+	GetUserSessions(ctx context.Context, userID uint64, lastSeenAfter time.Time) ([]models.Session, error)
+	Touch(ctx context.Context, id uuid.UUID, lastSeenAt time.Time) error
+	Revoke(ctx context.Context, userID uint64, ids []uuid.UUID, revokedAt time.Time) error
+}
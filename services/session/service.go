@@ -0,0 +1,130 @@
+package session
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+// maxUserAgentLength is the size of the user_agent column
+const maxUserAgentLength = 512
+
+// service allows interaction with the Repository
+type service struct {
+	repo        Repository
+	idleTimeout time.Duration
+}
+
+// Start creates a new models.Session for the models.User logging in from the device provided
+func (s *service) Start(ctx context.Context, userID uint64, userAgent, ipAddress string) (*models.Session, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	now := time.Now()
+
+	return s.repo.Create(ctx, &models.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	})
+}
+
+// Touch records that the models.Session has just been used
+func (s *service) Touch(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Touch(ctx, id, time.Now())
+}
+
+// GetActiveSessions returns the models.User sessions that have not been revoked or left idle for too long,
+// flagging the session the request was made from.
+func (s *service) GetActiveSessions(ctx context.Context, userID uint64, currentID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.repo.GetUserSessions(ctx, userID, time.Now().Add(-s.idleTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// Revoke ends one of the models.User sessions
+func (s *service) Revoke(ctx context.Context, userID uint64, id uuid.UUID) error {
+	found, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if found.UserID != userID || found.RevokedAt != nil {
+		return models.ErrNoRecord
+	}
+
+	return s.repo.Revoke(ctx, userID, []uuid.UUID{id}, time.Now())
+}
+
+// RevokeOthers ends every active models.User session except the one provided and returns the ids of the sessions
+// that were ended
+func (s *service) RevokeOthers(ctx context.Context, userID uint64, exceptID uuid.UUID) ([]uuid.UUID, error) {
+	return s.revokeAllExcept(ctx, userID, exceptID)
+}
+
+// RevokeAll ends every active models.User session and returns the ids of the sessions that were ended
+func (s *service) RevokeAll(ctx context.Context, userID uint64) ([]uuid.UUID, error) {
+	return s.revokeAllExcept(ctx, userID, uuid.Nil)
+}
+
+// revokeAllExcept ends every active models.User session except the one provided, uuid.Nil matches no session
+func (s *service) revokeAllExcept(ctx context.Context, userID uint64, exceptID uuid.UUID) ([]uuid.UUID, error) {
+	sessions, err := s.repo.GetUserSessions(ctx, userID, time.Now().Add(-s.idleTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+
+	for _, session := range sessions {
+		if session.ID != exceptID {
+			ids = append(ids, session.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	if err := s.repo.Revoke(ctx, userID, ids, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Start(ctx context.Context, userID uint64, userAgent, ipAddress string) (*models.Session, error)
+	Touch(ctx context.Context, id uuid.UUID) error
+	GetActiveSessions(ctx context.Context, userID uint64, currentID uuid.UUID) ([]models.Session, error)
+	Revoke(ctx context.Context, userID uint64, id uuid.UUID) error
+	RevokeOthers(ctx context.Context, userID uint64, exceptID uuid.UUID) ([]uuid.UUID, error)
+	RevokeAll(ctx context.Context, userID uint64) ([]uuid.UUID, error)
+}
+
+// NewService creates a new Service. Sessions that have not been used within the idle timeout are no longer active,
+// it should match the refresh token duration since that is how long a session can go without being used.
+func NewService(repo Repository, idleTimeout time.Duration) Service {
+	return &service{
+		repo:        repo,
+		idleTimeout: idleTimeout,
+	}
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubRepository keeps the sessions in memory
+type stubRepository struct {
+	sessions []*models.Session
+}
+
+func (r *stubRepository) Create(_ context.Context, session *models.Session) (*models.Session, error) {
+	r.sessions = append(r.sessions, session)
+	return session, nil
+}
+
+func (r *stubRepository) FindByID(_ context.Context, id uuid.UUID) (*models.Session, error) {
+	for _, session := range r.sessions {
+		if session.ID == id {
+			found := *session
+			return &found, nil
+		}
+	}
+
+	return nil, models.ErrNoRecord
+}
+
+func (r *stubRepository) GetUserSessions(_ context.Context, userID uint64, lastSeenAfter time.Time) (
+	[]models.Session, error) {
+	sessions := []models.Session{}
+
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.LastSeenAt.After(lastSeenAfter) {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	return sessions, nil
+}
+
+func (r *stubRepository) Touch(_ context.Context, id uuid.UUID, lastSeenAt time.Time) error {
+	for _, session := range r.sessions {
+		if session.ID == id {
+			session.LastSeenAt = lastSeenAt
+		}
+	}
+
+	return nil
+}
+
+func (r *stubRepository) Revoke(_ context.Context, userID uint64, ids []uuid.UUID, revokedAt time.Time) error {
+	for _, session := range r.sessions {
+		for _, id := range ids {
+			if session.ID == id && session.UserID == userID && session.RevokedAt == nil {
+				session.RevokedAt = &revokedAt
+			}
+		}
+	}
+
+	return nil
+}
+
+func TestService_GetActiveSessions(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubRepository{}
+	svc := NewService(repo, time.Hour)
+
+	current, err := svc.Start(ctx, 1, "Firefox", "127.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = svc.Start(ctx, 1, "Chrome", "10.0.0.1")
+	assert.NoError(t, err)
+
+	_, err = svc.Start(ctx, 2, "Safari", "10.0.0.2")
+	assert.NoError(t, err)
+
+	idle, err := svc.Start(ctx, 1, "Edge", "10.0.0.3")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Touch(ctx, idle.ID, time.Now().Add(-2*time.Hour)))
+
+	sessions, err := svc.GetActiveSessions(ctx, 1, current.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	for _, session := range sessions {
+		assert.Equal(t, session.ID == current.ID, session.Current)
+	}
+}
+
+func TestService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubRepository{}
+	svc := NewService(repo, time.Hour)
+
+	session, err := svc.Start(ctx, 1, "Firefox", "127.0.0.1")
+	assert.NoError(t, err)
+
+	err = svc.Revoke(ctx, 2, session.ID)
+	assert.ErrorIs(t, err, models.ErrNoRecord)
+
+	assert.NoError(t, svc.Revoke(ctx, 1, session.ID))
+
+	assert.NotNil(t, repo.sessions[0].RevokedAt)
+
+	err = svc.Revoke(ctx, 1, session.ID)
+	assert.ErrorIs(t, err, models.ErrNoRecord)
+}
+
+func TestService_RevokeOthers(t *testing.T) {
+	ctx := context.Background()
+	repo := &stubRepository{}
+	svc := NewService(repo, time.Hour)
+
+	current, err := svc.Start(ctx, 1, "Firefox", "127.0.0.1")
+	assert.NoError(t, err)
+
+	other, err := svc.Start(ctx, 1, "Chrome", "10.0.0.1")
+	assert.NoError(t, err)
+
+	revoked, err := svc.RevokeOthers(ctx, 1, current.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{other.ID}, revoked)
+
+	assert.Nil(t, repo.sessions[0].RevokedAt)
+
+	revoked, err = svc.RevokeOthers(ctx, 1, current.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, revoked)
+
+	revoked, err = svc.RevokeAll(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{current.ID}, revoked)
+}
@@ -0,0 +1,21 @@
+package twofactor
+
+import (
+	"chatapp/pkg/models"
+	"context"
+	"time"
+)
+
+// Repository provides an interface for interacting with the database.
+type Repository interface {
+	Find(ctx context.Context, userID uint64) (*models.TwoFactor, error)
+	// Save stores the unconfirmed models.TwoFactor, replacing any earlier one
+	Save(ctx context.Context, twoFactor *models.TwoFactor) error
+	// Confirm enables the models.TwoFactor and replaces the recovery codes with the hashes provided
+	Confirm(ctx context.Context, userID uint64, confirmedAt time.Time, recoveryCodeHashes []string) error
+	// UseStep records the TOTP step used, it returns false if a code for the step or a later one was already used
+	UseStep(ctx context.Context, userID uint64, step int64) (bool, error)
+	// UseRecoveryCode marks the recovery code as used, it returns false if it does not exist or was already used
+	UseRecoveryCode(ctx context.Context, userID uint64, codeHash string, usedAt time.Time) (bool, error)
+	Delete(ctx context.Context, userID uint64) error
+}
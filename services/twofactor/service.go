@@ -0,0 +1,242 @@
+package twofactor
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/totp"
+	"chatapp/pkg/util"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// recoveryCodesCount is the number of recovery codes generated when two-factor authentication is confirmed
+	recoveryCodesCount = 10
+
+	// recoveryCodeBytes is the number of random bytes in a recovery code
+	recoveryCodeBytes = 5
+)
+
+var (
+	// ErrAlreadyEnabled is returned when enrolling a models.User who already has two-factor authentication enabled
+	ErrAlreadyEnabled = errors.New("twofactor: two-factor authentication is already enabled")
+
+	// ErrNotEnrolled is returned when confirming two-factor authentication that has not been set up
+	ErrNotEnrolled = errors.New("twofactor: two-factor authentication has not been set up")
+
+	// ErrNotEnabled is returned when using two-factor authentication that has not been enabled
+	ErrNotEnabled = errors.New("twofactor: two-factor authentication is not enabled")
+
+	// ErrInvalidCode is returned when the code is wrong, expired or has already been used
+	ErrInvalidCode = errors.New("twofactor: invalid code")
+)
+
+// service allows interaction with the Repository
+type service struct {
+	repo      Repository
+	encrypter *util.Encrypter
+	issuer    string
+}
+
+// generateRecoveryCodes returns new recovery codes formatted as xxxx-xxxx
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeBytes)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("twofactor.generateRecoveryCodes:: error generating code - %v", err)
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:]
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode returns the hash the recovery code is stored as, ignoring case and formatting
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return util.HashToken(code)
+}
+
+// Enroll starts setting up two-factor authentication for the models.User. The secret has to be added to an
+// authenticator app and confirmed before it is enabled.
+func (s *service) Enroll(ctx context.Context, user *models.User) (*models.TwoFactorEnrollment, error) {
+	existing, err := s.repo.Find(ctx, user.ID)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	}
+
+	if existing != nil && existing.IsEnabled() {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSecret, err := s.encrypter.Encrypt([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.Save(ctx, &models.TwoFactor{
+		UserID:    user.ID,
+		Secret:    encryptedSecret,
+		CreatedAt: time.Now(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// verifyTOTP checks the code against the secret and makes sure it cannot be used again
+func (s *service) verifyTOTP(ctx context.Context, twoFactor *models.TwoFactor, code string) error {
+	secret, err := s.encrypter.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(string(secret), code, time.Now())
+	if !ok || step <= twoFactor.LastUsedStep {
+		return ErrInvalidCode
+	}
+
+	used, err := s.repo.UseStep(ctx, twoFactor.UserID, step)
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+// Confirm enables two-factor authentication once the models.User proves their authenticator app works. It returns
+// the recovery codes which can be used in place of a code if the authenticator app is lost.
+func (s *service) Confirm(ctx context.Context, userID uint64, code string) ([]string, error) {
+	twoFactor, err := s.repo.Find(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, ErrNotEnrolled
+		}
+
+		return nil, err
+	}
+
+	if twoFactor.IsEnabled() {
+		return nil, ErrAlreadyEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := s.repo.Confirm(ctx, userID, time.Now(), hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify checks the code from the authenticator app or a recovery code. Codes can only be used once.
+func (s *service) Verify(ctx context.Context, userID uint64, code string) error {
+	twoFactor, err := s.repo.Find(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return ErrNotEnabled
+		}
+
+		return err
+	}
+
+	if !twoFactor.IsEnabled() {
+		return ErrNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(ctx, twoFactor, code)
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+// Disable turns off two-factor authentication after verifying the code
+func (s *service) Disable(ctx context.Context, userID uint64, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, userID)
+}
+
+// IsEnabled checks if the models.User has two-factor authentication enabled
+func (s *service) IsEnabled(ctx context.Context, userID uint64) (bool, error) {
+	twoFactor, err := s.repo.Find(ctx, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return twoFactor.IsEnabled(), nil
+}
+
+// Service provides an interface for interacting with the repository
+type Service interface {
+	Enroll(ctx context.Context, user *models.User) (*models.TwoFactorEnrollment, error)
+	Confirm(ctx context.Context, userID uint64, code string) ([]string, error)
+	Verify(ctx context.Context, userID uint64, code string) error
+	Disable(ctx context.Context, userID uint64, code string) error
+	IsEnabled(ctx context.Context, userID uint64) (bool, error)
+}
+
+// NewService creates a new Service. Secrets are stored encrypted and the issuer is the name shown in
+// authenticator apps.
+func NewService(repo Repository, encrypter *util.Encrypter, issuer string) Service {
+	return &service{
+		repo:      repo,
+		encrypter: encrypter,
+		issuer:    issuer,
+	}
+}
@@ -0,0 +1,174 @@
+package twofactor
+
+import (
+	"chatapp/pkg/models"
+	"chatapp/pkg/totp"
+	"chatapp/pkg/util"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// stubRepository keeps the two-factor set ups in memory
+type stubRepository struct {
+	twoFactors    map[uint64]*models.TwoFactor
+	recoveryCodes map[uint64]map[string]bool
+}
+
+func newStubRepository() *stubRepository {
+	return &stubRepository{
+		twoFactors:    make(map[uint64]*models.TwoFactor),
+		recoveryCodes: make(map[uint64]map[string]bool),
+	}
+}
+
+func (r *stubRepository) Find(_ context.Context, userID uint64) (*models.TwoFactor, error) {
+	twoFactor, ok := r.twoFactors[userID]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	found := *twoFactor
+	return &found, nil
+}
+
+func (r *stubRepository) Save(_ context.Context, twoFactor *models.TwoFactor) error {
+	r.twoFactors[twoFactor.UserID] = twoFactor
+	return nil
+}
+
+func (r *stubRepository) Confirm(_ context.Context, userID uint64, confirmedAt time.Time, hashes []string) error {
+	r.twoFactors[userID].ConfirmedAt = &confirmedAt
+	r.recoveryCodes[userID] = make(map[string]bool)
+
+	for _, hash := range hashes {
+		r.recoveryCodes[userID][hash] = false
+	}
+
+	return nil
+}
+
+func (r *stubRepository) UseStep(_ context.Context, userID uint64, step int64) (bool, error) {
+	twoFactor := r.twoFactors[userID]
+	if twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+
+	twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *stubRepository) UseRecoveryCode(_ context.Context, userID uint64, codeHash string, _ time.Time) (bool, error) {
+	used, ok := r.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+
+	r.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func (r *stubRepository) Delete(_ context.Context, userID uint64) error {
+	delete(r.twoFactors, userID)
+	delete(r.recoveryCodes, userID)
+	return nil
+}
+
+func newTestService(t *testing.T) (Service, *stubRepository) {
+	encrypter, err := util.NewEncrypter("01234567890123456789012345678901")
+	assert.NoError(t, err)
+
+	repo := newStubRepository()
+
+	return NewService(repo, encrypter, "Chat App"), repo
+}
+
+// enroll sets up two-factor authentication for the user and returns the secret and the recovery codes
+func enroll(t *testing.T, svc Service, user *models.User) (string, []string) {
+	ctx := context.Background()
+
+	enrollment, err := svc.Enroll(ctx, user)
+	assert.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	assert.NoError(t, err)
+
+	recoveryCodes, err := svc.Confirm(ctx, user.ID, code)
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes, recoveryCodesCount)
+
+	return enrollment.Secret, recoveryCodes
+}
+
+func TestService_Enroll(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newTestService(t)
+	user := &models.User{ID: 1, Username: "jwambugu"}
+
+	enabled, err := svc.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	enrollment, err := svc.Enroll(ctx, user)
+	assert.NoError(t, err)
+	assert.NotEqual(t, enrollment.Secret, repo.twoFactors[user.ID].Secret, "the secret is stored encrypted")
+
+	enabled, err = svc.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, enabled, "two-factor authentication is not enabled until it is confirmed")
+
+	_, err = svc.Confirm(ctx, user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	_, err = svc.Confirm(ctx, 2, "000000")
+	assert.ErrorIs(t, err, ErrNotEnrolled)
+
+	enroll(t, svc, user)
+
+	enabled, err = svc.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, err = svc.Enroll(ctx, user)
+	assert.ErrorIs(t, err, ErrAlreadyEnabled)
+}
+
+func TestService_Verify(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t)
+	user := &models.User{ID: 1, Username: "jwambugu"}
+
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, "000000"), ErrNotEnabled)
+
+	secret, recoveryCodes := enroll(t, svc, user)
+
+	code, err := totp.Code(secret, time.Now())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, code), ErrInvalidCode, "the confirmation code cannot be reused")
+
+	nextCode, err := totp.Code(secret, time.Now().Add(totp.Period))
+	assert.NoError(t, err)
+	assert.NoError(t, svc.Verify(ctx, user.ID, nextCode))
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, nextCode), ErrInvalidCode)
+
+	assert.NoError(t, svc.Verify(ctx, user.ID, recoveryCodes[0]))
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, recoveryCodes[0]), ErrInvalidCode)
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, "abcd-efgh"), ErrInvalidCode)
+}
+
+func TestService_Disable(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t)
+	user := &models.User{ID: 1, Username: "jwambugu"}
+
+	_, recoveryCodes := enroll(t, svc, user)
+
+	assert.ErrorIs(t, svc.Disable(ctx, user.ID, "abcd-efgh"), ErrInvalidCode)
+	assert.NoError(t, svc.Disable(ctx, user.ID, recoveryCodes[1]))
+
+	enabled, err := svc.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+}
@@ -2,7 +2,9 @@ package user
 
 import (
 	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
 	"context"
+	"time"
 )
 
 // Repository provides an interface for interacting with the database.
@@ -10,6 +12,11 @@ type Repository interface {
 	Create(ctx context.Context, user *models.User) (*models.User, error)
 	FindByID(ctx context.Context, id uint64) (*models.User, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
-	CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error)
+	Exists(ctx context.Context, filters ...models.Filter) (bool, error)
 	GetIDAndPassword(ctx context.Context, username string) (*models.User, error)
+	GetPassword(ctx context.Context, id uint64) (string, error)
+	UpdatePassword(ctx context.Context, id uint64, password string, updatedAt time.Time) error
+	// Search fetches one user more than params.Limit so that the caller can tell if there are more to page through
+	Search(ctx context.Context, query string, params pagination.Params) ([]models.User, error)
+	UpdateProfile(ctx context.Context, id uint64, profile models.UserProfile, updatedAt time.Time) error
 }
@@ -2,7 +2,10 @@ package user
 
 import (
 	"chatapp/pkg/models"
+	"chatapp/pkg/pagination"
 	"context"
+	"strings"
+	"time"
 )
 
 // service allows interaction with the Repository
@@ -10,6 +13,12 @@ type service struct {
 	repo Repository
 }
 
+// Page is a page of users ordered by id. Cursors.Next points to the next page and Cursors.Prev is never set.
+type Page struct {
+	Users   []models.User      `json:"users"`
+	Cursors pagination.Cursors `json:"cursors"`
+}
+
 // Create inserts a new user record
 func (s *service) Create(ctx context.Context, user *models.User) (*models.User, error) {
 	return s.repo.Create(ctx, user)
@@ -25,9 +34,9 @@ func (s *service) FindByUsername(ctx context.Context, username string) (*models.
 	return s.repo.FindByUsername(ctx, username)
 }
 
-// CheckIfExists looks up if a given column exists
-func (s *service) CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error) {
-	return s.repo.CheckIfExists(ctx, column, value)
+// Exists checks if a models.User matching every filter exists, it is used to check unique fields are not taken
+func (s *service) Exists(ctx context.Context, filters ...models.Filter) (bool, error) {
+	return s.repo.Exists(ctx, filters...)
 }
 
 // GetIDAndPassword returns the id and password for the user to be user for logging in
@@ -35,13 +44,53 @@ func (s *service) GetIDAndPassword(ctx context.Context, username string) (*model
 	return s.repo.GetIDAndPassword(ctx, username)
 }
 
+// GetPassword returns the hashed password for the user with the provided ID
+func (s *service) GetPassword(ctx context.Context, id uint64) (string, error) {
+	return s.repo.GetPassword(ctx, id)
+}
+
+// UpdatePassword replaces the user password with the hashed password provided
+func (s *service) UpdatePassword(ctx context.Context, id uint64, password string) error {
+	return s.repo.UpdatePassword(ctx, id, password, time.Now().Local())
+}
+
+// Search returns a Page of the users whose username or display name starts with the query, or every user if the
+// query is empty. Only params.After is used since users are paged through in one direction.
+func (s *service) Search(ctx context.Context, query string, params pagination.Params) (*Page, error) {
+	users, err := s.repo.Search(ctx, strings.TrimSpace(query), params)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{
+		Users: users,
+	}
+
+	// The repository fetches an extra user to tell if there are more to page through
+	if len(users) > params.Limit {
+		page.Users = users[:params.Limit]
+		page.Cursors.Next = pagination.EncodeCursor(page.Users[len(page.Users)-1].ID)
+	}
+
+	return page, nil
+}
+
+// UpdateProfile replaces the user profile with the one provided
+func (s *service) UpdateProfile(ctx context.Context, id uint64, profile models.UserProfile) error {
+	return s.repo.UpdateProfile(ctx, id, profile, time.Now().Local())
+}
+
 // Service provides an interface for interacting with the repository
 type Service interface {
 	Create(ctx context.Context, user *models.User) (*models.User, error)
 	FindByID(ctx context.Context, id uint64) (*models.User, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
-	CheckIfExists(ctx context.Context, column string, value interface{}) (bool, error)
+	Exists(ctx context.Context, filters ...models.Filter) (bool, error)
 	GetIDAndPassword(ctx context.Context, username string) (*models.User, error)
+	GetPassword(ctx context.Context, id uint64) (string, error)
+	UpdatePassword(ctx context.Context, id uint64, password string) error
+	UpdateProfile(ctx context.Context, id uint64, profile models.UserProfile) error
+	Search(ctx context.Context, query string, params pagination.Params) (*Page, error)
 }
 
 // NewService creates a new Service